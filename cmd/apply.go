@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/uselagoon/build-deploy-tool/internal/apply"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"k8s.io/client-go/dynamic"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply the templates for a Lagoon build",
+	Long: `Render the templates for a Lagoon build and apply them to the environment namespace using server-side apply.
+Objects are applied in dependency order, secrets, configmaps, pvcs, dbaas consumers, services, deployments, cronjobs, then ingress.
+The sections of templates to apply can be limited using the templates flag, the sections are the same as the directories
+that are created by 'template all'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k8upVersion, err := cmd.Flags().GetString("version")
+		if err != nil {
+			return fmt.Errorf("error reading version flag: %v", err)
+		}
+		sections, err := cmd.Flags().GetStringSlice("templates")
+		if err != nil {
+			return fmt.Errorf("error reading templates flag: %v", err)
+		}
+		gen, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		images, err := rootCmd.PersistentFlags().GetString("images")
+		if err != nil {
+			return fmt.Errorf("error reading images flag: %v", err)
+		}
+		if images != "" {
+			imageRefs, err := loadImagesFromFile(images)
+			if err != nil {
+				return err
+			}
+			gen.ImageReferences = imageRefs.Images
+		}
+		gen.BackupConfiguration.K8upVersion = k8upVersion
+		restCfg, err := lagoon.GetConfig()
+		if err != nil {
+			return err
+		}
+		client, err := lagoon.GetDynamicClient(restCfg)
+		if err != nil {
+			return fmt.Errorf("unable to create client: %v", err)
+		}
+		results, err := ApplyTemplates(gen, sections, client)
+		for _, r := range results {
+			fmt.Println(r.String())
+		}
+		return err
+	},
+}
+
+// ApplyTemplates renders the requested sections of templates in memory and applies them to the environment namespace
+func ApplyTemplates(g generator.GeneratorInput, sections []string, client dynamic.Interface) ([]apply.ObjectResult, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return nil, err
+	}
+	out := newMemoryOutput()
+	if err := writeTemplateSections(lagoonBuild, out, g.Debug, sections); err != nil {
+		return nil, err
+	}
+	applier := apply.NewApplier(client, lagoonBuild.BuildValues.Namespace, false)
+	return applier.Apply(context.TODO(), out.bundle.Objects())
+}
+
+func init() {
+	applyCmd.Flags().StringP("version", "", "v1", "The version of k8up used.")
+	applyCmd.Flags().StringSliceP("templates", "", nil,
+		"The sections of templates to apply (lagoon-env, autogen-routes, routes, dbaas, backup, service-deployments), defaults to all")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/apply"
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/prune"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+// newFakeApplyClient returns a fake dynamic client that handles server-side apply by creating or replacing the object,
+// the kinds that are pruned are registered so that they can be listed
+func newFakeApplyClient(objects ...runtime.Object) *fake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, gvk := range append(append([]schema.GroupVersionKind{}, prune.Kinds...), prune.PersistentVolumeClaimKind) {
+		listKinds[apply.GroupVersionResource(gvk)] = gvk.Kind + "List"
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pa := action.(k8stesting.PatchAction)
+		if pa.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(pa.GetPatch(), &obj.Object); err != nil {
+			return true, nil, err
+		}
+		tracker := client.Tracker()
+		_, err := tracker.Get(pa.GetResource(), pa.GetNamespace(), pa.GetName())
+		switch {
+		case apierrors.IsNotFound(err):
+			err = tracker.Create(pa.GetResource(), obj, pa.GetNamespace())
+		case err == nil:
+			err = tracker.Update(pa.GetResource(), obj, pa.GetNamespace())
+		}
+		if err != nil {
+			return true, nil, err
+		}
+		ret, err := tracker.Get(pa.GetResource(), pa.GetNamespace(), pa.GetName())
+		return true, ret, err
+	})
+	return client
+}
+
+func TestApplyTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     testdata.TestData
+		sections []string
+		want     []apply.ObjectResult
+		wantErr  bool
+	}{
+		{
+			name: "test1 - apply all templates",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			want: []apply.ObjectResult{
+				{Kind: "ConfigMap", Name: "lagoon-env", Namespace: "example-project-main", Result: apply.Created},
+				{Kind: "Service", Name: "node", Namespace: "example-project-main", Result: apply.Created},
+				{Kind: "Deployment", Name: "node", Namespace: "example-project-main", Result: apply.Created},
+				{Kind: "Ingress", Name: "example.com", Namespace: "example-project-main", Result: apply.Created},
+				{Kind: "Ingress", Name: "node", Namespace: "example-project-main", Result: apply.Created},
+			},
+		},
+		{
+			name: "test2 - apply only the routes",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+				}, true),
+			sections: []string{"routes"},
+			want: []apply.ObjectResult{
+				{Kind: "Ingress", Name: "example.com", Namespace: "example-project-main", Result: apply.Created},
+			},
+		},
+		{
+			name: "test3 - unknown section",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+				}, true),
+			sections: []string{"nothing"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := testdata.SetupEnvironment(*rootCmd, "", tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			got, err := ApplyTemplates(generator, tt.sections, newFakeApplyClient())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ApplyTemplates() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyTemplates() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+				helpers.UnsetEnvVars(tt.args.BuildPodVariables)
+			})
+		})
+	}
+}
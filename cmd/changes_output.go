@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+)
+
+// exitCodeChanges is the exit code used by commands that report changes when there are changes
+const exitCodeChanges = 2
+
+// changesSummary is the json output of a set of changes
+type changesSummary struct {
+	Added     int                `json:"added"`
+	Changed   int                `json:"changed"`
+	Deleted   int                `json:"deleted"`
+	Unchanged int                `json:"unchanged"`
+	Changes   []manifests.Change `json:"changes"`
+}
+
+func summarizeChanges(changes []manifests.Change) changesSummary {
+	summary := changesSummary{Changes: []manifests.Change{}}
+	for _, c := range changes {
+		switch c.Action {
+		case manifests.Added:
+			summary.Added++
+		case manifests.Changed:
+			summary.Changed++
+		case manifests.Deleted:
+			summary.Deleted++
+		case manifests.Unchanged:
+			summary.Unchanged++
+			continue
+		}
+		summary.Changes = append(summary.Changes, c)
+	}
+	return summary
+}
+
+// writeChanges writes the changes in the requested format, unchanged objects are only counted
+func writeChanges(w io.Writer, changes []manifests.Change, format string) error {
+	summary := summarizeChanges(changes)
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return fmt.Errorf("couldn't marshal changes: %v", err)
+		}
+		fmt.Fprintln(w, string(data))
+	case "", "text":
+		for _, c := range summary.Changes {
+			symbol := "~"
+			switch c.Action {
+			case manifests.Added:
+				symbol = "+"
+			case manifests.Deleted:
+				symbol = "-"
+			}
+			fmt.Fprintf(w, "%s %s %s\n", symbol, c.String(), c.Action)
+			if c.Diff != "" {
+				fmt.Fprintln(w, c.Diff)
+			}
+		}
+		fmt.Fprintf(w, "%d to add, %d to change, %d to delete, %d unchanged\n", summary.Added, summary.Changed, summary.Deleted, summary.Unchanged)
+	default:
+		return fmt.Errorf("unsupported format %s, must be one of text or json", format)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+var convertLagoonYml = &cobra.Command{
+	Use:   "lagoon-yml",
+	Short: "Convert a .lagoon.yml into a version 2 .lagoon.yml",
+	Long: `Convert a .lagoon.yml into a version 2 .lagoon.yml.
+The converted file is printed, or written to the file given with --output. Anything that could not be converted exactly,
+such as strings that were converted to booleans, unknown or deprecated fields that were dropped, or a polysite block that
+was merged for the project, is reported as a warning so that the result can be checked`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			return fmt.Errorf("error reading output flag: %v", err)
+		}
+		lagoonYAML, err := rootCmd.PersistentFlags().GetString("lagoon-yml")
+		if err != nil {
+			return fmt.Errorf("error reading lagoon-yml flag: %v", err)
+		}
+		projectName, err := rootCmd.PersistentFlags().GetString("project-name")
+		if err != nil {
+			return fmt.Errorf("error reading project-name flag: %v", err)
+		}
+		converted, warnings, err := ConvertLagoonYml(lagoonYAML, projectName)
+		if err != nil {
+			return err
+		}
+		writeConvertWarnings(os.Stderr, warnings)
+		if output == "" {
+			fmt.Print(string(converted))
+			return nil
+		}
+		if err := os.WriteFile(output, converted, 0644); err != nil {
+			return fmt.Errorf("couldn't write %v: %v", output, err)
+		}
+		return nil
+	},
+}
+
+// ConvertLagoonYml converts the .lagoon.yml into a version 2 .lagoon.yml
+func ConvertLagoonYml(lagoonYml, projectName string) ([]byte, lagoon.Warnings, error) {
+	data, err := os.ReadFile(lagoonYml)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't read %v: %v", lagoonYml, err)
+	}
+	converted, warnings, err := lagoon.ConvertLagoonYAML(lagoonYml, data, projectName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't convert %v: %v", lagoonYml, err)
+	}
+	return converted, warnings, nil
+}
+
+// writeConvertWarnings writes the warnings found while converting
+func writeConvertWarnings(w io.Writer, warnings lagoon.Warnings) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning.String())
+	}
+}
+
+func init() {
+	convertLagoonYml.Flags().StringP("output", "o", "",
+		"The file to write the converted .lagoon.yml to, it is printed if this is not set.")
+	convertCmd.AddCommand(convertLagoonYml)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// identifiedServiceKind is the kind given to the services from the output of 'identify lagoon-services' so that they can be
+// compared the same way as rendered objects
+const identifiedServiceKind = "LagoonService"
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the rendered output of two builds",
+	Long: `Compare the rendered output of two builds without accessing a cluster, for example the output before and after a change
+to a .lagoon.yml file, or the output of two versions of the build-deploy-tool.
+The left and right inputs can be a directory of templates, such as the saved templates path of 'template all', a yaml or json
+bundle created with 'template --output', or the json output of 'identify lagoon-services'.
+Objects are matched by kind, namespace, and name. The values in secrets and the lagoon-env configmap are masked in the diff.
+The command exits with 0 if there are no changes, 2 if there are changes,
+and 1 if there is an error`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		left, err := cmd.Flags().GetString("left")
+		if err != nil {
+			return fmt.Errorf("error reading left flag: %v", err)
+		}
+		right, err := cmd.Flags().GetString("right")
+		if err != nil {
+			return fmt.Errorf("error reading right flag: %v", err)
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return fmt.Errorf("error reading format flag: %v", err)
+		}
+		changes, err := DiffOutputs(left, right)
+		if err != nil {
+			return err
+		}
+		if err := writeChanges(os.Stdout, changes, format); err != nil {
+			return err
+		}
+		if manifests.HasChanges(changes) {
+			return exitWithCode(cmd, exitCodeChanges)
+		}
+		return nil
+	},
+}
+
+// DiffOutputs loads the rendered output of two builds and returns the changes between them
+func DiffOutputs(left, right string) ([]manifests.Change, error) {
+	leftObjects, err := loadDiffInput(left)
+	if err != nil {
+		return nil, err
+	}
+	rightObjects, err := loadDiffInput(right)
+	if err != nil {
+		return nil, err
+	}
+	return manifests.Compare(leftObjects, rightObjects)
+}
+
+// loadDiffInput loads the objects from a directory or bundle, or the services from the output of 'identify lagoon-services'
+func loadDiffInput(path string) ([]unstructured.Unstructured, error) {
+	if path == "" {
+		return nil, fmt.Errorf("left and right must both be provided")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %v", path, err)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read file %v: %v", path, err)
+		}
+		// the output of identify lagoon-services is a json array, which is never a valid bundle
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+			return identifiedServiceObjects(data)
+		}
+	}
+	return manifests.Load(path)
+}
+
+// identifiedServiceObjects converts the output of 'identify lagoon-services' into objects
+func identifiedServiceObjects(data []byte) ([]unstructured.Unstructured, error) {
+	services := []identifyServices{}
+	if err := json.Unmarshal(data, &services); err != nil {
+		return nil, fmt.Errorf("couldn't decode lagoon services: %v", err)
+	}
+	objects := []unstructured.Unstructured{}
+	for _, s := range services {
+		sBytes, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		spec := map[string]interface{}{}
+		if err := json.Unmarshal(sBytes, &spec); err != nil {
+			return nil, err
+		}
+		delete(spec, "name")
+		obj := unstructured.Unstructured{Object: map[string]interface{}{
+			"kind": identifiedServiceKind,
+			"spec": spec,
+		}}
+		obj.SetName(s.Name)
+		objects = append(objects, obj)
+	}
+	manifests.Sort(objects)
+	return objects, nil
+}
+
+func init() {
+	diffCmd.Flags().StringP("left", "", "", "The directory, bundle, or lagoon-services json to compare from")
+	diffCmd.Flags().StringP("right", "", "", "The directory, bundle, or lagoon-services json to compare to")
+	diffCmd.Flags().StringP("format", "", "text", "The format to display the changes in (text or json)")
+}
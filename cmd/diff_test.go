@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestDiffOutputs(t *testing.T) {
+	basic := func(image string) testdata.TestData {
+		return testdata.GetSeedData(
+			testdata.TestData{
+				ProjectName:     "example-project",
+				EnvironmentName: "main",
+				Branch:          "main",
+				LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+				ImageReferences: map[string]string{
+					"node": image,
+				},
+			}, true)
+	}
+	tests := []struct {
+		name        string
+		left        testdata.TestData
+		leftOutput  string
+		right       testdata.TestData
+		rightOutput string
+		want        map[string]manifests.Action
+	}{
+		{
+			name:        "test1 - directory and bundle of the same build have no changes",
+			left:        basic("harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8"),
+			leftOutput:  templateOutputDir,
+			right:       basic("harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8"),
+			rightOutput: templateOutputYAML,
+			want:        map[string]manifests.Action{},
+		},
+		{
+			name:        "test2 - changed image",
+			left:        basic("harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8"),
+			leftOutput:  templateOutputJSON,
+			right:       basic("harbor.example/example-project/main/node@sha256:e90daba405cbf33bab23fe8a021146811b2c258df5f2afe7dadc92c0778eef45"),
+			rightOutput: templateOutputDir,
+			want: map[string]manifests.Action{
+				"deployment/node": manifests.Changed,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err := os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			render := func(args testdata.TestData, output string) string {
+				dir := t.TempDir()
+				gen, err := testdata.SetupEnvironment(*rootCmd, dir, args)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+				gen.TemplateOutput = output
+				if err := AllTemplateGeneration(gen); err != nil {
+					t.Errorf("AllTemplateGeneration() error = %v", err)
+				}
+				switch output {
+				case templateOutputYAML:
+					return filepath.Join(dir, "bundle.yaml")
+				case templateOutputJSON:
+					return filepath.Join(dir, "bundle.json")
+				}
+				return dir
+			}
+			left := render(tt.left, tt.leftOutput)
+			right := render(tt.right, tt.rightOutput)
+			changes, err := DiffOutputs(left, right)
+			if err != nil {
+				t.Errorf("DiffOutputs() error = %v", err)
+				return
+			}
+			got := map[string]manifests.Action{}
+			for _, c := range changes {
+				if c.Action != manifests.Unchanged {
+					got[c.String()] = c.Action
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffOutputs() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+				helpers.UnsetEnvVars(tt.left.BuildPodVariables)
+			})
+		})
+	}
+}
+
+func TestDiffIdentifiedServices(t *testing.T) {
+	dir := t.TempDir()
+	left := filepath.Join(dir, "left.json")
+	right := filepath.Join(dir, "right.json")
+	if err := os.WriteFile(left, []byte(`[{"name":"node","type":"basic","containers":[{"name":"basic","ports":[{"port":3000}]}]},{"name":"solr","type":"solr"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(right, []byte(`[{"name":"node","type":"basic-persistent","containers":[{"name":"basic","ports":[{"port":3000}]}]},{"name":"redis","type":"redis"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := DiffOutputs(left, right)
+	if err != nil {
+		t.Fatalf("DiffOutputs() error = %v", err)
+	}
+	got := map[string]manifests.Action{}
+	for _, c := range changes {
+		got[c.String()] = c.Action
+	}
+	want := map[string]manifests.Action{
+		"lagoonservice/node":  manifests.Changed,
+		"lagoonservice/redis": manifests.Added,
+		"lagoonservice/solr":  manifests.Deleted,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffOutputs() = %v, want %v", got, want)
+	}
+
+	// check the json output contains the summary and only the changes
+	var out bytes.Buffer
+	if err := writeChanges(&out, changes, "json"); err != nil {
+		t.Fatalf("writeChanges() error = %v", err)
+	}
+	summary := changesSummary{}
+	if err := json.Unmarshal(out.Bytes(), &summary); err != nil {
+		t.Fatalf("couldn't unmarshal changes: %v", err)
+	}
+	if summary.Added != 1 || summary.Changed != 1 || summary.Deleted != 1 || len(summary.Changes) != 3 {
+		t.Errorf("writeChanges() = %v", out.String())
+	}
+}
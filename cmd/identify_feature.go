@@ -10,15 +10,18 @@ import (
 )
 
 var featureFlagIdentify = &cobra.Command{
-	Use:     "feature",
+	Use:     "feature <name>",
 	Aliases: []string{"f"},
 	Short:   "Identify if a feature flag has been enabled",
+	Long: `Identify if a feature flag has been enabled, the name is the feature flag without the LAGOON_FEATURE_FLAG_ prefix,
+for example ROOTLESS_WORKLOAD. Use 'identify feature-flags' to list every feature flag`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		generator, err := generator.GenerateInput(*rootCmd, false)
 		if err != nil {
 			return err
 		}
-		flagValue, err := IdentifyFeatureFlag(generator, "")
+		flagValue, err := IdentifyFeatureFlag(generator, args[0])
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+// identifyFeatureFlag is a feature flag from the registry along with the value it resolved to for an environment
+type identifyFeatureFlag struct {
+	Name        string   `json:"name"`
+	Value       string   `json:"value"`
+	Tier        string   `json:"tier"`
+	Variable    string   `json:"variable,omitempty"`
+	Default     string   `json:"default"`
+	Values      []string `json:"values,omitempty"`
+	Admin       bool     `json:"admin"`
+	Description string   `json:"description"`
+}
+
+var featureFlagsIdentify = &cobra.Command{
+	Use:     "feature-flags",
+	Aliases: []string{"ff"},
+	Short:   "Identify the value of every feature flag for a specific environment",
+	Long: `Identify the value of every feature flag that the build-deploy-tool knows about for a specific environment,
+and the tier that each value was resolved from.
+Feature flags are resolved from these tiers, in order
+  FORCE       - the LAGOON_FEATURE_FLAG_FORCE_<name> build variable
+  ENVIRONMENT - a LAGOON_FEATURE_FLAG_<name> project or environment variable
+  DEFAULT     - the LAGOON_FEATURE_FLAG_DEFAULT_<name> build variable
+Admin feature flags are only resolved from
+  ADMIN       - the ADMIN_LAGOON_FEATURE_FLAG_<name> build variable
+If no tier provides a value, the BUILTIN default is used`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return fmt.Errorf("error reading format flag: %v", err)
+		}
+		gen, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			return err
+		}
+		flags, err := IdentifyFeatureFlags(gen)
+		if err != nil {
+			return err
+		}
+		return writeFeatureFlags(os.Stdout, flags, format)
+	},
+}
+
+// IdentifyFeatureFlags resolves every registered feature flag for an environment
+func IdentifyFeatureFlags(g generator.GeneratorInput) ([]identifyFeatureFlag, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return nil, err
+	}
+	flags := []identifyFeatureFlag{}
+	for _, flag := range generator.FeatureFlags {
+		result := flag.Resolve(lagoonBuild.BuildValues.EnvironmentVariables, g.Debug)
+		flags = append(flags, identifyFeatureFlag{
+			Name:        flag.Name,
+			Value:       result.Value,
+			Tier:        result.Tier,
+			Variable:    result.Variable,
+			Default:     flag.Default,
+			Values:      flag.Values,
+			Admin:       flag.Admin,
+			Description: flag.Description,
+		})
+	}
+	return flags, nil
+}
+
+// writeFeatureFlags writes the resolved feature flags as a table or as json
+func writeFeatureFlags(w io.Writer, flags []identifyFeatureFlag, format string) error {
+	switch format {
+	case "json":
+		flagsBytes, err := json.MarshalIndent(flags, "", "  ")
+		if err != nil {
+			return fmt.Errorf("couldn't marshal feature flags: %v", err)
+		}
+		fmt.Fprintln(w, string(flagsBytes))
+	case "text":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tVALUE\tTIER\tVARIABLE\tDESCRIPTION")
+		for _, f := range flags {
+			variable := f.Variable
+			if variable == "" {
+				variable = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", f.Name, f.Value, f.Tier, variable, f.Description)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported format %s, must be text or json", format)
+	}
+	return nil
+}
+
+func init() {
+	identifyCmd.AddCommand(featureFlagsIdentify)
+	featureFlagsIdentify.Flags().StringP("format", "", "text", "The format to display the feature flags in (text or json)")
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestIdentifyFeatureFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args testdata.TestData
+		vars []helpers.EnvironmentVariable
+		want map[string]identifyFeatureFlag
+	}{
+		{
+			name: "test1 - flags from every tier",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					EnvironmentType: "production",
+					LagoonYAML:      "internal/testdata/complex/lagoon.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{Name: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION", Value: "enabled", Scope: "build"},
+					},
+				}, true),
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_FORCE_ROOTLESS_WORKLOAD", Value: "enabled"},
+				{Name: "LAGOON_FEATURE_FLAG_DEFAULT_ISOLATION_NETWORK_POLICY", Value: "enabled"},
+				{Name: "ADMIN_LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_LIMIT", Value: "16Gi"},
+			},
+			want: map[string]identifyFeatureFlag{
+				"ROOTLESS_WORKLOAD": {
+					Value:    "enabled",
+					Tier:     generator.FeatureFlagTierForce,
+					Variable: "LAGOON_FEATURE_FLAG_FORCE_ROOTLESS_WORKLOAD",
+				},
+				"SPOT_INSTANCE_PRODUCTION": {
+					Value:    "enabled",
+					Tier:     generator.FeatureFlagTierEnvironment,
+					Variable: "LAGOON_FEATURE_FLAG_SPOT_INSTANCE_PRODUCTION",
+				},
+				"ISOLATION_NETWORK_POLICY": {
+					Value:    "enabled",
+					Tier:     generator.FeatureFlagTierDefault,
+					Variable: "LAGOON_FEATURE_FLAG_DEFAULT_ISOLATION_NETWORK_POLICY",
+				},
+				"CONTAINER_MEMORY_LIMIT": {
+					Value:    "16Gi",
+					Tier:     generator.FeatureFlagTierAdmin,
+					Variable: "ADMIN_LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_LIMIT",
+				},
+				"RWX_TO_RWO": {
+					Value: "disabled",
+					Tier:  generator.FeatureFlagTierBuiltin,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err := os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			gen, err := testdata.SetupEnvironment(*rootCmd, "", tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			for _, envVar := range tt.vars {
+				err = os.Setenv(envVar.Name, envVar.Value)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+			}
+			got, err := IdentifyFeatureFlags(gen)
+			if err != nil {
+				t.Errorf("IdentifyFeatureFlags() error = %v", err)
+				return
+			}
+			if len(got) != len(generator.FeatureFlags) {
+				t.Errorf("IdentifyFeatureFlags() returned %d flags, want %d", len(got), len(generator.FeatureFlags))
+			}
+			for _, f := range got {
+				want, ok := tt.want[f.Name]
+				if !ok {
+					continue
+				}
+				if f.Value != want.Value || f.Tier != want.Tier || f.Variable != want.Variable {
+					t.Errorf("IdentifyFeatureFlags() %s = %v/%v/%v, want %v/%v/%v", f.Name, f.Value, f.Tier, f.Variable, want.Value, want.Tier, want.Variable)
+				}
+			}
+
+			// every feature flag that the generator consults must be in the registry
+			lagoonBuild, err := generator.NewGenerator(gen)
+			if err != nil {
+				t.Errorf("NewGenerator() error = %v", err)
+				return
+			}
+			for _, result := range lagoonBuild.BuildValues.FeatureFlagResults {
+				if _, ok := generator.GetFeatureFlag(result.Name); !ok {
+					t.Errorf("feature flag %s is consulted by the generator but is not registered", result.Name)
+				}
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(tt.vars)
+				helpers.UnsetEnvVars(tt.args.BuildPodVariables)
+			})
+		})
+	}
+}
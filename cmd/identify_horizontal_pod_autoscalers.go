@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	servicestemplates "github.com/uselagoon/build-deploy-tool/internal/templating/services"
+)
+
+var horizontalPodAutoscalersIdentify = &cobra.Command{
+	Use:     "horizontal-pod-autoscalers",
+	Aliases: []string{"hpa"},
+	Short:   "Identify the horizontal pod autoscalers for a specific environment",
+	Long: `Identify the horizontal pod autoscalers for a specific environment, as a JSON list of names.
+Any horizontal pod autoscaler in the environment that isn't in the list is from a service that no longer autoscales`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			return err
+		}
+		hpas, err := IdentifyHorizontalPodAutoscalers(generator)
+		if err != nil {
+			return err
+		}
+		fmt.Println(hpas)
+		return nil
+	},
+}
+
+// IdentifyHorizontalPodAutoscalers returns the names of the horizontal pod autoscalers of the services as a JSON list
+func IdentifyHorizontalPodAutoscalers(g generator.GeneratorInput) (string, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return "", err
+	}
+	hpas, err := servicestemplates.GenerateHPATemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return "", fmt.Errorf("couldn't generate template: %v", err)
+	}
+	names := []string{}
+	for _, hpa := range hpas {
+		names = append(names, hpa.Name)
+	}
+	namesBytes, _ := json.Marshal(names)
+
+	return string(namesBytes), nil
+}
+
+func init() {
+	identifyCmd.AddCommand(horizontalPodAutoscalersIdentify)
+}
@@ -29,6 +29,10 @@ var lagoonServiceIdentify = &cobra.Command{
 	Use:     "lagoon-services",
 	Aliases: []string{"ls"},
 	Short:   "Identify the lagoon services for a Lagoon build",
+	Long: `Identify the lagoon services for a Lagoon build, and the containers and ports of each, as a json list.
+Earlier versions printed the services in go format, for example [{nginx nginx-php [{nginx [{8080}]} {php [{9000}]}]}],
+anything that parses that output needs to parse the json instead, for example
+[{"name":"nginx","type":"nginx-php","containers":[{"name":"nginx","ports":[{"port":8080}]},{"name":"php","ports":[{"port":9000}]}]}]`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		gen, err := generator.GenerateInput(*rootCmd, true)
 		if err != nil {
@@ -53,7 +57,11 @@ var lagoonServiceIdentify = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		fmt.Println(out)
+		outJSON, err := json.Marshal(out)
+		if err != nil {
+			return fmt.Errorf("couldn't marshal lagoon services: %v", err)
+		}
+		fmt.Println(string(outJSON))
 		return nil
 	},
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+type lagoonYmlIdentifyJSON struct {
+	DockerComposeYAML string                   `json:"dockerComposeYaml"`
+	GitSHA            bool                     `json:"gitSha"`
+	Environment       string                   `json:"environment"`
+	Types             map[string]string        `json:"types"`
+	FastlyAPISecrets  []lagoon.FastlyAPISecret `json:"fastlyApiSecrets"`
+}
+
+var lagoonYmlIdentify = &cobra.Command{
+	Use:     "lagoon-yml",
+	Aliases: []string{"lyml", "ly"},
+	Short:   "Identify the values of the .lagoon.yml that the build uses",
+	Long: `Identify the values of the .lagoon.yml that the build uses, after the override file and the LAGOON_YAML_OVERRIDE
+variable have been merged. Both versions of the .lagoon.yml are read, so the build doesn't need to know which keys the
+file uses. The values are printed as JSON
+  - dockerComposeYaml, the path to the docker-compose.yml
+  - gitSha, if the git sha should be injected into the environment
+  - environment, the key of the environments block that the environment being built uses, empty if there is none
+  - types, the service types from that environments block
+  - fastlyApiSecrets, the fastly api secrets from the fastly block`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lagoonYAML, err := rootCmd.PersistentFlags().GetString("lagoon-yml")
+		if err != nil {
+			return fmt.Errorf("error reading lagoon-yml flag: %v", err)
+		}
+		lagoonYAMLOverride, err := rootCmd.PersistentFlags().GetString("lagoon-yml-override")
+		if err != nil {
+			return fmt.Errorf("error reading lagoon-yml-override flag: %v", err)
+		}
+		projectName, err := rootCmd.PersistentFlags().GetString("project-name")
+		if err != nil {
+			return fmt.Errorf("error reading project-name flag: %v", err)
+		}
+		environmentNames, err := buildEnvironmentNames()
+		if err != nil {
+			return err
+		}
+		values, err := IdentifyLagoonYml(lagoonYAML, lagoonYAMLOverride, helpers.GetEnv("PROJECT", projectName, false), environmentNames)
+		if err != nil {
+			return err
+		}
+		valuesJSON, err := json.Marshal(values)
+		if err != nil {
+			return fmt.Errorf("couldn't marshal lagoon-yml values: %v", err)
+		}
+		fmt.Println(string(valuesJSON))
+		return nil
+	},
+}
+
+// IdentifyLagoonYml returns the values of the .lagoon.yml that the build uses for the environment with one of the names
+func IdentifyLagoonYml(lagoonYml, lagoonYmlOverride, projectName string, environmentNames []string) (lagoonYmlIdentifyJSON, error) {
+	lYAML := &lagoon.YAML{}
+	if err := generator.LoadAndUnmarshalLagoonYml(lagoonYml, lagoonYmlOverride, "LAGOON_YAML_OVERRIDE", lYAML, projectName, false, nil); err != nil {
+		return lagoonYmlIdentifyJSON{}, err
+	}
+	environment, environmentKey, err := lYAML.Environments.Resolve(environmentNames...)
+	if err != nil {
+		return lagoonYmlIdentifyJSON{}, fmt.Errorf("couldn't resolve the environment in the .lagoon.yml: %v", err)
+	}
+	values := lagoonYmlIdentifyJSON{
+		DockerComposeYAML: lYAML.DockerComposeYAML,
+		GitSHA:            lYAML.EnvironmentVariables.GitSHA != nil && *lYAML.EnvironmentVariables.GitSHA,
+		Environment:       environmentKey,
+		Types:             environment.Types,
+	}
+	if values.Types == nil {
+		values.Types = map[string]string{}
+	}
+	values.FastlyAPISecrets = []lagoon.FastlyAPISecret{}
+	if lYAML.Fastly != nil {
+		values.FastlyAPISecrets = append(values.FastlyAPISecrets, lYAML.Fastly.APISecrets...)
+	}
+	return values, nil
+}
+
+func init() {
+	identifyCmd.AddCommand(lagoonYmlIdentify)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestIdentifyLagoonYml(t *testing.T) {
+	tests := []struct {
+		name              string
+		lagoonYml         string
+		lagoonYmlOverride string
+		environmentNames  []string
+		want              lagoonYmlIdentifyJSON
+		wantErr           bool
+	}{
+		{
+			name:             "test1 - version 1",
+			lagoonYml:        "internal/testdata/identify-lagoon-yml/lagoon.yml",
+			environmentNames: []string{"main", "main"},
+			want: lagoonYmlIdentifyJSON{
+				DockerComposeYAML: "internal/testdata/basic/docker-compose.yml",
+				GitSHA:            true,
+				Environment:       "main",
+				Types:             map[string]string{"mariadb": "mariadb-single"},
+				FastlyAPISecrets: []lagoon.FastlyAPISecret{
+					{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+				},
+			},
+		},
+		{
+			name:             "test2 - version 2",
+			lagoonYml:        "internal/testdata/identify-lagoon-yml/lagoon.v2.yml",
+			environmentNames: []string{"main", "main"},
+			want: lagoonYmlIdentifyJSON{
+				DockerComposeYAML: "internal/testdata/basic/docker-compose.yml",
+				GitSHA:            true,
+				Environment:       "main",
+				Types:             map[string]string{"mariadb": "mariadb-single"},
+				FastlyAPISecrets: []lagoon.FastlyAPISecret{
+					{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+				},
+			},
+		},
+		{
+			name:             "test3 - version 2 with a pattern environment",
+			lagoonYml:        "internal/testdata/identify-lagoon-yml/lagoon.v2.yml",
+			environmentNames: []string{"feature/login", "feature-login"},
+			want: lagoonYmlIdentifyJSON{
+				DockerComposeYAML: "internal/testdata/basic/docker-compose.yml",
+				GitSHA:            true,
+				Environment:       "feature/*",
+				Types:             map[string]string{"mariadb": "mariadb-dbaas"},
+				FastlyAPISecrets: []lagoon.FastlyAPISecret{
+					{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+				},
+			},
+		},
+		{
+			name:              "test4 - version 1 with a version 2 override",
+			lagoonYml:         "internal/testdata/identify-lagoon-yml/lagoon.yml",
+			lagoonYmlOverride: "internal/testdata/identify-lagoon-yml/lagoon.override.yml",
+			environmentNames:  []string{"main", "main"},
+			want: lagoonYmlIdentifyJSON{
+				DockerComposeYAML: "internal/testdata/basic/docker-compose.yml",
+				GitSHA:            false,
+				Environment:       "main",
+				Types:             map[string]string{"mariadb": "mariadb-single", "redis": "redis-persistent"},
+				FastlyAPISecrets: []lagoon.FastlyAPISecret{
+					{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+					{Name: "exampleorg", APITokenVariableName: "FASTLY_API_TOKEN_ORG", PlatformTLSConfiguration: "B2cdFeGhI23fE353Tet"},
+				},
+			},
+		},
+		{
+			name:             "test5 - no environments block",
+			lagoonYml:        "internal/testdata/identify-lagoon-yml/lagoon.v2.yml",
+			environmentNames: []string{"develop", "develop"},
+			want: lagoonYmlIdentifyJSON{
+				DockerComposeYAML: "internal/testdata/basic/docker-compose.yml",
+				GitSHA:            true,
+				Types:             map[string]string{},
+				FastlyAPISecrets: []lagoon.FastlyAPISecret{
+					{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+				},
+			},
+		},
+		{
+			name:             "test6 - missing file",
+			lagoonYml:        "internal/testdata/identify-lagoon-yml/lagoon.missing.yml",
+			environmentNames: []string{"main", "main"},
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IdentifyLagoonYml(tt.lagoonYml, tt.lagoonYmlOverride, "example-project", tt.environmentNames)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IdentifyLagoonYml() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IdentifyLagoonYml() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	servicestemplates "github.com/uselagoon/build-deploy-tool/internal/templating/services"
+)
+
+var podDisruptionBudgetsIdentify = &cobra.Command{
+	Use:     "pod-disruption-budgets",
+	Aliases: []string{"pdb"},
+	Short:   "Identify the pod disruption budgets for a specific environment",
+	Long: `Identify the pod disruption budgets for a specific environment, as a JSON list of names.
+Any pod disruption budget in the environment that isn't in the list is from a service that no longer runs more than one
+replica, or the pod disruption budgets have been disabled with the POD_DISRUPTION_BUDGET feature flag`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generator, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			return err
+		}
+		pdbs, err := IdentifyPodDisruptionBudgets(generator)
+		if err != nil {
+			return err
+		}
+		fmt.Println(pdbs)
+		return nil
+	},
+}
+
+// IdentifyPodDisruptionBudgets returns the names of the pod disruption budgets of the services as a JSON list
+func IdentifyPodDisruptionBudgets(g generator.GeneratorInput) (string, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return "", err
+	}
+	pdbs, err := servicestemplates.GeneratePodDisruptionBudgetTemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return "", fmt.Errorf("couldn't generate template: %v", err)
+	}
+	names := []string{}
+	for _, pdb := range pdbs {
+		names = append(names, pdb.Name)
+	}
+	namesBytes, _ := json.Marshal(names)
+
+	return string(namesBytes), nil
+}
+
+func init() {
+	identifyCmd.AddCommand(podDisruptionBudgetsIdentify)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+var polysiteProjectsIdentify = &cobra.Command{
+	Use:     "polysite-projects",
+	Aliases: []string{"polysite", "pp"},
+	Short:   "Identify the polysite projects defined in a .lagoon.yml",
+	Long: `Identify the polysite projects defined in a .lagoon.yml, one per line.
+A polysite project is a top-level key in the .lagoon.yml that contains the .lagoon.yml configuration for that project`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		lagoonYAML, err := rootCmd.PersistentFlags().GetString("lagoon-yml")
+		if err != nil {
+			return fmt.Errorf("error reading lagoon-yml flag: %v", err)
+		}
+		projects, err := IdentifyPolysiteProjects(lagoonYAML)
+		if err != nil {
+			return err
+		}
+		for _, project := range projects {
+			fmt.Println(project)
+		}
+		return nil
+	},
+}
+
+// IdentifyPolysiteProjects returns the names of the polysite projects defined in a .lagoon.yml
+func IdentifyPolysiteProjects(lagoonYml string) ([]string, error) {
+	data, err := os.ReadFile(lagoonYml)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %v: %v", lagoonYml, err)
+	}
+	projects, err := lagoon.PolysiteProjects(data)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't identify polysite projects in %v: %v", lagoonYml, err)
+	}
+	return projects, nil
+}
+
+func init() {
+	identifyCmd.AddCommand(polysiteProjectsIdentify)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestIdentifyPolysiteProjects(t *testing.T) {
+	tests := []struct {
+		name      string
+		lagoonYml string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "test1 - polysite projects",
+			lagoonYml: "internal/testdata/validate-lagoon-yml/polysite/lagoon.yml",
+			want:      []string{"project-a", "project-b"},
+		},
+		{
+			name:      "test2 - no polysite projects",
+			lagoonYml: "internal/testdata/basic/lagoon.yml",
+			want:      []string{},
+		},
+		{
+			name:      "test3 - missing file",
+			lagoonYml: "internal/testdata/basic/lagoon.missing.yml",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IdentifyPolysiteProjects(tt.lagoonYml)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("IdentifyPolysiteProjects() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IdentifyPolysiteProjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
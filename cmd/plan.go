@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+	"github.com/uselagoon/build-deploy-tool/internal/plan"
+	"k8s.io/client-go/dynamic"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show what a Lagoon build would change in the environment",
+	Long: `Render all the templates for a Lagoon build and compare them against the live objects in the environment namespace.
+Objects that would be added, changed, or deleted by the build are displayed along with a diff of the changes. Fields that
+are populated by the api server, such as status and defaults, are ignored. Fields that a previous build set and the templates
+no longer set, like a removed label, are shown as removed, as the build removes them. The values in secrets and the lagoon-env configmap
+are never shown, the diff only shows if each key was added, changed, removed, or unchanged.
+The command exits with 0 if there are no changes, 2 if there are changes, and 1 if there is an error`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k8upVersion, err := cmd.Flags().GetString("version")
+		if err != nil {
+			return fmt.Errorf("error reading version flag: %v", err)
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return fmt.Errorf("error reading format flag: %v", err)
+		}
+		prunePVCs, err := cmd.Flags().GetBool("prune-persistent-volume-claims")
+		if err != nil {
+			return fmt.Errorf("error reading prune-persistent-volume-claims flag: %v", err)
+		}
+		gen, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			return err
+		}
+		images, err := rootCmd.PersistentFlags().GetString("images")
+		if err != nil {
+			return fmt.Errorf("error reading images flag: %v", err)
+		}
+		imageRefs, err := loadImagesFromFile(images)
+		if err != nil {
+			return err
+		}
+		gen.ImageReferences = imageRefs.Images
+		gen.BackupConfiguration.K8upVersion = k8upVersion
+		restCfg, err := lagoon.GetConfig()
+		if err != nil {
+			return err
+		}
+		client, err := lagoon.GetDynamicClient(restCfg)
+		if err != nil {
+			return fmt.Errorf("unable to create client: %v", err)
+		}
+		changes, err := PlanTemplates(gen, client, prunePVCs)
+		if err != nil {
+			return err
+		}
+		if err := writeChanges(os.Stdout, changes, format); err != nil {
+			return err
+		}
+		if manifests.HasChanges(changes) {
+			return exitWithCode(cmd, exitCodeChanges)
+		}
+		return nil
+	},
+}
+
+// PlanTemplates renders all the templates for a build in memory and returns the changes that the build would make to the
+// environment namespace
+func PlanTemplates(g generator.GeneratorInput, client dynamic.Interface, prunePVCs bool) ([]manifests.Change, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return nil, err
+	}
+	out := newMemoryOutput()
+	if err := writeTemplateSections(lagoonBuild, out, g.Debug, nil); err != nil {
+		return nil, err
+	}
+	planner := plan.NewPlanner(client, lagoonBuild.BuildValues.Namespace, prunePVCs, lagoonBuild.BuildValues.CleanupRemovedRoutes)
+	return planner.Plan(context.TODO(), out.bundle.Objects())
+}
+
+func init() {
+	planCmd.Flags().StringP("version", "", "v1", "The version of k8up used.")
+	planCmd.Flags().StringP("format", "", "text", "The format to display the changes in (text or json)")
+	planCmd.Flags().BoolP("prune-persistent-volume-claims", "", false,
+		"Include persistent volume claims that are no longer generated in the objects that would be deleted")
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestPlanTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		applied  testdata.TestData
+		args     testdata.TestData
+		existing []runtime.Object
+		want     map[string]manifests.Action
+	}{
+		{
+			name: "test1 - everything is added to an empty namespace",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			want: map[string]manifests.Action{
+				"configmap/lagoon-env": manifests.Added,
+				"service/node":         manifests.Added,
+				"deployment/node":      manifests.Added,
+				"ingress/example.com":  manifests.Added,
+				"ingress/node":         manifests.Added,
+			},
+		},
+		{
+			name: "test2 - no changes after the same build is applied",
+			applied: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			want: map[string]manifests.Action{
+				"configmap/lagoon-env": manifests.Unchanged,
+				"service/node":         manifests.Unchanged,
+				"deployment/node":      manifests.Unchanged,
+				"ingress/example.com":  manifests.Unchanged,
+				"ingress/node":         manifests.Unchanged,
+			},
+		},
+		{
+			name: "test3 - changed image and a removed redis deployment",
+			applied: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:e90daba405cbf33bab23fe8a021146811b2c258df5f2afe7dadc92c0778eef45",
+					},
+				}, true),
+			existing: []runtime.Object{
+				newPruneObject("apps/v1", "Deployment", "redis", "redis"),
+			},
+			want: map[string]manifests.Action{
+				"configmap/lagoon-env": manifests.Unchanged,
+				"service/node":         manifests.Unchanged,
+				"deployment/node":      manifests.Changed,
+				"deployment/redis":     manifests.Deleted,
+				"ingress/example.com":  manifests.Unchanged,
+				"ingress/node":         manifests.Unchanged,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err := os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			client := newFakeApplyClient(tt.existing...)
+			if tt.applied.ProjectName != "" {
+				generator, err := testdata.SetupEnvironment(*rootCmd, "", tt.applied)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+				if _, err := ApplyTemplates(generator, nil, client); err != nil {
+					t.Errorf("ApplyTemplates() error = %v", err)
+				}
+			}
+			generator, err := testdata.SetupEnvironment(*rootCmd, "", tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			changes, err := PlanTemplates(generator, client, false)
+			if err != nil {
+				t.Errorf("PlanTemplates() error = %v", err)
+				return
+			}
+			got := map[string]manifests.Action{}
+			for _, c := range changes {
+				got[c.String()] = c.Action
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanTemplates() = %v, want %v", got, tt.want)
+				for _, c := range changes {
+					if c.Action == manifests.Changed {
+						t.Errorf("%s\n%s", c, c.Diff)
+					}
+				}
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+				helpers.UnsetEnvVars(tt.args.BuildPodVariables)
+			})
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/prune"
+	"k8s.io/client-go/dynamic"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove resources that are no longer part of a Lagoon build",
+	Long: `Compare the resources that would be generated for a Lagoon build against the resources in the environment namespace
+and delete any that are no longer generated, such as routes, cronjobs, or services that have been removed from the .lagoon.yml
+or docker-compose file. Only resources labelled with app.kubernetes.io/managed-by=build-deploy-tool and lagoon.sh/service are
+considered, and anything labelled lagoon.sh/remove=false or acme.cert-manager.io/http01-solver=true is kept. Cronjobs, autoscalers,
+pod disruption budgets, deployments, services, and prebackuppods are pruned. Ingress are only pruned if the CLEANUP_REMOVED_LAGOON_ROUTES
+feature flag is enabled, and persistent volume claims are only pruned if explicitly requested as this deletes the data they contain`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k8upVersion, err := cmd.Flags().GetString("version")
+		if err != nil {
+			return fmt.Errorf("error reading version flag: %v", err)
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return fmt.Errorf("error reading dry-run flag: %v", err)
+		}
+		prunePVCs, err := cmd.Flags().GetBool("prune-persistent-volume-claims")
+		if err != nil {
+			return fmt.Errorf("error reading prune-persistent-volume-claims flag: %v", err)
+		}
+		gen, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		// the images are required, otherwise no deployments would be generated and they would all be pruned
+		images, err := rootCmd.PersistentFlags().GetString("images")
+		if err != nil {
+			return fmt.Errorf("error reading images flag: %v", err)
+		}
+		imageRefs, err := loadImagesFromFile(images)
+		if err != nil {
+			return err
+		}
+		gen.ImageReferences = imageRefs.Images
+		gen.BackupConfiguration.K8upVersion = k8upVersion
+		restCfg, err := lagoon.GetConfig()
+		if err != nil {
+			return err
+		}
+		client, err := lagoon.GetDynamicClient(restCfg)
+		if err != nil {
+			return fmt.Errorf("unable to create client: %v", err)
+		}
+		pruned, err := PruneTemplates(gen, client, dryRun, prunePVCs)
+		for _, p := range pruned {
+			if p.Retained {
+				fmt.Printf("%s would be pruned, set LAGOON_FEATURE_FLAG_CLEANUP_REMOVED_LAGOON_ROUTES=enabled as a GLOBAL scoped variable to remove it\n", p.String())
+				continue
+			}
+			if dryRun {
+				fmt.Printf("%s would be pruned (dry run)\n", p.String())
+			} else {
+				fmt.Printf("%s pruned\n", p.String())
+			}
+		}
+		return err
+	},
+}
+
+// PruneTemplates renders all the templates for a build in memory and prunes anything in the environment namespace that is
+// no longer generated
+func PruneTemplates(g generator.GeneratorInput, client dynamic.Interface, dryRun, prunePVCs bool) ([]prune.Object, error) {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return nil, err
+	}
+	out := newMemoryOutput()
+	if err := writeTemplateSections(lagoonBuild, out, g.Debug, nil); err != nil {
+		return nil, err
+	}
+	pruner := prune.NewPruner(client, lagoonBuild.BuildValues.Namespace, dryRun, prunePVCs, lagoonBuild.BuildValues.CleanupRemovedRoutes)
+	return pruner.Prune(context.TODO(), out.bundle.Objects())
+}
+
+func init() {
+	pruneCmd.Flags().StringP("version", "", "v1", "The version of k8up used.")
+	pruneCmd.Flags().BoolP("dry-run", "", false, "List the resources that would be pruned without deleting them")
+	pruneCmd.Flags().BoolP("prune-persistent-volume-claims", "", false,
+		"Also prune persistent volume claims, this will delete the data stored in them")
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/prune"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func newPruneObject(apiVersion, kind, name, service string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "example-project-main",
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/managed-by": "build-deploy-tool",
+				"lagoon.sh/service":            service,
+			},
+		},
+	}}
+	return obj
+}
+
+func TestPruneTemplates(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      testdata.TestData
+		existing  []runtime.Object
+		prunePVCs bool
+		want      []prune.Object
+	}{
+		{
+			name: "test1 - prune removed services and routes",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			existing: []runtime.Object{
+				newPruneObject("networking.k8s.io/v1", "Ingress", "example.com", "example.com"),
+				newPruneObject("networking.k8s.io/v1", "Ingress", "www.example.com", "www.example.com"),
+				newPruneObject("apps/v1", "Deployment", "node", "node"),
+				newPruneObject("v1", "Service", "node", "node"),
+				newPruneObject("apps/v1", "Deployment", "redis", "redis"),
+				newPruneObject("v1", "Service", "redis", "redis"),
+				newPruneObject("v1", "PersistentVolumeClaim", "redis", "redis"),
+			},
+			want: []prune.Object{
+				{Kind: "Ingress", Name: "www.example.com", Namespace: "example-project-main", Retained: true},
+				{Kind: "Deployment", Name: "redis", Namespace: "example-project-main"},
+				{Kind: "Service", Name: "redis", Namespace: "example-project-main"},
+			},
+		},
+		{
+			name: "test3 - prune removed routes when route cleanup is enabled",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_CLEANUP_REMOVED_LAGOON_ROUTES",
+							Value: "enabled",
+							Scope: "global",
+						},
+					},
+				}, true),
+			existing: []runtime.Object{
+				newPruneObject("networking.k8s.io/v1", "Ingress", "example.com", "example.com"),
+				newPruneObject("networking.k8s.io/v1", "Ingress", "www.example.com", "www.example.com"),
+				newPruneObject("apps/v1", "Deployment", "node", "node"),
+			},
+			want: []prune.Object{
+				{Kind: "Ingress", Name: "www.example.com", Namespace: "example-project-main"},
+			},
+		},
+		{
+			name: "test2 - prune persistent volume claims",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			existing: []runtime.Object{
+				newPruneObject("v1", "PersistentVolumeClaim", "redis", "redis"),
+			},
+			prunePVCs: true,
+			want: []prune.Object{
+				{Kind: "PersistentVolumeClaim", Name: "redis", Namespace: "example-project-main"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator, err := testdata.SetupEnvironment(*rootCmd, "", tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			client := newFakeApplyClient(tt.existing...)
+			got, err := PruneTemplates(generator, client, false, tt.prunePVCs)
+			if err != nil {
+				t.Errorf("PruneTemplates() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PruneTemplates() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+				helpers.UnsetEnvVars(tt.args.BuildPodVariables)
+			})
+		})
+	}
+}
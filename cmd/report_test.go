@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestBuildReport(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             testdata.TestData
+		wantServices     map[string]generator.ServiceReport
+		wantRoutes       map[string]string
+		wantFeatureFlags map[string]generator.FeatureFlagResult
+		wantWarnings     []string
+	}{
+		{
+			name: "test1 - dbaas fallback and forced feature flag",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/complex/lagoon.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{Name: "LAGOON_DBAAS_ENVIRONMENT_TYPES", Value: "mariadb:development2", Scope: "build"},
+						{Name: "LAGOON_FEATURE_FLAG_ISOLATION_NETWORK_POLICY", Value: "enabled", Scope: "build"},
+					},
+					BuildPodVariables: []helpers.EnvironmentVariable{
+						{Name: "LAGOON_FEATURE_FLAG_FORCE_ROOTLESS_WORKLOAD", Value: "enabled"},
+					},
+				}, true),
+			wantServices: map[string]generator.ServiceReport{
+				"mariadb": {
+					Name:                "mariadb",
+					OverrideName:        "mariadb",
+					Type:                "mariadb-single",
+					DBaaSEnvironment:    "development2",
+					DBaaSFallbackReason: "the DBaaS operator returned an error for mariadb in the development2 environment: no providers for dbaas environment development2",
+				},
+			},
+			wantRoutes: map[string]string{
+				"nginx-php-example-project-main.example.com": generator.RouteSourceAutogenerated,
+			},
+			wantFeatureFlags: map[string]generator.FeatureFlagResult{
+				"ROOTLESS_WORKLOAD": {
+					Name:     "ROOTLESS_WORKLOAD",
+					Value:    "enabled",
+					Tier:     generator.FeatureFlagTierForce,
+					Variable: "LAGOON_FEATURE_FLAG_FORCE_ROOTLESS_WORKLOAD",
+				},
+				"ISOLATION_NETWORK_POLICY": {
+					Name:     "ISOLATION_NETWORK_POLICY",
+					Value:    "enabled",
+					Tier:     generator.FeatureFlagTierEnvironment,
+					Variable: "LAGOON_FEATURE_FLAG_ISOLATION_NETWORK_POLICY",
+				},
+				"CONTAINER_MEMORY_LIMIT": {
+					Name: "CONTAINER_MEMORY_LIMIT",
+				},
+			},
+		},
+		{
+			name: "test2 - routes from the .lagoon.yml",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+				}, true),
+			wantRoutes: map[string]string{
+				"node-example-project-main.example.com": generator.RouteSourceAutogenerated,
+				"example.com":                           generator.RouteSourceLagoonYAML,
+			},
+		},
+		{
+			name: "test3 - lagoon.yml warnings",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/complex/lagoon.complex-1.yml",
+				}, true),
+			wantWarnings: []string{
+				"internal/testdata/complex/lagoon.complex-1.yml:12:3: routes.insecure: is deprecated and has no effect, use routes.autogenerate.insecure instead",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err := os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			gen, err := testdata.SetupEnvironment(*rootCmd, "", tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			gen.ReportFile = filepath.Join(t.TempDir(), "report.json")
+			if _, err := generator.NewGenerator(gen); err != nil {
+				t.Errorf("NewGenerator() error = %v", err)
+				return
+			}
+			reportBytes, err := os.ReadFile(gen.ReportFile)
+			if err != nil {
+				t.Errorf("couldn't read report: %v", err)
+				return
+			}
+			report := generator.BuildReport{}
+			if err := json.Unmarshal(reportBytes, &report); err != nil {
+				t.Errorf("couldn't unmarshal report: %v", err)
+				return
+			}
+			if report.Version != generator.BuildReportVersion {
+				t.Errorf("report version = %v, want %v", report.Version, generator.BuildReportVersion)
+			}
+			gotServices := map[string]generator.ServiceReport{}
+			for _, s := range report.Services {
+				gotServices[s.Name] = s
+			}
+			for name, want := range tt.wantServices {
+				if !reflect.DeepEqual(gotServices[name], want) {
+					t.Errorf("report service = %v, want %v", gotServices[name], want)
+				}
+			}
+			gotRoutes := map[string]string{}
+			for _, r := range report.Routes {
+				gotRoutes[r.Domain] = r.Source
+			}
+			for domain, source := range tt.wantRoutes {
+				if gotRoutes[domain] != source {
+					t.Errorf("report route %s source = %v, want %v", domain, gotRoutes[domain], source)
+				}
+			}
+			gotFlags := map[string]generator.FeatureFlagResult{}
+			for _, f := range report.FeatureFlags {
+				gotFlags[f.Name] = f
+			}
+			for name, want := range tt.wantFeatureFlags {
+				if !reflect.DeepEqual(gotFlags[name], want) {
+					t.Errorf("report feature flag = %v, want %v", gotFlags[name], want)
+				}
+			}
+			gotWarnings := []string{}
+			for _, w := range report.Warnings {
+				gotWarnings = append(gotWarnings, w.String())
+			}
+			if tt.wantWarnings != nil && !reflect.DeepEqual(gotWarnings, tt.wantWarnings) {
+				t.Errorf("report warnings = %v, want %v", gotWarnings, tt.wantWarnings)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+				helpers.UnsetEnvVars(tt.args.BuildPodVariables)
+			})
+		})
+	}
+}
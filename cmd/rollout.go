@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/rollout"
+	servicestemplates "github.com/uselagoon/build-deploy-tool/internal/templating/services"
+	"k8s.io/client-go/kubernetes"
+)
+
+var rolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "Monitor rollouts",
+	Long:  `Monitor the rollout of resources for Lagoon builds`,
+}
+
+// rolloutOptions are the options for monitoring the rollout of the deployments
+type rolloutOptions struct {
+	Timeout               time.Duration
+	ServiceTimeouts       map[string]time.Duration
+	LogLines              int64
+	ProbeFailureThreshold int32
+}
+
+var rolloutWait = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait for the deployments of a Lagoon build to roll out",
+	Long: `Wait for the deployments that are generated for a Lagoon build to complete their rollout.
+If a deployment fails to roll out, due to the progress deadline being exceeded, containers that are unable to start or pull
+their image, or probes that repeatedly fail, the events and the last lines of the container logs for the failing pods are
+displayed and the command exits with an error`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			return fmt.Errorf("error reading timeout flag: %v", err)
+		}
+		serviceTimeoutFlags, err := cmd.Flags().GetStringSlice("service-timeout")
+		if err != nil {
+			return fmt.Errorf("error reading service-timeout flag: %v", err)
+		}
+		serviceTimeouts, err := rollout.ParseServiceTimeouts(serviceTimeoutFlags)
+		if err != nil {
+			return err
+		}
+		logLines, err := cmd.Flags().GetInt64("log-lines")
+		if err != nil {
+			return fmt.Errorf("error reading log-lines flag: %v", err)
+		}
+		probeFailureThreshold, err := cmd.Flags().GetInt32("probe-failure-threshold")
+		if err != nil {
+			return fmt.Errorf("error reading probe-failure-threshold flag: %v", err)
+		}
+		gen, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		images, err := rootCmd.PersistentFlags().GetString("images")
+		if err != nil {
+			return fmt.Errorf("error reading images flag: %v", err)
+		}
+		imageRefs, err := loadImagesFromFile(images)
+		if err != nil {
+			return err
+		}
+		gen.ImageReferences = imageRefs.Images
+		restCfg, err := lagoon.GetConfig()
+		if err != nil {
+			return err
+		}
+		client, err := lagoon.GetK8sClient(restCfg)
+		if err != nil {
+			return fmt.Errorf("unable to create client: %v", err)
+		}
+		return RolloutWait(gen, client, rolloutOptions{
+			Timeout:               timeout,
+			ServiceTimeouts:       serviceTimeouts,
+			LogLines:              logLines,
+			ProbeFailureThreshold: probeFailureThreshold,
+		})
+	},
+}
+
+// RolloutWait generates the deployments for a build and waits for them to roll out in the environment namespace
+func RolloutWait(g generator.GeneratorInput, client kubernetes.Interface, opts rolloutOptions) error {
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	deployments, err := servicestemplates.GenerateDeploymentTemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	monitor := rollout.NewMonitor(client, lagoonBuild.BuildValues.Namespace)
+	monitor.Timeout = opts.Timeout
+	monitor.ServiceTimeouts = opts.ServiceTimeouts
+	monitor.LogLines = opts.LogLines
+	monitor.ProbeFailureThreshold = opts.ProbeFailureThreshold
+	return monitor.Wait(context.TODO(), deployments)
+}
+
+func init() {
+	rolloutCmd.AddCommand(rolloutWait)
+	rolloutWait.Flags().DurationP("timeout", "", rollout.DefaultTimeout,
+		"How long to wait for each deployment to roll out")
+	rolloutWait.Flags().StringSliceP("service-timeout", "", nil,
+		"Override the timeout for a service, in the format service=duration (eg, nginx=30m), can be provided multiple times")
+	rolloutWait.Flags().Int64P("log-lines", "", rollout.DefaultLogLines,
+		"The number of container log lines to display for failing pods")
+	rolloutWait.Flags().Int32P("probe-failure-threshold", "", rollout.DefaultProbeFailureThreshold,
+		"The number of times a probe can fail on a pod before the rollout is considered failed")
+}
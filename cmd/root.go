@@ -17,6 +17,7 @@ limitations under the License.
 */
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -60,15 +61,42 @@ var validateCmd = &cobra.Command{
 	Long:    `Validate resources for Lagoon builds`,
 }
 
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Convert resources",
+	Long:  `Convert resources for Lagoon builds into a newer format`,
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
+// exitCodeError is returned by commands that need to exit with a specific non-zero exit code that isn't an error, such as
+// plan and diff exiting with 2 when there are changes
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", e.code)
+}
+
+// exitWithCode returns an exitCodeError, silencing the error and usage output that cobra would print for it
+func exitWithCode(cmd *cobra.Command, code int) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &exitCodeError{code: code}
+}
+
 // version/build information (populated at build time by make file)
 var (
 	bdtName    = "build-deploy-tool"
@@ -99,6 +127,12 @@ func init() {
 	rootCmd.AddCommand(taskCmd)
 	rootCmd.AddCommand(identifyCmd)
 	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(rolloutCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(diffCmd)
 
 	rootCmd.PersistentFlags().StringP("lagoon-yml", "l", ".lagoon.yml",
 		"The .lagoon.yml file to read")
@@ -153,6 +187,10 @@ func init() {
 		"Ignore missing env_file files (true by default, subject to change).")
 	rootCmd.PersistentFlags().StringP("images", "", "",
 		"JSON representation of service:image reference")
+	rootCmd.PersistentFlags().StringP("report-file", "", "",
+		"If set, a JSON report of the resolved services, routes and feature flags is written to this file")
+	rootCmd.PersistentFlags().StringP("service-type-definitions", "", "",
+		"The file, or configmap directory, of additional service types provided by the cluster")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+)
+
+// the directories that `template all` will write into within the saved templates path
+// these match the directories the legacy build script uses for each of the individual template commands
+const (
+	allLagoonEnvDir          = "lagoon-env"
+	allAutogenRoutesDir      = "autogen-routes"
+	allRoutesDir             = "routes"
+	allDBaaSDir              = "dbaas"
+	allBackupDir             = "backup"
+	allServiceDeploymentsDir = "service-deployments"
+)
+
+// templateSections are all the sections of templates that are rendered for a build, in the order they are rendered
+var templateSections = []struct {
+	dir   string
+	write func(*generator.Generator, *templateOutput, bool) error
+}{
+	{dir: allLagoonEnvDir, write: writeLagoonEnvTemplates},
+	{dir: allAutogenRoutesDir, write: writeAutogeneratedIngressTemplates},
+	{dir: allRoutesDir, write: writeIngressTemplates},
+	{dir: allDBaaSDir, write: writeDBaaSTemplates},
+	{dir: allBackupDir, write: writeBackupTemplates},
+	{dir: allServiceDeploymentsDir, write: writeLagoonServiceTemplates},
+}
+
+var allGeneration = &cobra.Command{
+	Use:     "all",
+	Aliases: []string{"a"},
+	Short:   "Generate all the templates for a Lagoon build in a single pass",
+	Long: `Generate all the templates for a Lagoon build in a single pass of the generator.
+The resulting templates are written into the following directories within the saved templates path
+  lagoon-env          - the lagoon-env configmap template
+  autogen-routes      - the autogenerated ingress templates
+  routes              - the ingress templates from .lagoon.yml and the api
+  dbaas               - the dbaas consumer templates
+  backup              - the backup schedule and prebackuppod templates
+  service-deployments - the registry secrets, services, pvcs, deployments, cronjobs and networkpolicy templates`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		k8upVersion, err := cmd.Flags().GetString("version")
+		if err != nil {
+			return fmt.Errorf("error reading version flag: %v", err)
+		}
+		gen, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		images, err := rootCmd.PersistentFlags().GetString("images")
+		if err != nil {
+			return fmt.Errorf("error reading images flag: %v", err)
+		}
+		imageRefs, err := loadImagesFromFile(images)
+		if err != nil {
+			return err
+		}
+		gen.ImageReferences = imageRefs.Images
+		gen.BackupConfiguration.K8upVersion = k8upVersion
+		if err := templateOutputFlags(cmd, &gen); err != nil {
+			return err
+		}
+		return AllTemplateGeneration(gen)
+	},
+}
+
+// AllTemplateGeneration runs the generator once and writes every template that the individual template commands would
+// into their own directory within the saved templates path
+func AllTemplateGeneration(g generator.GeneratorInput) error {
+	out, err := newTemplateOutput(&g)
+	if err != nil {
+		return err
+	}
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	if err := writeTemplateSections(lagoonBuild, out, g.Debug, nil); err != nil {
+		return err
+	}
+	return out.flush()
+}
+
+// writeTemplateSections writes the requested sections of templates for an already generated build, each into their own
+// directory within the output. if no sections are requested, then all sections are written
+func writeTemplateSections(lagoonBuild *generator.Generator, out *templateOutput, debug bool, sections []string) error {
+	for _, section := range sections {
+		found := false
+		for _, t := range templateSections {
+			if t.dir == section {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown template section %s", section)
+		}
+	}
+	for _, t := range templateSections {
+		if len(sections) > 0 && !helpers.Contains(sections, t.dir) {
+			continue
+		}
+		sub, err := out.sub(t.dir)
+		if err != nil {
+			return err
+		}
+		if err := t.write(lagoonBuild, sub, debug); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	templateCmd.AddCommand(allGeneration)
+	allGeneration.Flags().StringP("version", "", "v1", "The version of k8up used.")
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/andreyvit/diff"
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestAllTemplateGeneration(t *testing.T) {
+	tests := []struct {
+		name         string
+		description  string
+		args         testdata.TestData
+		templatePath string
+		want         string
+	}{
+		{
+			name:        "test1-basic-deployment",
+			description: "tests a basic deployment produces the same templates as the individual commands",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			templatePath: "testoutput",
+			want:         "testoutput-individual",
+		},
+		{
+			name:        "test2-complex",
+			description: "tests a complex deployment with dbaas and backups produces the same templates as the individual commands",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/complex/lagoon.yml",
+					ImageReferences: map[string]string{
+						"cli":     "harbor.example/example-project/main/cli@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"nginx":   "harbor.example/example-project/main/nginx@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"php":     "harbor.example/example-project/main/php@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"mariadb": "harbor.example/example-project/main/mariadb@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"redis":   "harbor.example/example-project/main/redis@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"solr":    "harbor.example/example-project/main/solr@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"varnish": "harbor.example/example-project/main/varnish@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_ISOLATION_NETWORK_POLICY",
+							Value: "enabled",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testoutput",
+			want:         "testoutput-individual",
+		},
+		{
+			name:        "test3-active-standby",
+			description: "tests an active/standby deployment produces the same templates as the individual commands",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:        "example-project",
+					EnvironmentName:    "main",
+					Branch:             "main",
+					ActiveEnvironment:  "main",
+					StandbyEnvironment: "main2",
+					LagoonYAML:         "internal/testdata/node/lagoon.activestandby.yml",
+					ImageReferences: map[string]string{
+						"node":       "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"opensearch": "harbor.example/example-project/main/opensearch@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			templatePath: "testoutput",
+			want:         "testoutput-individual",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			gen, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			defer os.RemoveAll(savedTemplates)
+			defer os.RemoveAll(tt.want)
+
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+
+			if err := AllTemplateGeneration(gen); err != nil {
+				t.Errorf("AllTemplateGeneration() error = %v", err)
+			}
+
+			// generate the same templates using the individual commands to compare against
+			individual := []struct {
+				dir string
+				fn  func(generator.GeneratorInput) error
+			}{
+				{dir: allLagoonEnvDir, fn: LagoonEnvTemplateGeneration},
+				{dir: allAutogenRoutesDir, fn: AutogeneratedIngressGeneration},
+				{dir: allRoutesDir, fn: IngressTemplateGeneration},
+				{dir: allDBaaSDir, fn: DBaaSTemplateGeneration},
+				{dir: allBackupDir, fn: BackupTemplateGeneration},
+				{dir: allServiceDeploymentsDir, fn: LagoonServiceTemplateGeneration},
+			}
+			for _, i := range individual {
+				want := filepath.Join(tt.want, i.dir)
+				err = os.MkdirAll(want, 0755)
+				if err != nil {
+					t.Errorf("couldn't create directory %v: %v", want, err)
+				}
+				gen.SavedTemplatesPath = want
+				if err := i.fn(gen); err != nil {
+					t.Errorf("%v", err)
+				}
+				files, err := os.ReadDir(filepath.Join(savedTemplates, i.dir))
+				if err != nil {
+					t.Errorf("couldn't read directory %v: %v", filepath.Join(savedTemplates, i.dir), err)
+				}
+				results, err := os.ReadDir(want)
+				if err != nil {
+					t.Errorf("couldn't read directory %v: %v", want, err)
+				}
+				if len(files) != len(results) {
+					t.Errorf("number of generated templates in %s doesn't match results %v/%v", i.dir, len(files), len(results))
+				}
+				for _, r := range results {
+					f1, err := os.ReadFile(filepath.Join(savedTemplates, i.dir, r.Name()))
+					if err != nil {
+						t.Errorf("couldn't read file %v: %v", filepath.Join(savedTemplates, i.dir, r.Name()), err)
+						continue
+					}
+					r1, err := os.ReadFile(filepath.Join(want, r.Name()))
+					if err != nil {
+						t.Errorf("couldn't read file %v: %v", filepath.Join(want, r.Name()), err)
+					}
+					if !reflect.DeepEqual(f1, r1) {
+						t.Errorf("AllTemplateGeneration() %s = \n%v", fmt.Sprintf("%s/%s", i.dir, r.Name()), diff.LineDiff(string(r1), string(f1)))
+					}
+				}
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+				helpers.UnsetEnvVars(tt.args.BuildPodVariables)
+			})
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 
 	"github.com/spf13/cobra"
 	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
-	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	ingresstemplate "github.com/uselagoon/build-deploy-tool/internal/templating/ingress"
 )
 
@@ -18,31 +17,47 @@ var autogenRouteGeneration = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := templateOutputFlags(cmd, &generator); err != nil {
+			return err
+		}
 		return AutogeneratedIngressGeneration(generator)
 	},
 }
 
 // AutogeneratedIngressGeneration handles generating autogenerated ingress
 func AutogeneratedIngressGeneration(g generator.GeneratorInput) error {
+	out, err := newTemplateOutput(&g)
+	if err != nil {
+		return err
+	}
 	lagoonBuild, err := generator.NewGenerator(
 		g,
 	)
 	if err != nil {
 		return err
 	}
-	savedTemplates := g.SavedTemplatesPath
+	if err := writeAutogeneratedIngressTemplates(lagoonBuild, out, g.Debug); err != nil {
+		return err
+	}
+	return out.flush()
+}
 
+// writeAutogeneratedIngressTemplates writes the autogenerated ingress templates for an already generated build
+func writeAutogeneratedIngressTemplates(lagoonBuild *generator.Generator, out *templateOutput, debug bool) error {
+	savedTemplates := out.path
 	// generate the templates
 	for _, route := range lagoonBuild.AutogeneratedRoutes.Routes {
 		// autogenerated routes use the `servicename` as the name of the ingress resource, use `IngressName` in routev2 to handle this
-		if g.Debug {
+		if debug {
 			fmt.Printf("Templating autogenerated ingress manifest for %s to %s\n", route.Domain, fmt.Sprintf("%s/%s.yaml", savedTemplates, route.LagoonService))
 		}
 		templateYAML, err := ingresstemplate.GenerateIngressTemplate(route, *lagoonBuild.BuildValues)
 		if err != nil {
 			return fmt.Errorf("couldn't generate template: %v", err)
 		}
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, route.LagoonService), templateYAML)
+		if err := out.write(fmt.Sprintf("%s/%s.yaml", savedTemplates, route.LagoonService), templateYAML); err != nil {
+			return err
+		}
 	}
 
 	return nil
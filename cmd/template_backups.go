@@ -6,7 +6,6 @@ import (
 
 	"github.com/spf13/cobra"
 	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
-	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	backuptemplate "github.com/uselagoon/build-deploy-tool/internal/templating/backups"
 	"sigs.k8s.io/yaml"
 )
@@ -28,6 +27,9 @@ var backupGeneration = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := templateOutputFlags(cmd, &generator); err != nil {
+			return err
+		}
 		generator.BackupConfiguration.K8upVersion = k8upVersion
 		return BackupTemplateGeneration(generator)
 	},
@@ -36,14 +38,25 @@ var backupGeneration = &cobra.Command{
 // BackupTemplateGeneration .
 func BackupTemplateGeneration(g generator.GeneratorInput,
 ) error {
+	out, err := newTemplateOutput(&g)
+	if err != nil {
+		return err
+	}
 	lagoonBuild, err := generator.NewGenerator(
 		g,
 	)
 	if err != nil {
 		return err
 	}
-	savedTemplates := g.SavedTemplatesPath
+	if err := writeBackupTemplates(lagoonBuild, out, g.Debug); err != nil {
+		return err
+	}
+	return out.flush()
+}
 
+// writeBackupTemplates writes the backup schedule and prebackuppod templates for an already generated build
+func writeBackupTemplates(lagoonBuild *generator.Generator, out *templateOutput, debug bool) error {
+	savedTemplates := out.path
 	// TODO: the dbaas consumers aren't known when the generator runs currently
 	// so this is a small helper function to collect this from the build stage
 	// this will eventually need to be collected directly by the generator or some other component
@@ -66,24 +79,37 @@ func BackupTemplateGeneration(g generator.GeneratorInput,
 		}
 		repServices = append(repServices, s)
 	}
-	lagoonBuild.BuildValues.Services = repServices
+	// work on a copy of the build values so that the read replica information doesn't leak into
+	// anything else that may be templated from the same generator
+	buildValues := *lagoonBuild.BuildValues
+	buildValues.Services = repServices
 
 	// generate the backup schedule templates
-	templateYAML, err := backuptemplate.GenerateBackupSchedule(*lagoonBuild.BuildValues)
+	templateYAML, err := backuptemplate.GenerateBackupSchedule(buildValues)
 	if err != nil {
 		return fmt.Errorf("couldn't generate template: %v", err)
 	}
 	if len(templateYAML) > 0 {
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "k8up-lagoon-backup-schedule"), templateYAML)
+		if debug {
+			fmt.Printf("Templating backup schedule to %s\n", fmt.Sprintf("%s/%s.yaml", savedTemplates, "k8up-lagoon-backup-schedule"))
+		}
+		if err := out.write(fmt.Sprintf("%s/%s.yaml", savedTemplates, "k8up-lagoon-backup-schedule"), templateYAML); err != nil {
+			return err
+		}
 	}
 
 	// generate any prebackuppod templates
-	templateYAML, err = backuptemplate.GeneratePreBackupPod(*lagoonBuild.BuildValues)
+	templateYAML, err = backuptemplate.GeneratePreBackupPod(buildValues)
 	if err != nil {
 		return fmt.Errorf("couldn't generate template: %v", err)
 	}
 	if len(templateYAML) > 0 {
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "prebackuppods"), templateYAML)
+		if debug {
+			fmt.Printf("Templating prebackuppods to %s\n", fmt.Sprintf("%s/%s.yaml", savedTemplates, "prebackuppods"))
+		}
+		if err := out.write(fmt.Sprintf("%s/%s.yaml", savedTemplates, "prebackuppods"), templateYAML); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -5,7 +5,6 @@ import (
 
 	"github.com/spf13/cobra"
 	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
-	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	dbaasTemplater "github.com/uselagoon/build-deploy-tool/internal/templating/dbaas"
 )
 
@@ -18,6 +17,9 @@ var dbaasGeneration = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := templateOutputFlags(cmd, &generator); err != nil {
+			return err
+		}
 		return DBaaSTemplateGeneration(generator)
 	},
 }
@@ -25,21 +27,34 @@ var dbaasGeneration = &cobra.Command{
 // DBaaSTemplateGeneration .
 func DBaaSTemplateGeneration(g generator.GeneratorInput,
 ) error {
+	out, err := newTemplateOutput(&g)
+	if err != nil {
+		return err
+	}
 	lagoonBuild, err := generator.NewGenerator(
 		g,
 	)
 	if err != nil {
 		return err
 	}
-	savedTemplates := g.SavedTemplatesPath
+	if err := writeDBaaSTemplates(lagoonBuild, out, g.Debug); err != nil {
+		return err
+	}
+	return out.flush()
+}
 
+// writeDBaaSTemplates writes the dbaas consumer templates for an already generated build
+func writeDBaaSTemplates(lagoonBuild *generator.Generator, out *templateOutput, debug bool) error {
+	savedTemplates := out.path
 	templateYAML, err := dbaasTemplater.GenerateDBaaSTemplate(*lagoonBuild.BuildValues)
 	if err != nil {
 		return fmt.Errorf("couldn't generate template: %v", err)
 	}
 	if len(templateYAML) > 0 {
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, "dbaas"), templateYAML)
-		if g.Debug {
+		if err := out.write(fmt.Sprintf("%s/%s.yaml", savedTemplates, "dbaas"), templateYAML); err != nil {
+			return err
+		}
+		if debug {
 			fmt.Printf("Templating dbaas consumers to %s\n", fmt.Sprintf("%s/%s.yaml", savedTemplates, "dbaas"))
 		}
 	}
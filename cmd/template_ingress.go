@@ -5,7 +5,6 @@ import (
 
 	"github.com/spf13/cobra"
 	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
-	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	ingresstemplate "github.com/uselagoon/build-deploy-tool/internal/templating/ingress"
 )
 
@@ -18,30 +17,46 @@ var routeGeneration = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := templateOutputFlags(cmd, &generator); err != nil {
+			return err
+		}
 		return IngressTemplateGeneration(generator)
 	},
 }
 
 // IngressTemplateGeneration .
 func IngressTemplateGeneration(g generator.GeneratorInput) error {
+	out, err := newTemplateOutput(&g)
+	if err != nil {
+		return err
+	}
 	lagoonBuild, err := generator.NewGenerator(
 		g,
 	)
 	if err != nil {
 		return err
 	}
-	savedTemplates := g.SavedTemplatesPath
+	if err := writeIngressTemplates(lagoonBuild, out, g.Debug); err != nil {
+		return err
+	}
+	return out.flush()
+}
 
+// writeIngressTemplates writes the ingress templates for an already generated build
+func writeIngressTemplates(lagoonBuild *generator.Generator, out *templateOutput, debug bool) error {
+	savedTemplates := out.path
 	// generate the templates
 	for _, route := range lagoonBuild.MainRoutes.Routes {
-		if g.Debug {
+		if debug {
 			fmt.Printf("Templating ingress manifest for %s to %s\n", route.Domain, fmt.Sprintf("%s/%s.yaml", savedTemplates, route.Domain))
 		}
 		templateYAML, err := ingresstemplate.GenerateIngressTemplate(route, *lagoonBuild.BuildValues)
 		if err != nil {
 			return fmt.Errorf("couldn't generate template: %v", err)
 		}
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, route.Domain), templateYAML)
+		if err := out.write(fmt.Sprintf("%s/%s.yaml", savedTemplates, route.Domain), templateYAML); err != nil {
+			return err
+		}
 	}
 	if *lagoonBuild.ActiveEnvironment || *lagoonBuild.StandbyEnvironment {
 		// active/standby routes should not be changed by any environment defined routes.
@@ -50,14 +65,16 @@ func IngressTemplateGeneration(g generator.GeneratorInput) error {
 		// section are created correctly ensuring active/standby will work
 		// generate the templates for active/standby routes separately to normal routes
 		for _, route := range lagoonBuild.ActiveStandbyRoutes.Routes {
-			if g.Debug {
+			if debug {
 				fmt.Printf("Templating active/standby ingress manifest for %s to %s\n", route.Domain, fmt.Sprintf("%s/%s.yaml", savedTemplates, route.Domain))
 			}
 			templateYAML, err := ingresstemplate.GenerateIngressTemplate(route, *lagoonBuild.BuildValues)
 			if err != nil {
 				return fmt.Errorf("couldn't generate template: %v", err)
 			}
-			helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, route.Domain), templateYAML)
+			if err := out.write(fmt.Sprintf("%s/%s.yaml", savedTemplates, route.Domain), templateYAML); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -432,6 +432,57 @@ func TestTemplateRoutes(t *testing.T) {
 			templatePath: "testdata/output",
 			want:         "internal/testdata/basic/ingress-templates/test25-pathroutes",
 		},
+		{
+			name: "test26 version 2 lagoon.yml",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "content-example-com",
+					EnvironmentName: "production",
+					Branch:          "production",
+					LagoonYAML:      "internal/testdata/complex/lagoon.v2.yml",
+				}, true),
+			templatePath: "testoutput",
+			want:         "internal/testdata/complex/ingress-templates/ingress-1",
+		},
+		{
+			name: "test27 lagoon.yml interpolation",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/node/lagoon.interpolation.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_LAGOONYML_INTERPOLATION",
+							Value: "enabled",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testoutput",
+			want:         "internal/testdata/node/ingress-templates/ingress-24",
+		},
+		{
+			name: "test28 lagoon.yml interpolation undefined variable",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "undefined",
+					Branch:          "undefined",
+					LagoonYAML:      "internal/testdata/node/lagoon.interpolation.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{
+							Name:  "LAGOON_FEATURE_FLAG_LAGOONYML_INTERPOLATION",
+							Value: "enabled",
+							Scope: "build",
+						},
+					},
+				}, true),
+			templatePath: "testoutput",
+			wantErr:      true,
+			wantErrMsg:   "couldn't interpolate environments.undefined.routes.0.node.0: the variable NOT_DEFINED is not defined",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/templating/configmap"
+	"sigs.k8s.io/yaml"
+)
+
+var lagoonEnvGeneration = &cobra.Command{
+	Use:     "lagoon-env",
+	Aliases: []string{"le"},
+	Short:   "Generate the lagoon-env configmap template for a Lagoon build",
+	Long: `Generate the lagoon-env configmap template for a Lagoon build from the runtime and global scoped project and environment variables,
+and the variables that Lagoon adds to every environment. The sha of the configmap data is added as the lagoon.sh/configMapSha annotation`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gen, err := generator.GenerateInput(*rootCmd, true)
+		if err != nil {
+			return err
+		}
+		if err := templateOutputFlags(cmd, &gen); err != nil {
+			return err
+		}
+		return LagoonEnvTemplateGeneration(gen)
+	},
+}
+
+// LagoonEnvTemplateGeneration .
+func LagoonEnvTemplateGeneration(g generator.GeneratorInput) error {
+	out, err := newTemplateOutput(&g)
+	if err != nil {
+		return err
+	}
+	lagoonBuild, err := generator.NewGenerator(
+		g,
+	)
+	if err != nil {
+		return err
+	}
+	if err := writeLagoonEnvTemplates(lagoonBuild, out, g.Debug); err != nil {
+		return err
+	}
+	return out.flush()
+}
+
+// writeLagoonEnvTemplates writes the lagoon-env configmap template for an already generated build
+func writeLagoonEnvTemplates(lagoonBuild *generator.Generator, out *templateOutput, debug bool) error {
+	savedTemplates := out.path
+	cm, err := configmap.GenerateLagoonEnvConfigMap(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	cmBytes, err := yaml.Marshal(cm)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	separator := []byte("---\n")
+	restoreResult := append(separator[:], cmBytes[:]...)
+	if debug {
+		fmt.Printf("Templating configmap manifest %s\n", fmt.Sprintf("%s/%s.yaml", savedTemplates, cm.Name))
+	}
+	return out.write(fmt.Sprintf("%s/%s.yaml", savedTemplates, cm.Name), restoreResult)
+}
+
+func init() {
+	templateCmd.AddCommand(lagoonEnvGeneration)
+}
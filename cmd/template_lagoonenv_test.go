@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/andreyvit/diff"
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestLagoonEnvTemplateGeneration(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         testdata.TestData
+		templatePath string
+		want         string
+	}{
+		{
+			name: "test1 - basic branch environment",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+				}, true),
+			templatePath: "testoutput",
+			want:         "internal/testdata/basic/lagoon-env-templates/lagoon-env-1/lagoon-env.yaml",
+		},
+		{
+			name: "test2 - only runtime and global variables are added, environment overrides project",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ProjectVariables: []lagoon.EnvironmentVariable{
+						{Name: "MY_RUNTIME_VAR", Value: "project", Scope: "runtime"},
+						{Name: "MY_GLOBAL_VAR", Value: "global", Scope: "global"},
+						{Name: "MY_BUILD_VAR", Value: "build", Scope: "build"},
+					},
+					EnvVariables: []lagoon.EnvironmentVariable{
+						{Name: "MY_RUNTIME_VAR", Value: "environment", Scope: "runtime"},
+					},
+				}, true),
+			templatePath: "testoutput",
+			want:         "internal/testdata/basic/lagoon-env-templates/lagoon-env-2/lagoon-env.yaml",
+		},
+		{
+			name: "test3 - pullrequest environment",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "pr-123",
+					EnvironmentType: "development",
+					BuildType:       "pullrequest",
+					PRNumber:        "123",
+					PRTitle:         "My PR",
+					PRHeadBranch:    "feature",
+					PRBaseBranch:    "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+				}, true),
+			templatePath: "testoutput",
+			want:         "internal/testdata/basic/lagoon-env-templates/lagoon-env-3/lagoon-env.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helpers.UnsetEnvVars(nil) //unset variables before running tests
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			generator, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			err = os.MkdirAll(savedTemplates, 0755)
+			if err != nil {
+				t.Errorf("couldn't create directory %v: %v", savedTemplates, err)
+			}
+			defer os.RemoveAll(savedTemplates)
+
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+
+			if err := LagoonEnvTemplateGeneration(generator); err != nil {
+				t.Errorf("LagoonEnvTemplateGeneration() error = %v", err)
+			}
+			f1, err := os.ReadFile(fmt.Sprintf("%s/lagoon-env.yaml", savedTemplates))
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", savedTemplates, err)
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			if !reflect.DeepEqual(f1, r1) {
+				t.Errorf("LagoonEnvTemplateGeneration() = \n%v", diff.LineDiff(string(r1), string(f1)))
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+				helpers.UnsetEnvVars(tt.args.BuildPodVariables)
+			})
+		})
+	}
+}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/spf13/cobra"
 	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
-	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/templating/networkpolicy"
 	"github.com/uselagoon/build-deploy-tool/internal/templating/registrysecret"
 	servicestemplates "github.com/uselagoon/build-deploy-tool/internal/templating/services"
@@ -26,6 +25,9 @@ var lagoonServiceGeneration = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if err := templateOutputFlags(cmd, &gen); err != nil {
+			return err
+		}
 		images, err := rootCmd.PersistentFlags().GetString("images")
 		if err != nil {
 			return fmt.Errorf("error reading images flag: %v", err)
@@ -53,14 +55,25 @@ func loadImagesFromFile(file string) (*ImageReferences, error) {
 
 // LagoonServiceTemplateGeneration .
 func LagoonServiceTemplateGeneration(g generator.GeneratorInput) error {
+	out, err := newTemplateOutput(&g)
+	if err != nil {
+		return err
+	}
 	lagoonBuild, err := generator.NewGenerator(
 		g,
 	)
 	if err != nil {
 		return err
 	}
-	savedTemplates := g.SavedTemplatesPath
+	if err := writeLagoonServiceTemplates(lagoonBuild, out, g.Debug); err != nil {
+		return err
+	}
+	return out.flush()
+}
 
+// writeLagoonServiceTemplates writes the service, pvc, deployment, hpa, pdb, cronjob and networkpolicy templates for an already generated build
+func writeLagoonServiceTemplates(lagoonBuild *generator.Generator, out *templateOutput, debug bool) error {
+	savedTemplates := out.path
 	// generate the templates
 	secrets, err := registrysecret.GenerateRegistrySecretTemplate(*lagoonBuild.BuildValues)
 	if err != nil {
@@ -73,10 +86,12 @@ func LagoonServiceTemplateGeneration(g generator.GeneratorInput) error {
 		}
 		separator := []byte("---\n")
 		restoreResult := append(separator[:], serviceBytes[:]...)
-		if g.Debug {
+		if debug {
 			fmt.Printf("Templating registry secret manifests %s\n", fmt.Sprintf("%s/%s.yaml", savedTemplates, secret.Name))
 		}
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/%s.yaml", savedTemplates, secret.Name), restoreResult)
+		if err := out.write(fmt.Sprintf("%s/%s.yaml", savedTemplates, secret.Name), restoreResult); err != nil {
+			return err
+		}
 	}
 	services, err := servicestemplates.GenerateServiceTemplate(*lagoonBuild.BuildValues)
 	if err != nil {
@@ -89,10 +104,12 @@ func LagoonServiceTemplateGeneration(g generator.GeneratorInput) error {
 		}
 		separator := []byte("---\n")
 		restoreResult := append(separator[:], serviceBytes[:]...)
-		if g.Debug {
+		if debug {
 			fmt.Printf("Templating service manifests %s\n", fmt.Sprintf("%s/service-%s.yaml", savedTemplates, d.Name))
 		}
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/service-%s.yaml", savedTemplates, d.Name), restoreResult)
+		if err := out.write(fmt.Sprintf("%s/service-%s.yaml", savedTemplates, d.Name), restoreResult); err != nil {
+			return err
+		}
 	}
 	pvcs, err := servicestemplates.GeneratePVCTemplate(*lagoonBuild.BuildValues)
 	if err != nil {
@@ -105,10 +122,12 @@ func LagoonServiceTemplateGeneration(g generator.GeneratorInput) error {
 		}
 		separator := []byte("---\n")
 		restoreResult := append(separator[:], serviceBytes[:]...)
-		if g.Debug {
+		if debug {
 			fmt.Printf("Templating pvc manifests %s\n", fmt.Sprintf("%s/pvc-%s.yaml", savedTemplates, d.Name))
 		}
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/pvc-%s.yaml", savedTemplates, d.Name), restoreResult)
+		if err := out.write(fmt.Sprintf("%s/pvc-%s.yaml", savedTemplates, d.Name), restoreResult); err != nil {
+			return err
+		}
 	}
 	deployments, err := servicestemplates.GenerateDeploymentTemplate(*lagoonBuild.BuildValues)
 	if err != nil {
@@ -121,10 +140,48 @@ func LagoonServiceTemplateGeneration(g generator.GeneratorInput) error {
 		}
 		separator := []byte("---\n")
 		restoreResult := append(separator[:], deploymentBytes[:]...)
-		if g.Debug {
+		if debug {
 			fmt.Printf("Templating deployment manifests %s\n", fmt.Sprintf("%s/deployment-%s.yaml", savedTemplates, d.Name))
 		}
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/deployment-%s.yaml", savedTemplates, d.Name), restoreResult)
+		if err := out.write(fmt.Sprintf("%s/deployment-%s.yaml", savedTemplates, d.Name), restoreResult); err != nil {
+			return err
+		}
+	}
+	hpas, err := servicestemplates.GenerateHPATemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	for _, d := range hpas {
+		hpaBytes, err := yaml.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("couldn't generate template: %v", err)
+		}
+		separator := []byte("---\n")
+		restoreResult := append(separator[:], hpaBytes[:]...)
+		if debug {
+			fmt.Printf("Templating horizontal pod autoscaler manifests %s\n", fmt.Sprintf("%s/hpa-%s.yaml", savedTemplates, d.Name))
+		}
+		if err := out.write(fmt.Sprintf("%s/hpa-%s.yaml", savedTemplates, d.Name), restoreResult); err != nil {
+			return err
+		}
+	}
+	pdbs, err := servicestemplates.GeneratePodDisruptionBudgetTemplate(*lagoonBuild.BuildValues)
+	if err != nil {
+		return fmt.Errorf("couldn't generate template: %v", err)
+	}
+	for _, d := range pdbs {
+		pdbBytes, err := yaml.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("couldn't generate template: %v", err)
+		}
+		separator := []byte("---\n")
+		restoreResult := append(separator[:], pdbBytes[:]...)
+		if debug {
+			fmt.Printf("Templating pod disruption budget manifests %s\n", fmt.Sprintf("%s/pdb-%s.yaml", savedTemplates, d.Name))
+		}
+		if err := out.write(fmt.Sprintf("%s/pdb-%s.yaml", savedTemplates, d.Name), restoreResult); err != nil {
+			return err
+		}
 	}
 	cronjobs, err := servicestemplates.GenerateCronjobTemplate(*lagoonBuild.BuildValues)
 	if err != nil {
@@ -137,10 +194,12 @@ func LagoonServiceTemplateGeneration(g generator.GeneratorInput) error {
 		}
 		separator := []byte("---\n")
 		restoreResult := append(separator[:], deploymentBytes[:]...)
-		if g.Debug {
+		if debug {
 			fmt.Printf("Templating cronjob manifests %s\n", fmt.Sprintf("%s/cronjob-%s.yaml", savedTemplates, d.Name))
 		}
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/cronjob-%s.yaml", savedTemplates, d.Name), restoreResult)
+		if err := out.write(fmt.Sprintf("%s/cronjob-%s.yaml", savedTemplates, d.Name), restoreResult); err != nil {
+			return err
+		}
 	}
 	if lagoonBuild.BuildValues.IsolationNetworkPolicy {
 		// if isolation network policies are enabled, template that here
@@ -154,10 +213,12 @@ func LagoonServiceTemplateGeneration(g generator.GeneratorInput) error {
 		}
 		separator := []byte("---\n")
 		restoreResult := append(separator[:], npBytes[:]...)
-		if g.Debug {
+		if debug {
 			fmt.Printf("Templating networkpolicy manifest %s\n", fmt.Sprintf("%s/isolation-network-policy.yaml", savedTemplates))
 		}
-		helpers.WriteTemplateFile(fmt.Sprintf("%s/isolation-network-policy.yaml", savedTemplates), restoreResult)
+		if err := out.write(fmt.Sprintf("%s/isolation-network-policy.yaml", savedTemplates), restoreResult); err != nil {
+			return err
+		}
 	}
 	return nil
 }
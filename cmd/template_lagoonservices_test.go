@@ -480,6 +480,71 @@ func TestTemplateLagoonServices(t *testing.T) {
 			templatePath: "testoutput",
 			want:         "internal/testdata/basic/service-templates/test-basic-spot-affinity",
 		},
+		{
+			name: "test16-basic-service-type-definitions",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.service-definitions.yml",
+					ImageReferences: map[string]string{
+						"node":      "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"memcached": "harbor.example/example-project/main/memcached@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+					BuildPodVariables: []helpers.EnvironmentVariable{
+						{
+							// the service type definitions are provided by the remote-controller
+							Name:  "LAGOON_SERVICE_TYPE_DEFINITIONS",
+							Value: "internal/testdata/basic/service-definitions.yml",
+						},
+					},
+				}, true),
+			templatePath: "testoutput",
+			want:         "internal/testdata/basic/service-templates/test16-basic-service-type-definitions",
+		},
+		{
+			name:        "test17-basic-resource-overrides",
+			description: "set the resources and probes of a service from labels and the .lagoon.yml overrides, within the ceilings",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.resource-overrides.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+					BuildPodVariables: []helpers.EnvironmentVariable{
+						{
+							Name:  "ADMIN_LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_CEILING",
+							Value: "2Gi",
+						},
+						{
+							Name:  "ADMIN_LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_LIMIT",
+							Value: "1Gi",
+						},
+					},
+				}, true),
+			templatePath: "testoutput",
+			want:         "internal/testdata/basic/service-templates/test17-basic-resource-overrides",
+		},
+		{
+			name:        "test18-node-autoscaling",
+			description: "autoscale a node service with a horizontal pod autoscaler and pod disruption budget, the deployment doesn't set the replicas",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.autoscaling.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			templatePath: "testoutput",
+			want:         "internal/testdata/basic/service-templates/test18-node-autoscaling",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+)
+
+// the supported template output modes
+const (
+	templateOutputDir    = "dir"
+	templateOutputYAML   = "yaml"
+	templateOutputJSON   = "json"
+	templateOutputStdout = "stdout"
+	// templateOutputMemory only collects the templates into the bundle, it is not selectable by the output flag
+	templateOutputMemory = "memory"
+)
+
+// templateOutput handles where the rendered templates end up, either written as individual files into the saved templates path
+// or collected into a single bundle that is written once all the templates have been rendered
+type templateOutput struct {
+	mode   string
+	file   string
+	path   string
+	bundle *manifests.Bundle
+}
+
+// newTemplateOutput creates the template output from the generator input, if the output is going to stdout then debug
+// is disabled on the generator input so that nothing else is written to stdout
+func newTemplateOutput(g *generator.GeneratorInput) (*templateOutput, error) {
+	out := &templateOutput{
+		mode:   g.TemplateOutput,
+		file:   g.TemplateOutputFile,
+		path:   g.SavedTemplatesPath,
+		bundle: manifests.NewBundle(),
+	}
+	switch out.mode {
+	case "", templateOutputDir:
+		out.mode = templateOutputDir
+	case templateOutputYAML:
+		if out.file == "" {
+			out.file = filepath.Join(out.path, "bundle.yaml")
+		}
+	case templateOutputJSON:
+		if out.file == "" {
+			out.file = filepath.Join(out.path, "bundle.json")
+		}
+	case templateOutputStdout:
+		out.file = "-"
+	default:
+		return nil, fmt.Errorf("unsupported output %s, must be one of dir, yaml, json, or stdout", out.mode)
+	}
+	if out.file == "-" {
+		g.Debug = false
+	}
+	return out, nil
+}
+
+// newMemoryOutput creates a template output that only collects the templates into a bundle
+func newMemoryOutput() *templateOutput {
+	return &templateOutput{
+		mode:   templateOutputMemory,
+		bundle: manifests.NewBundle(),
+	}
+}
+
+// sub returns a template output that writes into a sub directory of this one, but collects into the same bundle
+func (o *templateOutput) sub(dir string) (*templateOutput, error) {
+	sub := &templateOutput{
+		mode:   o.mode,
+		file:   o.file,
+		path:   filepath.Join(o.path, dir),
+		bundle: o.bundle,
+	}
+	if o.mode == templateOutputDir {
+		if err := os.MkdirAll(sub.path, 0755); err != nil {
+			return nil, fmt.Errorf("couldn't create directory %v: %v", sub.path, err)
+		}
+	}
+	return sub, nil
+}
+
+// write writes the template file, or stores it in the bundle if the output is not a directory
+func (o *templateOutput) write(file string, data []byte) error {
+	if o.mode == templateOutputDir {
+		helpers.WriteTemplateFile(file, data)
+		return nil
+	}
+	return o.bundle.Add(file, data)
+}
+
+// flush writes out the bundle if the output is not a directory
+func (o *templateOutput) flush() error {
+	var data []byte
+	var err error
+	switch o.mode {
+	case templateOutputDir, templateOutputMemory:
+		return nil
+	case templateOutputJSON:
+		data, err = o.bundle.JSON()
+		data = append(data, []byte("\n")...)
+	default:
+		data, err = o.bundle.YAML()
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't generate bundle: %v", err)
+	}
+	if o.file == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(o.file, data, 0644); err != nil {
+		return fmt.Errorf("couldn't write bundle %s: %v", o.file, err)
+	}
+	return nil
+}
+
+// templateOutputFlags reads the template output flags into the generator input
+func templateOutputFlags(cmd *cobra.Command, g *generator.GeneratorInput) error {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return fmt.Errorf("error reading output flag: %v", err)
+	}
+	outputFile, err := cmd.Flags().GetString("output-file")
+	if err != nil {
+		return fmt.Errorf("error reading output-file flag: %v", err)
+	}
+	g.TemplateOutput = output
+	g.TemplateOutputFile = outputFile
+	return nil
+}
+
+func init() {
+	templateCmd.PersistentFlags().StringP("output", "", templateOutputDir,
+		"How to output the templates (dir, yaml, json, or stdout). dir writes individual files into the saved templates path, yaml and json write a single sorted bundle")
+	templateCmd.PersistentFlags().StringP("output-file", "", "",
+		"The file to write the yaml or json bundle to, defaults to bundle.yaml or bundle.json in the saved templates path, use - for stdout")
+}
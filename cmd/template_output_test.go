@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andreyvit/diff"
+	"github.com/spf13/pflag"
+	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestTemplateOutputBundle(t *testing.T) {
+	tests := []struct {
+		name         string
+		description  string
+		args         testdata.TestData
+		output       string
+		templatePath string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:        "test1-yaml-bundle",
+			description: "the yaml bundle should contain the same objects as the individual templates",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/complex/lagoon.yml",
+					ImageReferences: map[string]string{
+						"cli":     "harbor.example/example-project/main/cli@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"nginx":   "harbor.example/example-project/main/nginx@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"php":     "harbor.example/example-project/main/php@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"mariadb": "harbor.example/example-project/main/mariadb@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"redis":   "harbor.example/example-project/main/redis@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"solr":    "harbor.example/example-project/main/solr@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+						"varnish": "harbor.example/example-project/main/varnish@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			output:       "yaml",
+			templatePath: "testoutput",
+			want:         "testoutput-dir",
+		},
+		{
+			name:        "test2-json-bundle",
+			description: "the json bundle should contain the same objects as the individual templates",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+					ImageReferences: map[string]string{
+						"node": "harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8",
+					},
+				}, true),
+			output:       "json",
+			templatePath: "testoutput",
+			want:         "testoutput-dir",
+		},
+		{
+			name:        "test3-invalid-output",
+			description: "an unsupported output mode should error",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/basic/lagoon.yml",
+				}, true),
+			output:       "xml",
+			templatePath: "testoutput",
+			want:         "testoutput-dir",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// set the environment variables from args
+			savedTemplates := tt.templatePath
+			generator, err := testdata.SetupEnvironment(*rootCmd, savedTemplates, tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			for _, dir := range []string{savedTemplates, tt.want} {
+				err = os.MkdirAll(dir, 0755)
+				if err != nil {
+					t.Errorf("couldn't create directory %v: %v", dir, err)
+				}
+				defer os.RemoveAll(dir)
+			}
+
+			ts := dbaasclient.TestDBaaSHTTPServer()
+			defer ts.Close()
+			err = os.Setenv("DBAAS_OPERATOR_HTTP", ts.URL)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+
+			generator.TemplateOutput = tt.output
+			if err := AllTemplateGeneration(generator); (err != nil) != tt.wantErr {
+				t.Errorf("AllTemplateGeneration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := os.ReadFile(fmt.Sprintf("%s/bundle.%s", savedTemplates, tt.output))
+			if err != nil {
+				t.Errorf("couldn't read bundle: %v", err)
+			}
+
+			// generate the individual templates and load them into a bundle to compare against
+			generator.TemplateOutput = "dir"
+			generator.SavedTemplatesPath = tt.want
+			if err := AllTemplateGeneration(generator); err != nil {
+				t.Errorf("AllTemplateGeneration() error = %v", err)
+			}
+			want := manifests.NewBundle()
+			for _, dir := range []string{allLagoonEnvDir, allAutogenRoutesDir, allRoutesDir, allDBaaSDir, allBackupDir, allServiceDeploymentsDir} {
+				files, err := os.ReadDir(fmt.Sprintf("%s/%s", tt.want, dir))
+				if err != nil {
+					t.Errorf("couldn't read directory %v: %v", dir, err)
+				}
+				for _, f := range files {
+					data, err := os.ReadFile(fmt.Sprintf("%s/%s/%s", tt.want, dir, f.Name()))
+					if err != nil {
+						t.Errorf("couldn't read file %v: %v", f.Name(), err)
+					}
+					if err := want.Add(fmt.Sprintf("%s/%s", dir, f.Name()), data); err != nil {
+						t.Errorf("%v", err)
+					}
+				}
+			}
+			var wantBytes []byte
+			switch tt.output {
+			case "json":
+				wantBytes, err = want.JSON()
+				wantBytes = append(wantBytes, []byte("\n")...)
+			default:
+				wantBytes, err = want.YAML()
+			}
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			if string(got) != string(wantBytes) {
+				t.Errorf("AllTemplateGeneration() = \n%v", diff.LineDiff(string(wantBytes), string(got)))
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+				helpers.UnsetEnvVars(tt.args.BuildPodVariables)
+			})
+		})
+	}
+}
+
+func TestTemplateOutputFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		lagoonYAML string
+		wantKind   string
+	}{
+		{
+			name:       "test1-lagoon-services",
+			args:       []string{"template", "lagoon-services"},
+			lagoonYAML: "internal/testdata/basic/lagoon.yml",
+			wantKind:   "kind: Deployment",
+		},
+		{
+			name:       "test2-backup-schedule",
+			args:       []string{"template", "backup-schedule"},
+			lagoonYAML: "internal/testdata/node/lagoon.yml",
+			wantKind:   "kind: Schedule",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testdata.SetupEnvironment(*rootCmd, "", testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+				}, true))
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			dir := t.TempDir()
+			images := filepath.Join(dir, "images.json")
+			err = os.WriteFile(images, []byte(`{"images":{"node":"harbor.example/example-project/main/node@sha256:b2001babafaa8128fe89aa8fd11832cade59931d14c3de5b3ca32e2a010fbaa8"}}`), 0644)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			bundle := filepath.Join(dir, "bundle.yaml")
+			rootCmd.SetArgs(append(tt.args,
+				"--lagoon-yml", tt.lagoonYAML,
+				"--images", images,
+				"--saved-templates-path", filepath.Join(dir, "templates"),
+				"--output", "yaml",
+				"--output-file", bundle,
+			))
+			t.Cleanup(func() {
+				// the flags stay set on the commands, so put them back for the other tests
+				for _, flags := range []*pflag.FlagSet{rootCmd.PersistentFlags(), templateCmd.PersistentFlags()} {
+					flags.VisitAll(func(f *pflag.Flag) {
+						if f.Changed {
+							_ = f.Value.Set(f.DefValue)
+							f.Changed = false
+						}
+					})
+				}
+				rootCmd.SetArgs(nil)
+				helpers.UnsetEnvVars(nil)
+			})
+			if err := rootCmd.Execute(); err != nil {
+				t.Errorf("Execute() error = %v", err)
+				return
+			}
+			got, err := os.ReadFile(bundle)
+			if err != nil {
+				t.Errorf("the output flags were ignored, couldn't read bundle: %v", err)
+				return
+			}
+			if !strings.Contains(string(got), tt.wantKind) {
+				t.Errorf("bundle doesn't contain %s:\n%s", tt.wantKind, got)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "templates")); err == nil {
+				t.Errorf("the templates were written to the saved templates path instead of the bundle")
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/uselagoon/build-deploy-tool/internal/findings"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
 )
 
@@ -31,11 +32,42 @@ var validateDockerCompose = &cobra.Command{
 			os.Exit(1)
 		}
 
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			fmt.Println(fmt.Errorf("error reading format flag: %v", err))
+			os.Exit(1)
+		}
+
 		err = ValidateDockerCompose(dockerComposeFile, ignoreNonStringKeyErrors, ignoreMissingEnvFiles)
+		if format == findings.FormatText {
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+		found := findings.Findings{}
 		if err != nil {
-			fmt.Println(err.Error())
+			found = append(found, findings.FromError(ruleDockerCompose, dockerComposeFile, err))
+		}
+		if err := findings.Write(os.Stdout, format, found, dockerComposeRules, bdtVersion); err != nil {
+			fmt.Println(err)
 			os.Exit(1)
 		}
+		if found.Errors() > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// the rule of the findings that validate docker-compose reports
+const ruleDockerCompose = "docker-compose"
+
+var dockerComposeRules = []findings.Rule{
+	{
+		ID:          ruleDockerCompose,
+		Severity:    findings.SeverityError,
+		Description: "the docker-compose file must be valid for this tool",
 	},
 }
 
@@ -81,6 +113,8 @@ func init() {
 	validateCmd.AddCommand(validateDockerComposeWithErrors)
 	validateDockerCompose.Flags().StringP("docker-compose", "", "docker-compose.yml",
 		"The docker-compose.yml file to read.")
+	validateDockerCompose.Flags().StringP("format", "", findings.FormatText,
+		"The format to display the result in (text, json or sarif).")
 	validateDockerComposeWithErrors.Flags().StringP("docker-compose", "", "docker-compose.yml",
 		"The docker-compose.yml file to read.")
 }
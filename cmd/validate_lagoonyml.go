@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+	"github.com/uselagoon/build-deploy-tool/internal/findings"
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
 	"sigs.k8s.io/yaml"
 )
@@ -14,7 +21,55 @@ import (
 var validateLagoonYml = &cobra.Command{
 	Use:   "lagoon-yml",
 	Short: "Verify .lagoon.yml and environment for compatability with this tool",
+	Long: `Verify .lagoon.yml and environment for compatability with this tool.
+The .lagoon.yml, the override file and the LAGOON_YAML_OVERRIDE variable are validated against the .lagoon.yml JSON Schema,
+reporting every error with the line and column it was found at. Unknown fields are reported as warnings, or as errors with --strict.
+Use --schema to print the JSON Schema, and --schema-version to choose the version of the .lagoon.yml it is for.
+Files with version: 2 are validated against the version 2 schema.
+The resulting .lagoon.yml is then checked with the lint rules, use --list-rules to see them and --disable-rule to disable any of them.
+Use --all-projects to validate a polysite .lagoon.yml for every project defined in it.
+If the branch or environment name is set, with the flags or the BRANCH and ENVIRONMENT build variables, lint errors in the
+environments blocks that the environment doesn't use are reported as warnings.
+Use --format=json or --format=sarif for machine readable findings, SARIF 2.1.0 can be uploaded to code scanning tools`,
 	Run: func(cmd *cobra.Command, args []string) {
+		printSchema, err := cmd.Flags().GetBool("schema")
+		if err != nil {
+			fmt.Println(fmt.Errorf("error reading schema flag: %v", err))
+			os.Exit(1)
+		}
+		if printSchema {
+			schemaVersion, err := cmd.Flags().GetInt("schema-version")
+			if err != nil {
+				fmt.Println(fmt.Errorf("error reading schema-version flag: %v", err))
+				os.Exit(1)
+			}
+			schema, err := lagoon.GenerateSchemaJSON(schemaVersion)
+			if err != nil {
+				fmt.Println(fmt.Errorf("couldn't generate schema: %v", err))
+				os.Exit(1)
+			}
+			fmt.Println(string(schema))
+			return
+		}
+		listRules, err := cmd.Flags().GetBool("list-rules")
+		if err != nil {
+			fmt.Println(fmt.Errorf("error reading list-rules flag: %v", err))
+			os.Exit(1)
+		}
+		if listRules {
+			writeLintRules(os.Stdout)
+			return
+		}
+		disabledRules, err := cmd.Flags().GetStringSlice("disable-rule")
+		if err != nil {
+			fmt.Println(fmt.Errorf("error reading disable-rule flag: %v", err))
+			os.Exit(1)
+		}
+		strict, err := cmd.Flags().GetBool("strict")
+		if err != nil {
+			fmt.Println(fmt.Errorf("error reading strict flag: %v", err))
+			os.Exit(1)
+		}
 		lagoonYAML, err := rootCmd.PersistentFlags().GetString("lagoon-yml")
 		if err != nil {
 			fmt.Println(fmt.Errorf("error reading lagoon-yml flag: %v", err))
@@ -36,49 +91,422 @@ var validateLagoonYml = &cobra.Command{
 			os.Exit(1)
 		}
 
-		lYAML := &lagoon.YAML{}
-		err = ValidateLagoonYml(lagoonYAML, lagoonYAMLOverride, "LAGOON_YAML_OVERRIDE", lYAML, projectName, false)
+		allProjects, err := cmd.Flags().GetBool("all-projects")
 		if err != nil {
-			fmt.Println("Could not validate your .lagoon.yml -", err.Error())
+			fmt.Println(fmt.Errorf("error reading all-projects flag: %v", err))
+			os.Exit(1)
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			fmt.Println(fmt.Errorf("error reading format flag: %v", err))
+			os.Exit(1)
+		}
+		if !helpers.Contains(findings.Formats, format) {
+			fmt.Printf("unsupported format %s, must be one of %s\n", format, strings.Join(findings.Formats, ", "))
+			os.Exit(1)
+		}
+		for _, id := range disabledRules {
+			if _, ok := lagoon.GetLintRule(id); !ok {
+				fmt.Printf("unknown lint rule %s\n", id)
+				os.Exit(1)
+			}
+		}
+		// when a build runs the validation, only the environments block that the environment uses can fail it
+		environmentNames, err := buildEnvironmentNames()
+		if err != nil {
+			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		if printOutput {
-			resultingBS, err := yaml.Marshal(lYAML)
+		if format != findings.FormatText {
+			// the machine readable formats report the findings of every project in a single document
+			projects := []string{projectName}
+			found := findings.Findings{}
+			if allProjects {
+				projects, err = IdentifyPolysiteProjects(lagoonYAML)
+				if err != nil {
+					found = append(found, findings.FromError(ruleLagoonYmlLoad, lagoonYAML, err))
+				}
+			}
+			for _, project := range projects {
+				projectFindings, _ := lagoonYmlFindings(lagoonYAML, lagoonYAMLOverride, project, environmentNames, strict, disabledRules)
+				for _, f := range projectFindings {
+					if allProjects {
+						f.Project = project
+					}
+					found = append(found, f)
+				}
+			}
+			if err := findings.Write(os.Stdout, format, found, lagoonYmlRules(), bdtVersion); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if found.Errors() > 0 {
+				os.Exit(1)
+			}
+			return
+		}
+
+		if allProjects {
+			projects, err := IdentifyPolysiteProjects(lagoonYAML)
 			if err != nil {
-				fmt.Println("Unable to unmarshal resulting yml for printing: ", err)
+				fmt.Println("Could not validate your .lagoon.yml -", err.Error())
+				os.Exit(1)
+			}
+			if len(projects) == 0 {
+				fmt.Println("Could not validate your .lagoon.yml - no polysite projects found")
+				os.Exit(1)
+			}
+			failed := false
+			for _, project := range projects {
+				// the effective .lagoon.yml is always printed for each project, so that they can be compared
+				fmt.Printf("project: %s\n", project)
+				if err := validateLagoonYmlProject(lagoonYAML, lagoonYAMLOverride, project, environmentNames, strict, disabledRules, true); err != nil {
+					fmt.Printf("result: invalid - %v\n", err)
+					failed = true
+					continue
+				}
+				fmt.Println("result: valid")
+			}
+			if failed {
+				fmt.Println("Could not validate your .lagoon.yml - found invalid projects")
 				os.Exit(1)
 			}
-			fmt.Println(string(resultingBS))
+			return
+		}
+
+		if err := validateLagoonYmlProject(lagoonYAML, lagoonYAMLOverride, projectName, environmentNames, strict, disabledRules, printOutput); err != nil {
+			fmt.Println("Could not validate your .lagoon.yml -", err.Error())
+			os.Exit(1)
 		}
 	},
 }
 
-func ValidateLagoonYml(lagoonYml string, lagoonYmlOverride string, lagoonYmlEnvVar string, lYAML *lagoon.YAML, projectName string, debug bool) error {
-	if err := generator.LoadAndUnmarshalLagoonYml(lagoonYml, lagoonYmlOverride, lagoonYmlEnvVar, lYAML, projectName, debug); err != nil {
+// buildEnvironmentNames returns the names that the environment being built is known by, the branch (or pr-NUMBER for a
+// pullrequest) and the environment name, from the flags or the build variables. Nothing is returned if neither is set, as the
+// .lagoon.yml isn't being validated for a build
+func buildEnvironmentNames() ([]string, error) {
+	branch, err := rootCmd.PersistentFlags().GetString("branch")
+	if err != nil {
+		return nil, fmt.Errorf("error reading branch flag: %v", err)
+	}
+	environmentName, err := rootCmd.PersistentFlags().GetString("environment-name")
+	if err != nil {
+		return nil, fmt.Errorf("error reading environment-name flag: %v", err)
+	}
+	buildType, err := rootCmd.PersistentFlags().GetString("build-type")
+	if err != nil {
+		return nil, fmt.Errorf("error reading build-type flag: %v", err)
+	}
+	prNumber, err := rootCmd.PersistentFlags().GetString("pullrequest-number")
+	if err != nil {
+		return nil, fmt.Errorf("error reading pullrequest-number flag: %v", err)
+	}
+	branch = helpers.GetEnv("BRANCH", branch, false)
+	environmentName = helpers.GetEnv("ENVIRONMENT", environmentName, false)
+	if helpers.GetEnv("BUILD_TYPE", buildType, false) == "pullrequest" {
+		branch = fmt.Sprintf("pr-%v", helpers.GetEnv("PR_NUMBER", prNumber, false))
+	}
+	names := []string{}
+	for _, name := range []string{branch, environmentName} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// the rules of the findings that validate lagoon-yml reports, along with the lint rules
+const (
+	ruleLagoonYmlLoad    = "lagoon-yml"
+	ruleLagoonYmlSchema  = "lagoon-yml-schema"
+	ruleLagoonYmlWarning = "lagoon-yml-warning"
+	ruleCronjobCommand   = "cronjob-command"
+)
+
+// lagoonYmlRules returns every rule that validate lagoon-yml can report findings for
+func lagoonYmlRules() []findings.Rule {
+	rules := []findings.Rule{
+		{
+			ID:          ruleLagoonYmlLoad,
+			Severity:    findings.SeverityError,
+			Description: "the .lagoon.yml and its overrides must be readable and valid yaml",
+		},
+		{
+			ID:          ruleLagoonYmlSchema,
+			Severity:    findings.SeverityError,
+			Description: "the .lagoon.yml and its overrides must match the .lagoon.yml JSON Schema, unknown fields are warnings unless --strict is used",
+		},
+		{
+			ID:          ruleLagoonYmlWarning,
+			Severity:    findings.SeverityWarning,
+			Description: "values in the .lagoon.yml that are deprecated, converted, or replaced by a polysite project",
+		},
+		{
+			ID:          ruleCronjobCommand,
+			Severity:    findings.SeverityError,
+			Description: "cronjob commands must be a single line",
+		},
+	}
+	for _, rule := range lagoon.LintRules {
+		rules = append(rules, findings.Rule{ID: rule.ID, Severity: rule.Severity, Description: rule.Description})
+	}
+	return rules
+}
+
+// validateLagoonYmlProject validates the .lagoon.yml for a project against the schema and the lint rules, printing any
+// problems that are found, and returns an error if it isn't valid
+func validateLagoonYmlProject(lagoonYAML, lagoonYAMLOverride, projectName string, environmentNames []string, strict bool, disabledRules []string, printOutput bool) error {
+	found, lYAML := lagoonYmlFindings(lagoonYAML, lagoonYAMLOverride, projectName, environmentNames, strict, disabledRules)
+	if printOutput && lYAML != nil {
+		resultingBS, err := yaml.Marshal(lYAML)
+		if err != nil {
+			return fmt.Errorf("unable to marshal resulting yml for printing: %v", err)
+		}
+		fmt.Println(string(resultingBS))
+	}
+	if err := findings.WriteText(os.Stdout, found); err != nil {
 		return err
 	}
+	if errorCount := found.Errors(); errorCount > 0 {
+		return fmt.Errorf("found %d errors", errorCount)
+	}
+	return nil
+}
+
+// lagoonYmlFindings validates the .lagoon.yml for a project against the schema and the lint rules, and returns everything
+// that was found. The resulting .lagoon.yml is returned too, or nil if it couldn't be loaded.
+// If the names of the environment being built are provided, the lint errors in the environments blocks that aren't used by
+// that environment are reported as warnings, so that a problem in the block of another environment doesn't fail the build.
+func lagoonYmlFindings(lagoonYAML, lagoonYAMLOverride, projectName string, environmentNames []string, strict bool, disabledRules []string) (findings.Findings, *lagoon.YAML) {
+	found := findings.Findings{}
+	schemaErrors, err := ValidateLagoonYmlSchema(lagoonYAML, lagoonYAMLOverride, "LAGOON_YAML_OVERRIDE", projectName, strict)
+	if err != nil {
+		return append(found, findings.FromError(ruleLagoonYmlLoad, lagoonYAML, err)), nil
+	}
+	found = append(found, schemaFindings(schemaErrors)...)
+	if found.Errors() > 0 {
+		return found, nil
+	}
+
+	lYAML := &lagoon.YAML{}
+	warnings := lagoon.Warnings{}
+	if err := generator.LoadAndUnmarshalLagoonYml(lagoonYAML, lagoonYAMLOverride, "LAGOON_YAML_OVERRIDE", lYAML, projectName, false, &warnings); err != nil {
+		return append(found, findings.FromError(ruleLagoonYmlLoad, lagoonYAML, err)), nil
+	}
+	found = append(found, warningFindings(warnings)...)
+
+	// the lint rules run against the merged .lagoon.yml, so each result is located in the last source that has its path
+	sources := lagoonYmlSources(lagoonYAML, lagoonYAMLOverride, "LAGOON_YAML_OVERRIDE")
+	found = append(found, cronjobFindings(lYAML, sources, projectName)...)
+	lintResults, err := lagoon.Lint(lYAML, disabledRules)
+	if err != nil {
+		return append(found, findings.FromError(ruleLagoonYmlLoad, lagoonYAML, err)), lYAML
+	}
+	buildEnvironment, scoped := "", false
+	if len(environmentNames) > 0 {
+		// an invalid environment pattern fails the build, so the results can't be scoped to the environment being built
+		if _, key, err := lYAML.Environments.Resolve(environmentNames...); err == nil {
+			buildEnvironment, scoped = key, true
+		}
+	}
+	for _, r := range lintResults {
+		severity, message := r.Severity, r.Message
+		if scoped && severity == findings.SeverityError && r.Environment() != "" && r.Environment() != buildEnvironment {
+			severity = findings.SeverityWarning
+			message = fmt.Sprintf("%s, this is a warning as the environment being built doesn't use this block", message)
+		}
+		found = append(found, locateFinding(findings.Finding{
+			Rule:     r.Rule,
+			Severity: severity,
+			Path:     r.Path,
+			Message:  message,
+		}, r.Keys(), sources, projectName))
+	}
+	return found, lYAML
+}
+
+type lagoonYmlSource struct {
+	name string
+	data []byte
+}
+
+// lagoonYmlSources returns the contents of the override variable, the override file, and the .lagoon.yml, in the order that
+// a value in the merged .lagoon.yml should be located in them
+func lagoonYmlSources(lagoonYml string, lagoonYmlOverride string, lagoonYmlEnvVar string) []lagoonYmlSource {
+	sources := []lagoonYmlSource{}
+	if envLagoonYamlBase64 := os.Getenv(lagoonYmlEnvVar); lagoonYmlEnvVar != "" && envLagoonYamlBase64 != "" {
+		if data, err := base64.StdEncoding.DecodeString(envLagoonYamlBase64); err == nil {
+			sources = append(sources, lagoonYmlSource{name: lagoonYmlEnvVar, data: data})
+		}
+	}
+	for _, file := range []string{lagoonYmlOverride, lagoonYml} {
+		if data, err := os.ReadFile(file); err == nil {
+			sources = append(sources, lagoonYmlSource{name: file, data: data})
+		}
+	}
+	return sources
+}
+
+// locateFinding sets the file, line and column of a finding from the first source that has its path. If no source has the path,
+// such as when the finding is for a key that is missing, the finding is located at the closest parent key that a source has
+func locateFinding(f findings.Finding, path []string, sources []lagoonYmlSource, projectName string) findings.Finding {
+	for keys := path; len(keys) > 0; keys = keys[:len(keys)-1] {
+		for _, source := range sources {
+			if line, column, ok := lagoon.LocatePath(source.data, projectName, keys); ok {
+				f.File = source.name
+				f.Line = line
+				f.Column = column
+				return f
+			}
+		}
+	}
+	return f
+}
+
+// schemaFindings converts the errors found by validating the .lagoon.yml against the schema into findings
+func schemaFindings(schemaErrors []lagoon.SchemaError) findings.Findings {
+	found := findings.Findings{}
+	for _, e := range schemaErrors {
+		found = append(found, findings.Finding{
+			Rule:     ruleLagoonYmlSchema,
+			Severity: e.Severity,
+			File:     e.File,
+			Path:     e.Path,
+			Line:     e.Line,
+			Column:   e.Column,
+			Message:  e.Message,
+		})
+	}
+	return found
+}
+
+// warningFindings converts the warnings found while loading the .lagoon.yml into findings
+func warningFindings(warnings lagoon.Warnings) findings.Findings {
+	found := findings.Findings{}
+	for _, w := range warnings {
+		found = append(found, findings.Finding{
+			Rule:     ruleLagoonYmlWarning,
+			Severity: findings.SeverityWarning,
+			File:     w.File,
+			Path:     w.Path,
+			Line:     w.Line,
+			Column:   w.Column,
+			Message:  w.Message,
+		})
+	}
+	return found
+}
 
-	failedCronjobValidation := false
-	for eName, e := range lYAML.Environments {
-		for _, cronjob := range e.Cronjobs {
+// cronjobFindings checks the cronjob commands of every environment, each finding is located in the sources
+func cronjobFindings(lYAML *lagoon.YAML, sources []lagoonYmlSource, projectName string) findings.Findings {
+	found := findings.Findings{}
+	environments := []string{}
+	for name := range lYAML.Environments {
+		environments = append(environments, name)
+	}
+	sort.Strings(environments)
+	for _, name := range environments {
+		for idx, cronjob := range lYAML.Environments[name].Cronjobs {
 			if err := ValidateCronjob(&cronjob); err != nil {
-				failedCronjobValidation = true
-				fmt.Println(fmt.Errorf("error: environment %s: %v", eName, err))
+				path := []string{"environments", name, "cronjobs", strconv.Itoa(idx), "command"}
+				found = append(found, locateFinding(findings.Finding{
+					Rule:     ruleCronjobCommand,
+					Severity: findings.SeverityError,
+					Path:     lagoon.FormatPath(path),
+					Message:  err.Error(),
+				}, path, sources, projectName))
 			}
 		}
 	}
+	return found
+}
 
-	if failedCronjobValidation {
+func ValidateLagoonYml(lagoonYml string, lagoonYmlOverride string, lagoonYmlEnvVar string, lYAML *lagoon.YAML, projectName string, debug bool) error {
+	warnings := lagoon.Warnings{}
+	if err := generator.LoadAndUnmarshalLagoonYml(lagoonYml, lagoonYmlOverride, lagoonYmlEnvVar, lYAML, projectName, debug, &warnings); err != nil {
+		return err
+	}
+	found := append(warningFindings(warnings), cronjobFindings(lYAML, nil, projectName)...)
+	if err := findings.WriteText(os.Stdout, found); err != nil {
+		return err
+	}
+	if found.Errors() > 0 {
 		return fmt.Errorf("found invalid cron jobs")
 	}
-
 	return nil
 }
 
+// ValidateLagoonYmlSchema validates the .lagoon.yml, the override file if it exists, and the override environment variable if
+// it is set, against the .lagoon.yml schema
+func ValidateLagoonYmlSchema(lagoonYml string, lagoonYmlOverride string, lagoonYmlEnvVar string, projectName string, strict bool) ([]lagoon.SchemaError, error) {
+	files := []string{lagoonYml}
+	if _, err := os.Stat(lagoonYmlOverride); err == nil {
+		files = append(files, lagoonYmlOverride)
+	}
+	schemaErrors := []lagoon.SchemaError{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read %v: %v", file, err)
+		}
+		validate := lagoon.ValidateSchema
+		if file != lagoonYml {
+			validate = lagoon.ValidateOverrideSchema
+		}
+		fileErrors, err := validate(data, projectName, strict)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't validate %v: %v", file, err)
+		}
+		for _, e := range fileErrors {
+			e.File = file
+			schemaErrors = append(schemaErrors, e)
+		}
+	}
+	if envLagoonYamlBase64 := os.Getenv(lagoonYmlEnvVar); lagoonYmlEnvVar != "" && envLagoonYamlBase64 != "" {
+		data, err := base64.StdEncoding.DecodeString(envLagoonYamlBase64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode %v - is it base64 encoded?", lagoonYmlEnvVar)
+		}
+		envErrors, err := lagoon.ValidateOverrideSchema(data, projectName, strict)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't validate %v: %v", lagoonYmlEnvVar, err)
+		}
+		for _, e := range envErrors {
+			e.File = lagoonYmlEnvVar
+			schemaErrors = append(schemaErrors, e)
+		}
+	}
+	return schemaErrors, nil
+}
+
+// writeLintRules writes the lint rules as a table
+func writeLintRules(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSEVERITY\tDESCRIPTION")
+	for _, rule := range lagoon.LintRules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", rule.ID, rule.Severity, rule.Description)
+	}
+	tw.Flush()
+}
+
 func init() {
 	validateCmd.PersistentFlags().BoolP("print-resulting-lagoonyml", "", false,
 		"Display the resulting, post merging, lagoon.yml file.")
+	validateLagoonYml.Flags().BoolP("schema", "", false,
+		"Print the .lagoon.yml JSON Schema and exit.")
+	validateLagoonYml.Flags().IntP("schema-version", "", 1,
+		"The version of the .lagoon.yml to print the JSON Schema for.")
+	validateLagoonYml.Flags().BoolP("strict", "", false,
+		"Treat unknown fields in the .lagoon.yml as errors instead of warnings.")
+	validateLagoonYml.Flags().StringSliceP("disable-rule", "", []string{},
+		"The ID of a lint rule to disable, can be repeated or comma separated.")
+	validateLagoonYml.Flags().BoolP("list-rules", "", false,
+		"Print the lint rules and exit.")
+	validateLagoonYml.Flags().BoolP("all-projects", "", false,
+		"Validate the .lagoon.yml for every polysite project in it, printing the resulting .lagoon.yml for each project.")
+	validateLagoonYml.Flags().StringP("format", "", findings.FormatText,
+		"The format to display the findings in (text, json or sarif), the resulting .lagoon.yml is only printed with text.")
 	validateCmd.AddCommand(validateLagoonYml)
 }
 
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/uselagoon/build-deploy-tool/internal/findings"
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
 	"sigs.k8s.io/yaml"
@@ -186,7 +189,7 @@ func TestValidateLagoonYml(t *testing.T) {
 
 func TestMultilineCronjobs(t *testing.T) {
 	var l lagoon.YAML
-	if err := generator.LoadAndUnmarshalLagoonYml("internal/testdata/validate-lagoon-yml/cronjobs/multiline-cronjobs.lagoon.yml", "", "", &l, "", false); err != nil {
+	if err := generator.LoadAndUnmarshalLagoonYml("internal/testdata/validate-lagoon-yml/cronjobs/multiline-cronjobs.lagoon.yml", "", "", &l, "", false, nil); err != nil {
 		t.Fatalf("couldn't load and unmarshal YAML: %v", err)
 	}
 
@@ -207,7 +210,7 @@ func TestMultilineCronjobs(t *testing.T) {
 
 func TestSinglelineCronjobs(t *testing.T) {
 	var l lagoon.YAML
-	if err := generator.LoadAndUnmarshalLagoonYml("internal/testdata/validate-lagoon-yml/cronjobs/singleline-cronjobs.lagoon.yml", "", "", &l, "", false); err != nil {
+	if err := generator.LoadAndUnmarshalLagoonYml("internal/testdata/validate-lagoon-yml/cronjobs/singleline-cronjobs.lagoon.yml", "", "", &l, "", false, nil); err != nil {
 		t.Fatalf("couldn't load and unmarshal YAML: %v", err)
 	}
 
@@ -225,3 +228,212 @@ func TestSinglelineCronjobs(t *testing.T) {
 	}
 
 }
+
+func TestValidateLagoonYmlSchema(t *testing.T) {
+	tests := []struct {
+		name                     string
+		lagoonYml                string
+		lagoonOverrideYml        string
+		lagoonOverrideEnvVarFile string
+		strict                   bool
+		want                     []string
+		wantErrorCount           int
+	}{
+		{
+			name:              "test1 - override file and tasks are valid",
+			lagoonYml:         "internal/testdata/validate-lagoon-yml/test2/lagoon.yml",
+			lagoonOverrideYml: "internal/testdata/validate-lagoon-yml/test2/lagoon-override.yml",
+			want:              []string{},
+		},
+		{
+			name:              "test2 - errors are reported from the override file",
+			lagoonYml:         "internal/testdata/validate-lagoon-yml/schema/lagoon.yml",
+			lagoonOverrideYml: "internal/testdata/validate-lagoon-yml/schema/lagoon-override.yml",
+			want: []string{
+				"warning: internal/testdata/validate-lagoon-yml/schema/lagoon.yml:7:15: environments.main.routes.0.nginx.0.\"example.com\".tls_acme: Additional property tls_acme is not allowed [lagoon-yml-schema]",
+				"error: internal/testdata/validate-lagoon-yml/schema/lagoon-override.yml:8:16: environments.main.cronjobs.0.inPod: Invalid type. Expected: boolean, given: string [lagoon-yml-schema]",
+			},
+			wantErrorCount: 1,
+		},
+		{
+			name:                     "test3 - strict errors are reported from the override variable",
+			lagoonYml:                "internal/testdata/validate-lagoon-yml/test1/lagoon.yml",
+			lagoonOverrideEnvVarFile: "internal/testdata/validate-lagoon-yml/schema/lagoon.yml",
+			strict:                   true,
+			want: []string{
+				"error: VALIDATE_LAGOON_YML_TEST_ENV:7:15: environments.main.routes.0.nginx.0.\"example.com\".tls_acme: Additional property tls_acme is not allowed [lagoon-yml-schema]",
+			},
+			wantErrorCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const testEnvVar = "VALIDATE_LAGOON_YML_TEST_ENV"
+			os.Setenv(testEnvVar, "")
+			if tt.lagoonOverrideEnvVarFile != "" {
+				contents, err := os.ReadFile(tt.lagoonOverrideEnvVarFile)
+				if err != nil {
+					t.Errorf("Unable to read contents of env var test file '%v'", tt.lagoonOverrideEnvVarFile)
+				}
+				os.Setenv(testEnvVar, base64.StdEncoding.EncodeToString(contents))
+			}
+			t.Cleanup(func() {
+				os.Unsetenv(testEnvVar)
+			})
+			schemaErrors, err := ValidateLagoonYmlSchema(tt.lagoonYml, tt.lagoonOverrideYml, testEnvVar, "", tt.strict)
+			if err != nil {
+				t.Errorf("ValidateLagoonYmlSchema() error = %v", err)
+				return
+			}
+			var out bytes.Buffer
+			found := schemaFindings(schemaErrors)
+			if err := findings.WriteText(&out, found); err != nil {
+				t.Fatalf("WriteText() error = %v", err)
+			}
+			got := []string{}
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if line != "" {
+					got = append(got, line)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateLagoonYmlSchema() = %v, want %v", got, tt.want)
+			}
+			if errorCount := found.Errors(); errorCount != tt.wantErrorCount {
+				t.Errorf("error count = %v, want %v", errorCount, tt.wantErrorCount)
+			}
+		})
+	}
+}
+
+func TestValidateLagoonYmlAllProjects(t *testing.T) {
+	tests := []struct {
+		name      string
+		lagoonYml string
+		wantValid map[string]bool
+		wantCrons map[string][]string
+	}{
+		{
+			name:      "test1 - each project is validated with its effective .lagoon.yml",
+			lagoonYml: "internal/testdata/validate-lagoon-yml/polysite/lagoon.yml",
+			wantValid: map[string]bool{
+				"project-a": true,
+				"project-b": false,
+			},
+			wantCrons: map[string][]string{
+				// the top-level cronjobs are only added once, and not when the project has a cronjob with the same name
+				"project-a": {"project cron", "shared cron"},
+				"project-b": {"project cron", "shared cron"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projects, err := IdentifyPolysiteProjects(tt.lagoonYml)
+			if err != nil {
+				t.Fatalf("IdentifyPolysiteProjects() error = %v", err)
+			}
+			if len(projects) != len(tt.wantValid) {
+				t.Errorf("IdentifyPolysiteProjects() = %v, want %v projects", projects, len(tt.wantValid))
+			}
+			for _, project := range projects {
+				err := validateLagoonYmlProject(tt.lagoonYml, "", project, nil, false, nil, false)
+				if (err == nil) != tt.wantValid[project] {
+					t.Errorf("validateLagoonYmlProject() project %s error = %v, want valid %v", project, err, tt.wantValid[project])
+				}
+				lYAML := &lagoon.YAML{}
+				if err := ValidateLagoonYml(tt.lagoonYml, "", "", lYAML, project, false); err != nil {
+					t.Errorf("ValidateLagoonYml() project %s error = %v", project, err)
+					continue
+				}
+				crons := []string{}
+				for _, cronjob := range lYAML.Environments["main"].Cronjobs {
+					crons = append(crons, cronjob.Name)
+				}
+				if !reflect.DeepEqual(crons, tt.wantCrons[project]) {
+					t.Errorf("project %s cronjobs = %v, want %v", project, crons, tt.wantCrons[project])
+				}
+			}
+		})
+	}
+}
+
+func TestLagoonYmlFindings(t *testing.T) {
+	tests := []struct {
+		name              string
+		lagoonYml         string
+		lagoonOverrideYml string
+		environmentNames  []string
+		want              []string
+	}{
+		{
+			name:              "test1 - findings are located in the file that defines them",
+			lagoonYml:         "internal/testdata/validate-lagoon-yml/findings/lagoon.yml",
+			lagoonOverrideYml: "internal/testdata/validate-lagoon-yml/findings/lagoon-override.yml",
+			want: []string{
+				"error cronjob-command internal/testdata/validate-lagoon-yml/findings/lagoon.yml:7:18 environments.main.cronjobs.0.command",
+				"error route-hostname internal/testdata/validate-lagoon-yml/findings/lagoon-override.yml:5:13 environments.main.routes.0.nginx.0",
+			},
+		},
+		{
+			name:              "test2 - schema errors stop the validation",
+			lagoonYml:         "internal/testdata/validate-lagoon-yml/schema/lagoon.yml",
+			lagoonOverrideYml: "internal/testdata/validate-lagoon-yml/schema/lagoon-override.yml",
+			want: []string{
+				"warning lagoon-yml-schema internal/testdata/validate-lagoon-yml/schema/lagoon.yml:7:15 environments.main.routes.0.nginx.0.\"example.com\".tls_acme",
+				"error lagoon-yml-schema internal/testdata/validate-lagoon-yml/schema/lagoon-override.yml:8:16 environments.main.cronjobs.0.inPod",
+			},
+		},
+		{
+			name:      "test3 - a file that can't be read",
+			lagoonYml: "internal/testdata/validate-lagoon-yml/findings/missing.yml",
+			want: []string{
+				"error lagoon-yml internal/testdata/validate-lagoon-yml/findings/missing.yml:0:0 ",
+			},
+		},
+		{
+			name:      "test4 - a missing key is located at its parent",
+			lagoonYml: "internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml",
+			want: []string{
+				"error route-hostname internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml:11:13 environments.dev.routes.0.nginx.0",
+				"error cronjob-schedule internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml:5:9 environments.main.cronjobs.0.schedule",
+			},
+		},
+		{
+			name:             "test5 - only the environments block of the environment being built has errors",
+			lagoonYml:        "internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml",
+			environmentNames: []string{"dev"},
+			want: []string{
+				"error route-hostname internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml:11:13 environments.dev.routes.0.nginx.0",
+				"warning cronjob-schedule internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml:5:9 environments.main.cronjobs.0.schedule",
+			},
+		},
+		{
+			name:             "test6 - an environment without a block only has warnings",
+			lagoonYml:        "internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml",
+			environmentNames: []string{"feature/test", "feature-test"},
+			want: []string{
+				"warning route-hostname internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml:11:13 environments.dev.routes.0.nginx.0",
+				"warning cronjob-schedule internal/testdata/validate-lagoon-yml/findings/lagoon.environments.yml:5:9 environments.main.cronjobs.0.schedule",
+			},
+		},
+		{
+			name:             "test7 - domains that use variables are not checked before they are interpolated",
+			lagoonYml:        "internal/testdata/node/lagoon.interpolation.yml",
+			environmentNames: []string{"main", "main"},
+			want:             []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, _ := lagoonYmlFindings(tt.lagoonYml, tt.lagoonOverrideYml, "", tt.environmentNames, false, nil)
+			got := []string{}
+			for _, f := range found {
+				got = append(got, fmt.Sprintf("%s %s %s:%d:%d %s", f.Severity, f.Rule, f.File, f.Line, f.Column, f.Path))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lagoonYmlFindings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	generator "github.com/uselagoon/build-deploy-tool/internal/generator"
+)
+
+var validateProject = &cobra.Command{
+	Use:   "project",
+	Short: "Verify that the .lagoon.yml references services that exist in the docker-compose file",
+	Long: `Verify that the .lagoon.yml references services that exist in the docker-compose file.
+The .lagoon.yml and docker-compose file are loaded the same way as a build, and every route, path route, task,
+cronjob, type override and override is checked against the docker-compose services. All errors are reported at once`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gen, err := generator.GenerateInput(*rootCmd, false)
+		if err != nil {
+			return err
+		}
+		projectErrors, err := generator.ValidateProject(gen)
+		if err != nil {
+			return fmt.Errorf("couldn't validate project: %v", err)
+		}
+		if writeProjectErrors(os.Stdout, projectErrors) > 0 {
+			fmt.Println("Could not validate your project - found errors")
+			return exitWithCode(cmd, 1)
+		}
+		return nil
+	},
+}
+
+// writeProjectErrors writes the project errors, and returns the number of errors
+func writeProjectErrors(w io.Writer, projectErrors []generator.ProjectError) int {
+	for _, e := range projectErrors {
+		fmt.Fprintf(w, "error: %s\n", e.String())
+	}
+	return len(projectErrors)
+}
+
+func init() {
+	validateCmd.AddCommand(validateProject)
+}
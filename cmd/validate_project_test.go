@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/testdata"
+
+	// changes the testing to source from root so paths to test resources must be defined from repo root
+	_ "github.com/uselagoon/build-deploy-tool/internal/testing"
+)
+
+func TestValidateProject(t *testing.T) {
+	tests := []struct {
+		name string
+		args testdata.TestData
+		want []string
+	}{
+		{
+			name: "test1 - every broken reference is reported",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/validate-project/lagoon.yml",
+				}, true),
+			want: []string{
+				"docker-compose.redis: no lagoon.type has been set for service redis",
+				"environments.dev.autogeneratePathRoutes.0: autogenerated path route has no path defined",
+				"environments.main.types.mariadb: type override references the service mariadb that is not defined in the docker-compose file",
+				"environments.main.overrides.nginx.initContainers.0.service: container migrate references the service drupal that is not defined in the docker-compose file",
+				"environments.main.overrides.solr: override references the service solr that is not defined in the docker-compose file",
+				"environments.main.routes.0.nginx.0.\"example.com\".pathRoutes.0.toService: path route for example.com references the service node-3000 that has the type none in the main environment",
+				"environments.main.routes.0.nginx.0.\"example.com\".pathRoutes.1.toService: path route for example.com references the service node-4000 that is not defined in the docker-compose file",
+				"environments.main.routes.1.varnish: route references the service varnish that is not defined in the docker-compose file",
+				"environments.main.cronjobs.1.service: cronjob mail references the service mailhog that has the type none in the main environment",
+				"environments.main.cronjobs.2.service: cronjob node references the service node that has the type none in the main environment",
+				"routes.autogenerate.pathRoutes.0.toService: autogenerated path route references the service varnish that is not defined in the docker-compose file",
+				"tasks.post-rollout.0.run.service: task drush cr references the service drupal that is not defined in the docker-compose file",
+			},
+		},
+		{
+			name: "test2 - valid project",
+			args: testdata.GetSeedData(
+				testdata.TestData{
+					ProjectName:     "example-project",
+					EnvironmentName: "main",
+					Branch:          "main",
+					LagoonYAML:      "internal/testdata/validate-project/lagoon.valid.yml",
+				}, true),
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := testdata.SetupEnvironment(*rootCmd, "", tt.args)
+			if err != nil {
+				t.Errorf("%v", err)
+				return
+			}
+			projectErrors, err := generator.ValidateProject(gen)
+			if err != nil {
+				t.Errorf("ValidateProject() error = %v", err)
+				return
+			}
+			got := []string{}
+			for _, e := range projectErrors {
+				got = append(got, e.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateProject() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(nil)
+			})
+		})
+	}
+}
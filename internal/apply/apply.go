@@ -0,0 +1,168 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// FieldManager is the field manager used for all server-side applies
+const FieldManager = "build-deploy-tool"
+
+// Result is the outcome of applying a single object
+type Result string
+
+const (
+	Created    Result = "created"
+	Configured Result = "configured"
+	Unchanged  Result = "unchanged"
+)
+
+// ObjectResult is the result of applying a single object
+type ObjectResult struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Result    Result `json:"result"`
+}
+
+func (r ObjectResult) String() string {
+	return fmt.Sprintf("%s/%s %s", strings.ToLower(r.Kind), r.Name, r.Result)
+}
+
+// kindOrder is the order that kinds are applied in, so that anything a workload depends on exists before the workload does.
+// any kind not in this list is applied last
+var kindOrder = []string{
+	"Secret",
+	"ConfigMap",
+	"PersistentVolumeClaim",
+	"MariaDBConsumer",
+	"MongoDBConsumer",
+	"PostgreSQLConsumer",
+	"Service",
+	"NetworkPolicy",
+	"Deployment",
+	"CronJob",
+	"Ingress",
+	"Schedule",
+	"PreBackupPod",
+}
+
+// Applier applies objects into a namespace using server-side apply
+type Applier struct {
+	Client    dynamic.Interface
+	Namespace string
+	Debug     bool
+}
+
+// NewApplier returns an applier for the provided namespace
+func NewApplier(client dynamic.Interface, namespace string, debug bool) *Applier {
+	return &Applier{
+		Client:    client,
+		Namespace: namespace,
+		Debug:     debug,
+	}
+}
+
+// Order sorts objects into the order they should be applied in, objects of the same kind are sorted by name
+func Order(objects []unstructured.Unstructured) {
+	manifests.Sort(objects)
+	sort.SliceStable(objects, func(i, j int) bool {
+		return kindWeight(objects[i].GetKind()) < kindWeight(objects[j].GetKind())
+	})
+}
+
+func kindWeight(kind string) int {
+	for idx, k := range kindOrder {
+		if k == kind {
+			return idx
+		}
+	}
+	return len(kindOrder)
+}
+
+// Apply applies all the provided objects in dependency order, if an object fails to apply then the results of any objects
+// applied so far are returned along with the error
+func (a *Applier) Apply(ctx context.Context, objects []unstructured.Unstructured) ([]ObjectResult, error) {
+	ordered := make([]unstructured.Unstructured, len(objects))
+	copy(ordered, objects)
+	Order(ordered)
+	results := []ObjectResult{}
+	for _, o := range ordered {
+		result, err := a.applyObject(ctx, o.DeepCopy())
+		if err != nil {
+			return results, err
+		}
+		if a.Debug {
+			fmt.Println(result.String())
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (a *Applier) applyObject(ctx context.Context, obj *unstructured.Unstructured) (ObjectResult, error) {
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(a.Namespace)
+	}
+	// generated templates include a null creationTimestamp which can't be applied
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	result := ObjectResult{
+		Kind:      obj.GetKind(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+	}
+	client := a.Client.Resource(GroupVersionResource(obj.GroupVersionKind())).Namespace(obj.GetNamespace())
+	existing, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return result, fmt.Errorf("couldn't get %s %s: %v", obj.GetKind(), obj.GetName(), err)
+		}
+		existing = nil
+	}
+	applied, err := client.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{
+		FieldManager: FieldManager,
+		Force:        true,
+	})
+	if err != nil {
+		return result, fmt.Errorf("couldn't apply %s %s: %v", obj.GetKind(), obj.GetName(), err)
+	}
+	switch {
+	case existing == nil:
+		result.Result = Created
+	case unchanged(existing, applied):
+		result.Result = Unchanged
+	default:
+		result.Result = Configured
+	}
+	return result, nil
+}
+
+// unchanged compares the object before and after it was applied, ignoring fields that the server updates on every write
+func unchanged(before, after *unstructured.Unstructured) bool {
+	b := before.DeepCopy()
+	a := after.DeepCopy()
+	for _, o := range []*unstructured.Unstructured{a, b} {
+		unstructured.RemoveNestedField(o.Object, "metadata", "managedFields")
+		unstructured.RemoveNestedField(o.Object, "metadata", "resourceVersion")
+		unstructured.RemoveNestedField(o.Object, "metadata", "generation")
+	}
+	return reflect.DeepEqual(a.Object, b.Object)
+}
+
+// GroupVersionResource returns the resource for a kind, all the kinds the build-deploy-tool creates follow the standard
+// pluralisation rules so no discovery is required
+func GroupVersionResource(gvk schema.GroupVersionKind) schema.GroupVersionResource {
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	return gvr
+}
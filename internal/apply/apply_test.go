@@ -0,0 +1,151 @@
+package apply
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeClient returns a fake dynamic client that handles server-side apply by creating or replacing the object,
+// the default fake object tracker is unable to create objects through apply
+func newFakeClient(objects ...runtime.Object) *fake.FakeDynamicClient {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pa := action.(k8stesting.PatchAction)
+		if pa.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(pa.GetPatch(), &obj.Object); err != nil {
+			return true, nil, err
+		}
+		tracker := client.Tracker()
+		_, err := tracker.Get(pa.GetResource(), pa.GetNamespace(), pa.GetName())
+		switch {
+		case apierrors.IsNotFound(err):
+			err = tracker.Create(pa.GetResource(), obj, pa.GetNamespace())
+		case err == nil:
+			err = tracker.Update(pa.GetResource(), obj, pa.GetNamespace())
+		}
+		if err != nil {
+			return true, nil, err
+		}
+		ret, err := tracker.Get(pa.GetResource(), pa.GetNamespace(), pa.GetName())
+		return true, ret, err
+	})
+	return client
+}
+
+func newObject(apiVersion, kind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+	}}
+	if namespace != "" {
+		obj.SetNamespace(namespace)
+	}
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+	return obj
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		objects  []unstructured.Unstructured
+		want     []ObjectResult
+		wantErr  bool
+	}{
+		{
+			name: "test1 - objects are created in dependency order",
+			objects: []unstructured.Unstructured{
+				*newObject("networking.k8s.io/v1", "Ingress", "", "example.com", nil),
+				*newObject("batch/v1", "CronJob", "", "cronjob-cli-drush-cron", nil),
+				*newObject("apps/v1", "Deployment", "", "nginx", nil),
+				*newObject("apps/v1", "Deployment", "", "cli", nil),
+				*newObject("v1", "Service", "", "nginx", nil),
+				*newObject("v1", "PersistentVolumeClaim", "", "nginx", nil),
+				*newObject("v1", "Secret", "", "lagoon-private-registry-my-registry", nil),
+			},
+			want: []ObjectResult{
+				{Kind: "Secret", Name: "lagoon-private-registry-my-registry", Namespace: "example-project-main", Result: Created},
+				{Kind: "PersistentVolumeClaim", Name: "nginx", Namespace: "example-project-main", Result: Created},
+				{Kind: "Service", Name: "nginx", Namespace: "example-project-main", Result: Created},
+				{Kind: "Deployment", Name: "cli", Namespace: "example-project-main", Result: Created},
+				{Kind: "Deployment", Name: "nginx", Namespace: "example-project-main", Result: Created},
+				{Kind: "CronJob", Name: "cronjob-cli-drush-cron", Namespace: "example-project-main", Result: Created},
+				{Kind: "Ingress", Name: "example.com", Namespace: "example-project-main", Result: Created},
+			},
+		},
+		{
+			name: "test2 - existing objects are configured or unchanged",
+			existing: []runtime.Object{
+				newObject("v1", "Service", "example-project-main", "nginx", map[string]interface{}{"type": "ClusterIP"}),
+				newObject("apps/v1", "Deployment", "example-project-main", "nginx", map[string]interface{}{"replicas": int64(1)}),
+			},
+			objects: []unstructured.Unstructured{
+				*newObject("v1", "Service", "", "nginx", map[string]interface{}{"type": "ClusterIP"}),
+				*newObject("apps/v1", "Deployment", "", "nginx", map[string]interface{}{"replicas": int64(2)}),
+				*newObject("backup.appuio.ch/v1alpha1", "Schedule", "", "k8up-lagoon-backup-schedule", nil),
+			},
+			want: []ObjectResult{
+				{Kind: "Service", Name: "nginx", Namespace: "example-project-main", Result: Unchanged},
+				{Kind: "Deployment", Name: "nginx", Namespace: "example-project-main", Result: Configured},
+				{Kind: "Schedule", Name: "k8up-lagoon-backup-schedule", Namespace: "example-project-main", Result: Created},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(tt.existing...)
+			a := NewApplier(client, "example-project-main", false)
+			got, err := a.Apply(context.TODO(), tt.objects)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+			// check that everything was actually applied
+			for _, o := range tt.objects {
+				_, err := client.Resource(GroupVersionResource(o.GroupVersionKind())).Namespace("example-project-main").Get(context.TODO(), o.GetName(), metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("couldn't get applied %s %s: %v", o.GetKind(), o.GetName(), err)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPatchType(t *testing.T) {
+	client := newFakeClient()
+	a := NewApplier(client, "example-project-main", false)
+	_, err := a.Apply(context.TODO(), []unstructured.Unstructured{*newObject("v1", "Service", "", "nginx", nil)})
+	if err != nil {
+		t.Errorf("Apply() error = %v", err)
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "patch" {
+			continue
+		}
+		pa := action.(k8stesting.PatchActionImpl)
+		if pa.GetPatchType() != types.ApplyPatchType {
+			t.Errorf("Apply() patch type = %v, want %v", pa.GetPatchType(), types.ApplyPatchType)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package findings is the result model shared by the validate commands, and the renderers for it.
+//
+// A finding is a single problem found by a validator, identified by the rule that found it. Findings are rendered as
+//   - text, one finding per line
+//   - json, the findings along with the number of errors and warnings
+//   - sarif, a SARIF 2.1.0 log that code scanning tools can use to show the findings on the lines they were found at
+package findings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// the severities of a finding
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// the formats that findings can be rendered in
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// Formats is every format that findings can be rendered in
+var Formats = []string{FormatText, FormatJSON, FormatSARIF}
+
+// Rule is a check that produces findings
+type Rule struct {
+	ID          string `json:"id"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// Finding is a problem found by a rule
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Project  string `json:"project,omitempty"`
+	File     string `json:"file,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (f Finding) String() string {
+	s := f.Message
+	if f.Path != "" {
+		s = fmt.Sprintf("%s: %s", f.Path, s)
+	}
+	switch {
+	case f.File != "" && f.Line > 0:
+		s = fmt.Sprintf("%s:%d:%d: %s", f.File, f.Line, f.Column, s)
+	case f.File != "":
+		s = fmt.Sprintf("%s: %s", f.File, s)
+	}
+	return fmt.Sprintf("%s [%s]", s, f.Rule)
+}
+
+// Findings are the findings of one or more validators
+type Findings []Finding
+
+// Errors returns the number of findings that are errors
+func (f Findings) Errors() int {
+	count := 0
+	for _, finding := range f {
+		if finding.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
+
+// Warnings returns the number of findings that are warnings
+func (f Findings) Warnings() int {
+	count := 0
+	for _, finding := range f {
+		if finding.Severity == SeverityWarning {
+			count++
+		}
+	}
+	return count
+}
+
+// the line reported in yaml parsing errors, `yaml: line 5: ...`
+var errorLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// FromError returns the finding for an error that stopped a validator, if the error reports the line it was found at then
+// the line is used
+func FromError(rule, file string, err error) Finding {
+	finding := Finding{
+		Rule:     rule,
+		Severity: SeverityError,
+		File:     file,
+		Message:  err.Error(),
+	}
+	if match := errorLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		finding.Line, _ = strconv.Atoi(match[1])
+	}
+	return finding
+}
+
+// Write renders the findings in the format, the rules and the version of the tool are only used by the sarif format
+func Write(w io.Writer, format string, findings Findings, rules []Rule, toolVersion string) error {
+	switch format {
+	case FormatText:
+		return WriteText(w, findings)
+	case FormatJSON:
+		return WriteJSON(w, findings)
+	case FormatSARIF:
+		return WriteSARIF(w, findings, rules, toolVersion)
+	}
+	return fmt.Errorf("unsupported format %s, must be text, json or sarif", format)
+}
+
+// WriteText writes each finding on its own line
+func WriteText(w io.Writer, findings Findings) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", f.Severity, f.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type jsonResult struct {
+	Valid    bool     `json:"valid"`
+	Errors   int      `json:"errors"`
+	Warnings int      `json:"warnings"`
+	Findings Findings `json:"findings"`
+}
+
+// WriteJSON writes the findings as a json document
+func WriteJSON(w io.Writer, findings Findings) error {
+	if findings == nil {
+		findings = Findings{}
+	}
+	result, err := json.MarshalIndent(jsonResult{
+		Valid:    findings.Errors() == 0,
+		Errors:   findings.Errors(),
+		Warnings: findings.Warnings(),
+		Findings: findings,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't marshal findings: %v", err)
+	}
+	_, err = fmt.Fprintln(w, string(result))
+	return err
+}
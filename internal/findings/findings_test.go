@@ -0,0 +1,192 @@
+package findings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var testFindings = Findings{
+	{
+		Rule:     "schema",
+		Severity: SeverityWarning,
+		File:     ".lagoon.yml",
+		Path:     "environments.main.routes.0.nginx.0.\"example.com\".tls_acme",
+		Line:     7,
+		Column:   15,
+		Message:  "Additional property tls_acme is not allowed",
+	},
+	{
+		Rule:     "cronjob-schedule",
+		Severity: SeverityError,
+		Project:  "project-a",
+		File:     ".lagoon.yml",
+		Path:     "environments.main.cronjobs.0.schedule",
+		Message:  "cron definition 'M * * *' is invalid",
+	},
+	{
+		Rule:     "load",
+		Severity: SeverityError,
+		Message:  "couldn't read .lagoon.yml",
+	},
+}
+
+var testRules = []Rule{
+	{ID: "schema", Severity: SeverityError, Description: "the .lagoon.yml must match the schema"},
+	{ID: "cronjob-schedule", Severity: SeverityError, Description: "cronjob schedules must be valid"},
+}
+
+func TestWriteText(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteText(&out, testFindings); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := `warning: .lagoon.yml:7:15: environments.main.routes.0.nginx.0."example.com".tls_acme: Additional property tls_acme is not allowed [schema]
+error: .lagoon.yml: environments.main.cronjobs.0.schedule: cron definition 'M * * *' is invalid [cronjob-schedule]
+error: couldn't read .lagoon.yml [load]
+`
+	if out.String() != want {
+		t.Errorf("WriteText() = %v, want %v", out.String(), want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		findings Findings
+		want     jsonResult
+	}{
+		{
+			name:     "test1 - findings",
+			findings: testFindings,
+			want: jsonResult{
+				Valid:    false,
+				Errors:   2,
+				Warnings: 1,
+				Findings: testFindings,
+			},
+		},
+		{
+			name:     "test2 - no findings",
+			findings: nil,
+			want: jsonResult{
+				Valid:    true,
+				Findings: Findings{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := WriteJSON(&out, tt.findings); err != nil {
+				t.Fatalf("WriteJSON() error = %v", err)
+			}
+			got := jsonResult{}
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("couldn't unmarshal output: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WriteJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteSARIF(&out, testFindings, testRules, "1.2.3"); err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+	got := sarifLog{}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("couldn't unmarshal output: %v", err)
+	}
+	if got.Version != SARIFVersion || got.Schema != SARIFSchema {
+		t.Errorf("WriteSARIF() version = %v %v, want %v %v", got.Version, got.Schema, SARIFVersion, SARIFSchema)
+	}
+	if len(got.Runs) != 1 {
+		t.Fatalf("WriteSARIF() runs = %v, want 1", len(got.Runs))
+	}
+	run := got.Runs[0]
+	if run.Tool.Driver.Version != "1.2.3" || len(run.Tool.Driver.Rules) != len(testRules) {
+		t.Errorf("WriteSARIF() driver = %v", run.Tool.Driver)
+	}
+	zero, one := 0, 1
+	want := []sarifResult{
+		{
+			RuleID:    "schema",
+			RuleIndex: &zero,
+			Level:     "warning",
+			Message:   sarifMessage{Text: "Additional property tls_acme is not allowed"},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: &sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: ".lagoon.yml"},
+						Region:           &sarifRegion{StartLine: 7, StartColumn: 15},
+					},
+					LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "environments.main.routes.0.nginx.0.\"example.com\".tls_acme"}},
+				},
+			},
+		},
+		{
+			RuleID:    "cronjob-schedule",
+			RuleIndex: &one,
+			Level:     "error",
+			Message:   sarifMessage{Text: "cron definition 'M * * *' is invalid"},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: &sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: ".lagoon.yml"},
+					},
+					LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: "environments.main.cronjobs.0.schedule"}},
+				},
+			},
+			Properties: map[string]string{"project": "project-a"},
+		},
+		{
+			RuleID:  "load",
+			Level:   "error",
+			Message: sarifMessage{Text: "couldn't read .lagoon.yml"},
+		},
+	}
+	if !reflect.DeepEqual(run.Results, want) {
+		gotJSON, _ := json.Marshal(run.Results)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("WriteSARIF() results = %v, want %v", string(gotJSON), string(wantJSON))
+	}
+}
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Finding
+	}{
+		{
+			name: "test1 - yaml error with a line",
+			err:  fmt.Errorf("yaml: line 5: did not find expected key"),
+			want: Finding{Rule: "load", Severity: SeverityError, File: "docker-compose.yml", Line: 5, Message: "yaml: line 5: did not find expected key"},
+		},
+		{
+			name: "test2 - error without a line",
+			err:  fmt.Errorf("couldn't read docker-compose.yml"),
+			want: Finding{Rule: "load", Severity: SeverityError, File: "docker-compose.yml", Message: "couldn't read docker-compose.yml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromError("load", "docker-compose.yml", tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, "xml", testFindings, testRules, ""); err == nil {
+		t.Errorf("Write() error = nil, want an unsupported format error")
+	}
+}
@@ -0,0 +1,161 @@
+package findings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// the SARIF version and schema that WriteSARIF writes
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// the name and homepage of the tool in the SARIF log
+const (
+	sarifToolName = "build-deploy-tool"
+	sarifToolURI  = "https://github.com/uselagoon/build-deploy-tool"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  *int              `json:"ruleIndex,omitempty"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// sarifLevel converts a severity into a SARIF level
+func sarifLevel(severity string) string {
+	if severity == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log with a single run. Every rule is described in the log, so the rules
+// should include every rule that the findings can reference.
+func WriteSARIF(w io.Writer, findings Findings, rules []Rule, toolVersion string) error {
+	driver := sarifDriver{
+		Name:           sarifToolName,
+		Version:        toolVersion,
+		InformationURI: sarifToolURI,
+		Rules:          []sarifRule{},
+	}
+	ruleIndex := map[string]int{}
+	for idx, rule := range rules {
+		ruleIndex[rule.ID] = idx
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+	results := []sarifResult{}
+	for _, f := range findings {
+		result := sarifResult{
+			RuleID:  f.Rule,
+			Level:   sarifLevel(f.Severity),
+			Message: sarifMessage{Text: f.Message},
+		}
+		if idx, ok := ruleIndex[f.Rule]; ok {
+			result.RuleIndex = &idx
+		}
+		location := sarifLocation{}
+		if f.File != "" {
+			location.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.File},
+			}
+			// sarif lines start at 1, a finding without a line is reported against the whole file
+			if f.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+			}
+		}
+		if f.Path != "" {
+			location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: f.Path}}
+		}
+		if location.PhysicalLocation != nil || location.LogicalLocations != nil {
+			result.Locations = []sarifLocation{location}
+		}
+		if f.Project != "" {
+			result.Properties = map[string]string{"project": f.Project}
+		}
+		results = append(results, result)
+	}
+	log, err := json.MarshalIndent(sarifLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs: []sarifRun{
+			{
+				Tool:    sarifTool{Driver: driver},
+				Results: results,
+			},
+		},
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't marshal sarif log: %v", err)
+	}
+	_, err = fmt.Fprintln(w, string(log))
+	return err
+}
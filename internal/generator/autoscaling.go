@@ -0,0 +1,125 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// these are the service types that can be scaled by a horizontal pod autoscaler, they don't keep any state in the pod
+var autoscalingTypes = []string{
+	"nginx-php",
+	"node",
+	"python",
+}
+
+// the autoscaling defaults if the service doesn't set them
+const (
+	defaultAutoscalingMinReplicas          = 2
+	defaultAutoscalingTargetCPUUtilization = 80
+)
+
+// serviceAutoscaling returns the autoscaling of a service from the `lagoon.autoscaling.*` labels and the overrides for the
+// service in the environment of the .lagoon.yml, or nil if the service doesn't autoscale. Only production environments autoscale.
+func serviceAutoscaling(
+	buildValues *BuildValues,
+	service, lagoonType string,
+	labels map[string]string,
+	override lagoon.Override,
+	resources *lagoon.ResourceOverrides,
+	debug bool,
+) (*ServiceAutoscaling, error) {
+	labelAutoscaling, err := lagoon.AutoscalingFromLabels(labels)
+	if err != nil {
+		return nil, fmt.Errorf("service %s: %v", service, err)
+	}
+	autoscaling := lagoon.MergeAutoscaling(labelAutoscaling, override.Autoscaling)
+	if autoscaling == nil {
+		return nil, nil
+	}
+	if buildValues.EnvironmentType != "production" {
+		if debug {
+			fmt.Printf("Autoscaling of service %s is only used in production environments\n", service)
+		}
+		return nil, nil
+	}
+	if !helpers.Contains(autoscalingTypes, lagoonType) {
+		return nil, fmt.Errorf("service %s: autoscaling is only supported for the service types %s", service, strings.Join(autoscalingTypes, ", "))
+	}
+	sa := &ServiceAutoscaling{
+		MinReplicas: defaultAutoscalingMinReplicas,
+	}
+	if autoscaling.MinReplicas != nil {
+		sa.MinReplicas = *autoscaling.MinReplicas
+	}
+	if autoscaling.MaxReplicas == nil {
+		return nil, fmt.Errorf("service %s: autoscaling needs maxReplicas", service)
+	}
+	sa.MaxReplicas = *autoscaling.MaxReplicas
+	if sa.MinReplicas < 1 {
+		return nil, fmt.Errorf("service %s: autoscaling minReplicas must be at least 1", service)
+	}
+	if sa.MaxReplicas < sa.MinReplicas {
+		return nil, fmt.Errorf("service %s: autoscaling maxReplicas %d is less than minReplicas %d", service, sa.MaxReplicas, sa.MinReplicas)
+	}
+	if autoscaling.TargetCPUUtilization != nil {
+		sa.TargetCPUUtilization = *autoscaling.TargetCPUUtilization
+	}
+	if autoscaling.TargetMemoryUtilization != nil {
+		sa.TargetMemoryUtilization = *autoscaling.TargetMemoryUtilization
+	}
+	if sa.TargetCPUUtilization == 0 && sa.TargetMemoryUtilization == 0 {
+		sa.TargetCPUUtilization = defaultAutoscalingTargetCPUUtilization
+	}
+	if sa.TargetCPUUtilization < 0 || sa.TargetMemoryUtilization < 0 {
+		return nil, fmt.Errorf("service %s: autoscaling utilization targets must be greater than zero", service)
+	}
+	// the default requests of the service types are tiny, a utilization target measured against them would always be exceeded
+	var requests lagoon.ResourceList
+	if resources != nil && resources.Requests != nil {
+		requests = *resources.Requests
+	}
+	if sa.TargetCPUUtilization > 0 && requests.CPU == "" {
+		return nil, fmt.Errorf("service %s: autoscaling on cpu utilization needs a cpu request, set lagoon.resources.requests.cpu", service)
+	}
+	if sa.TargetMemoryUtilization > 0 && requests.Memory == "" {
+		return nil, fmt.Errorf("service %s: autoscaling on memory utilization needs a memory request, set lagoon.resources.requests.memory", service)
+	}
+	return sa, nil
+}
+
+// checkLinkedAutoscaling checks the autoscaling of the services that share a deployment, like the nginx and php services of
+// nginx-php. Only one of them can set the autoscaling, and as the utilization of a pod is measured against the requests of
+// all of its containers, the other service needs the requests too.
+func checkLinkedAutoscaling(services []ServiceValues) error {
+	for idx, service := range services {
+		for _, linked := range services[idx+1:] {
+			if linked.OverrideName != service.OverrideName {
+				continue
+			}
+			if service.Autoscaling != nil && linked.Autoscaling != nil {
+				return fmt.Errorf("services %s and %s share a deployment, only one of them can set autoscaling", service.Name, linked.Name)
+			}
+			autoscaling, declaring, other := service.Autoscaling, service.Name, linked
+			if autoscaling == nil {
+				autoscaling, declaring, other = linked.Autoscaling, linked.Name, service
+			}
+			if autoscaling == nil {
+				continue
+			}
+			var requests lagoon.ResourceList
+			if other.Resources != nil && other.Resources.Requests != nil {
+				requests = *other.Resources.Requests
+			}
+			if autoscaling.TargetCPUUtilization > 0 && requests.CPU == "" {
+				return fmt.Errorf("service %s: autoscaling on cpu utilization needs a cpu request on the linked service %s too, set lagoon.resources.requests.cpu", declaring, other.Name)
+			}
+			if autoscaling.TargetMemoryUtilization > 0 && requests.Memory == "" {
+				return fmt.Errorf("service %s: autoscaling on memory utilization needs a memory request on the linked service %s too, set lagoon.resources.requests.memory", declaring, other.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_serviceAutoscaling(t *testing.T) {
+	cpuRequest := &lagoon.ResourceOverrides{
+		Requests: &lagoon.ResourceList{CPU: "100m"},
+	}
+	tests := []struct {
+		name            string
+		environmentType string
+		lagoonType      string
+		labels          map[string]string
+		override        lagoon.Override
+		resources       *lagoon.ResourceOverrides
+		want            *ServiceAutoscaling
+		wantErr         string
+	}{
+		{
+			name:            "test1 - labels with the defaults",
+			environmentType: "production",
+			lagoonType:      "node",
+			labels: map[string]string{
+				"lagoon.autoscaling.maxReplicas": "5",
+			},
+			resources: cpuRequest,
+			want: &ServiceAutoscaling{
+				MinReplicas:          2,
+				MaxReplicas:          5,
+				TargetCPUUtilization: 80,
+			},
+		},
+		{
+			name:            "test2 - lagoon.yml overrides the labels",
+			environmentType: "production",
+			lagoonType:      "python",
+			labels: map[string]string{
+				"lagoon.autoscaling.maxReplicas":          "5",
+				"lagoon.autoscaling.targetCPUUtilization": "60",
+			},
+			override: lagoon.Override{
+				Autoscaling: &lagoon.Autoscaling{
+					MinReplicas: helpers.Int32Ptr(3),
+					MaxReplicas: helpers.Int32Ptr(8),
+				},
+			},
+			resources: cpuRequest,
+			want: &ServiceAutoscaling{
+				MinReplicas:          3,
+				MaxReplicas:          8,
+				TargetCPUUtilization: 60,
+			},
+		},
+		{
+			name:            "test3 - development environments don't autoscale",
+			environmentType: "development",
+			lagoonType:      "node",
+			labels: map[string]string{
+				"lagoon.autoscaling.maxReplicas": "5",
+			},
+			resources: cpuRequest,
+			want:      nil,
+		},
+		{
+			name:            "test4 - unsupported service type",
+			environmentType: "production",
+			lagoonType:      "redis",
+			labels: map[string]string{
+				"lagoon.autoscaling.maxReplicas": "5",
+			},
+			resources: cpuRequest,
+			wantErr:   "service myservice: autoscaling is only supported for the service types nginx-php, node, python",
+		},
+		{
+			name:            "test5 - no max replicas",
+			environmentType: "production",
+			lagoonType:      "node",
+			labels: map[string]string{
+				"lagoon.autoscaling.minReplicas": "2",
+			},
+			resources: cpuRequest,
+			wantErr:   "service myservice: autoscaling needs maxReplicas",
+		},
+		{
+			name:            "test6 - max replicas less than min replicas",
+			environmentType: "production",
+			lagoonType:      "node",
+			labels: map[string]string{
+				"lagoon.autoscaling.minReplicas": "4",
+				"lagoon.autoscaling.maxReplicas": "3",
+			},
+			resources: cpuRequest,
+			wantErr:   "service myservice: autoscaling maxReplicas 3 is less than minReplicas 4",
+		},
+		{
+			name:            "test7 - memory target without a memory request",
+			environmentType: "production",
+			lagoonType:      "node",
+			labels: map[string]string{
+				"lagoon.autoscaling.maxReplicas":             "3",
+				"lagoon.autoscaling.targetMemoryUtilization": "80",
+			},
+			resources: cpuRequest,
+			wantErr:   "service myservice: autoscaling on memory utilization needs a memory request, set lagoon.resources.requests.memory",
+		},
+		{
+			name:            "test8 - cpu target without a cpu request",
+			environmentType: "production",
+			lagoonType:      "node",
+			labels: map[string]string{
+				"lagoon.autoscaling.maxReplicas": "3",
+			},
+			wantErr: "service myservice: autoscaling on cpu utilization needs a cpu request, set lagoon.resources.requests.cpu",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buildValues := &BuildValues{
+				EnvironmentType: tt.environmentType,
+			}
+			got, err := serviceAutoscaling(buildValues, "myservice", tt.lagoonType, tt.labels, tt.override, tt.resources, false)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("serviceAutoscaling() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Errorf("serviceAutoscaling() error = nil, wantErr %v", tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("serviceAutoscaling() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkLinkedAutoscaling(t *testing.T) {
+	autoscaling := &ServiceAutoscaling{MinReplicas: 2, MaxReplicas: 5, TargetCPUUtilization: 80}
+	requests := &lagoon.ResourceOverrides{
+		Requests: &lagoon.ResourceList{CPU: "100m", Memory: "256Mi"},
+	}
+	tests := []struct {
+		name     string
+		services []ServiceValues
+		wantErr  string
+	}{
+		{
+			name: "test1 - linked service with requests",
+			services: []ServiceValues{
+				{Name: "nginx", OverrideName: "nginx", Autoscaling: autoscaling, Resources: requests},
+				{Name: "php", OverrideName: "nginx", Resources: requests},
+			},
+		},
+		{
+			name: "test2 - linked service without requests",
+			services: []ServiceValues{
+				{Name: "nginx", OverrideName: "nginx", Autoscaling: autoscaling, Resources: requests},
+				{Name: "php", OverrideName: "nginx"},
+			},
+			wantErr: "service nginx: autoscaling on cpu utilization needs a cpu request on the linked service php too, set lagoon.resources.requests.cpu",
+		},
+		{
+			name: "test3 - autoscaling set on the second linked service",
+			services: []ServiceValues{
+				{Name: "nginx", OverrideName: "nginx", Resources: &lagoon.ResourceOverrides{Requests: &lagoon.ResourceList{CPU: "100m"}}},
+				{Name: "php", OverrideName: "nginx", Autoscaling: &ServiceAutoscaling{MinReplicas: 2, MaxReplicas: 5, TargetMemoryUtilization: 80}, Resources: requests},
+			},
+			wantErr: "service php: autoscaling on memory utilization needs a memory request on the linked service nginx too, set lagoon.resources.requests.memory",
+		},
+		{
+			name: "test4 - both linked services set autoscaling",
+			services: []ServiceValues{
+				{Name: "nginx", OverrideName: "nginx", Autoscaling: autoscaling, Resources: requests},
+				{Name: "php", OverrideName: "nginx", Autoscaling: autoscaling, Resources: requests},
+			},
+			wantErr: "services nginx and php share a deployment, only one of them can set autoscaling",
+		},
+		{
+			name: "test5 - services that aren't linked",
+			services: []ServiceValues{
+				{Name: "node", OverrideName: "node", Autoscaling: autoscaling, Resources: requests},
+				{Name: "cli", OverrideName: "cli"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLinkedAutoscaling(tt.services)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("checkLinkedAutoscaling() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Errorf("checkLinkedAutoscaling() error = nil, wantErr %v", tt.wantErr)
+			}
+		})
+	}
+}
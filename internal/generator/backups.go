@@ -30,7 +30,7 @@ func generateBackupValues(
 	// generator
 	newBackupSchedule := buildValues.DefaultBackupSchedule
 
-	customBackupConfig := CheckFeatureFlag("CUSTOM_BACKUP_CONFIG", mergedVariables, debug)
+	customBackupConfig := buildValues.checkFeatureFlag("CUSTOM_BACKUP_CONFIG", mergedVariables, debug)
 	if customBackupConfig == "enabled" {
 		switch buildValues.BuildType {
 		case "promote":
@@ -99,7 +99,7 @@ func generateBackupValues(
 
 	// start: get variables from the build pod that may have been added by the controller
 	flagCheckSchedule := helpers.GetEnv("K8UP_WEEKLY_RANDOM_FEATURE_FLAG", defaultCheckSchedule, debug)
-	lffCheckSchedule := CheckFeatureFlag("K8UP_WEEKLY_RANDOM_CHECK", mergedVariables, debug)
+	lffCheckSchedule := buildValues.checkFeatureFlag("K8UP_WEEKLY_RANDOM_CHECK", mergedVariables, debug)
 	if flagCheckSchedule == "enabled" || lffCheckSchedule == "enabled" {
 		buildValues.Backup.CheckSchedule = "@weekly-random"
 	} else {
@@ -109,7 +109,7 @@ func generateBackupValues(
 		}
 	}
 	flagPruneSchedule := helpers.GetEnv("K8UP_WEEKLY_RANDOM_FEATURE_FLAG", defaultPruneSchedule, debug)
-	lffPruneSchedule := CheckFeatureFlag("K8UP_WEEKLY_RANDOM_PRUNE", mergedVariables, debug)
+	lffPruneSchedule := buildValues.checkFeatureFlag("K8UP_WEEKLY_RANDOM_PRUNE", mergedVariables, debug)
 	if flagPruneSchedule == "enabled" || lffPruneSchedule == "enabled" {
 		buildValues.Backup.PruneSchedule = "@weekly-random"
 	} else {
@@ -4,7 +4,9 @@ import (
 	composetypes "github.com/compose-spec/compose-go/types"
 	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/servicetypes"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
@@ -13,81 +15,94 @@ const (
 
 // BuildValues is the values file data generated by the lagoon build
 type BuildValues struct {
-	SourceRepository              string                       `json:"sourceRepository" description:"the source repository for the project"`
-	BuildName                     string                       `json:"buildName" description:"the name of the build"`
-	Project                       string                       `json:"project" description:"the name of the project"`
-	Environment                   string                       `json:"environment" description:"the name of the environment, this is the safe version and may differ from the branch name"`
-	EnvironmentType               string                       `json:"environmentType" description:"the type of the environment, production or development"`
-	Namespace                     string                       `json:"namespace" description:"the kubernetes namespace that this environment is built in"`
-	GitSHA                        string                       `json:"gitSha" description:"the git sha of this particular build"`
-	BuildType                     string                       `json:"buildType" description:"the type of build this is, branch, pullrequest, or promote"`
-	Kubernetes                    string                       `json:"kubernetes" description:"the name of the cluster that this hosts this environment"`
-	LagoonVersion                 string                       `json:"lagoonVersion" description:"the version of lagoon that started this build"`
-	ActiveEnvironment             string                       `json:"activeEnvironment" activestandby:"true" description:"the current active environment"`
-	StandbyEnvironment            string                       `json:"standbyEnvironment" activestandby:"true" description:"the current standby environment"`
-	IsActiveEnvironment           bool                         `json:"isActiveEnvironment" activestandby:"true" description:"flag to determine if this environment is currently an active environment"`
-	IsStandbyEnvironment          bool                         `json:"isStandbyEnvironment" activestandby:"true" description:"flag to determine if this environment is currently a standby environment"`
-	PodSecurityContext            PodSecurityContext           `json:"podSecurityContext" description:"stores the podsecuritycontext overrides"`
-	Branch                        string                       `json:"branch" buildtype:"branch" description:"the branch used for this environment"`
-	PRNumber                      string                       `json:"prNumber" buildtype:"pullrequest" description:"pullrequest number"`
-	PRTitle                       string                       `json:"prTitle" buildtype:"pullrequest" description:"title of the pullrequest"`
-	PRHeadBranch                  string                       `json:"prHeadBranch" buildtype:"pullrequest" description:"head branch of the pullrequest"`
-	PRBaseBranch                  string                       `json:"prBaseBranch" buildtype:"pullrequest" description:"base branch of the pullrequest"`
-	PRHeadSHA                     string                       `json:"prHeadSHA" buildtype:"pullrequest" description:"head sha of the pullrequest"`
-	PRBaseSHA                     string                       `json:"prBaseSHA" buildtype:"pullrequest" description:"base sha of the pullrequest"`
-	PrivateRegistryURLS           []string                     `json:"privateRegistryURLS" description:"this stores all the private registry urls used by this environment"`
-	Fastly                        Fastly                       `json:"fastly" deprecated:"true" description:"this is the configuration of fastly for this environment"`
-	FastlyCacheNoCache            string                       `json:"fastlyCacheNoCahce" deprecated:"true" description:"this is the service id of a fastly cache-no-cache service"`
-	FastlyAPISecretPrefix         string                       `json:"fastlyAPISecretPrefix" deprecated:"true" description:"this is the fastly-api-secret prefix to use"`
-	ConfigMapSha                  string                       `json:"configMapSha" description:"this is the computed sha of the lagoon-env configmap, it is used to determine if changes are required to deployments"`
-	Route                         string                       `json:"route" description:"this stores the primary determiend route after all have been calculated"`
-	Routes                        []string                     `json:"routes" description:"this stores all routes after they are calculated"`
-	AutogeneratedRoutes           []string                     `json:"autogeneratedRoutes" description:"this stores autogenerated routes after they are calculated"`
-	AutogeneratedRoutesFastly     bool                         `json:"autogeneratedRoutesFastly" deprecated:"true" description:"the flag to determine if autogenerated routes should receive fastly annotations"`
-	Services                      []ServiceValues              `json:"services" description:"stores all the computed values for all docker-compose services for this environment"`
-	Backup                        BackupConfiguration          `json:"backup" description:"stores backup configuration"`
-	Monitoring                    MonitoringConfig             `json:"monitoring" deprecated:"true" description:"stores monitoring configuration"`
-	DBaaSOperatorEndpoint         string                       `json:"dbaasOperatorEndpoint" description:"the dbaas operator to use for provisioning a consumer"`
-	ServiceTypeOverrides          *lagoon.EnvironmentVariable  `json:"serviceTypeOverrides" description:"stores any service type overrides"`
-	DBaaSEnvironmentTypeOverrides *lagoon.EnvironmentVariable  `json:"dbaasEnvironmentTypeOverrides" description:"stores any dbaas type overrides"`
-	DBaaSFallbackSingle           bool                         `json:"dbaasFallbackSingle" description:"the fallback flag to define if a single pod should be used if no provider is found"`
-	IngressClass                  string                       `json:"ingressClass" description:"the ingress class used for this environment"`
-	TaskScaleMaxIterations        int                          `json:"taskScaleMaxIterations" description:"the number of attempts to wait for pods to scale for pre and post rollout tasks"`
-	TaskScaleWaitTime             int                          `json:"taskScaleWaitTime" description:"the time to wait for pods to scale for pre and post rollout tasks"`
-	DynamicSecretMounts           []DynamicSecretMounts        `json:"dynamicSecretMounts" description:"stores any dynamic secret mount definitions"`
-	DynamicSecretVolumes          []DynamicSecretVolumes       `json:"dynamicSecretVolumes" description:"stores any dynamic secret volume definitions"`
-	DynamicDBaaSSecrets           []string                     `json:"dynamicDBaaSSecrets" description:"stores any dynamic dbaas secret definitions"`
-	ImageCache                    string                       `json:"imageCache" description:"if an imagecache has been provided for images outside of the imageregistry"`
-	DefaultBackupSchedule         string                       `json:"defaultBackupSchedule" description:"the default backup scheduled"`
-	DBaaSClient                   *dbaasclient.Client          `json:"-" description:"used to store connection information for the dbaas operator endpoint"`
-	ImageReferences               map[string]string            `json:"imageReferences" description:"the post image build phase storage location of images for this build"`
-	Resources                     Resources                    `json:"resources" description:"this stores resource overrides for this environment"`
-	CronjobsDisabled              bool                         `json:"cronjobsDisabled" description:"this controls whether cronjobs are enabled for this environment or not"`
-	FeatureFlags                  map[string]bool              `json:"-" description:"these are used by templating systems to turn on or off certain functionality based on if feature flags are defined"`
-	ImageRegistry                 string                       `json:"imageRegistry" description:"the image registry in use for this environment, usually harbor"`
-	DockerBuildKit                *bool                        `json:"dockerBuildKit" description:"the flag to determine if docker buildkit is used"`
-	ImageBuildArguments           map[string]string            `json:"imageBuildArguments" description:"where the calculated image build arguments are stored"`
-	EnvironmentVariables          []lagoon.EnvironmentVariable `json:"environmentVariables" description:"the merged project and environment variables for this environment"`
-	LagoonYAML                    lagoon.YAML                  `json:"lagoonYAML" description:"the unmarshalled lagoon yaml file"`
-	PromotionSourceEnvironment    string                       `json:"promotionSourceEnvironment" buildtype:"promote" description:"the promotion source environment to pull images from"`
-	IsCI                          bool                         `json:"isCI" description:"this controls aspects of the environment or build depending on if a CI job"`
-	RWX2RWO                       bool                         `json:"RWX2RWO" description:"this controls whether the ReadWriteMany to ReadWriteOnce override should be used"`
-	IsolationNetworkPolicy        bool                         `json:"isolationNetworkPolicy" description:"this controls whether isolation network policies should be enabled"`
-	ContainerRegistry             []ContainerRegistry          `json:"containerRegistry" description:"this contains any private container registries that may exist within the environment that need to be logged into"`
-	RoutesAutogeneratePrefixes    []string                     `json:"routesAutogeneratePrefixes"`
-	BackupsEnabled                bool                         `json:"backupsEnabled"`
-	RouteQuota                    *int                         `json:"routeQuota"`
-	ImageCacheBuildArguments      []ImageCacheBuildArguments   `json:"imageCacheBuildArgs"`
-	IgnoreImageCache              bool                         `json:"ignoreImageCache"`
-	SSHPrivateKey                 string                       `json:"sshPrivateKey"`
-	ForcePullImages               []string                     `json:"forcePullImages"`
-	Volumes                       []ComposeVolume              `json:"volumes,omitempty" description:"stores any additional persistent volume definitions"`
-	PodAntiAffinity               bool                         `json:"podAntiAffinity"`
+	SourceRepository              string                              `json:"sourceRepository" description:"the source repository for the project"`
+	BuildName                     string                              `json:"buildName" description:"the name of the build"`
+	Project                       string                              `json:"project" description:"the name of the project"`
+	Environment                   string                              `json:"environment" description:"the name of the environment, this is the safe version and may differ from the branch name"`
+	EnvironmentType               string                              `json:"environmentType" description:"the type of the environment, production or development"`
+	Namespace                     string                              `json:"namespace" description:"the kubernetes namespace that this environment is built in"`
+	GitSHA                        string                              `json:"gitSha" description:"the git sha of this particular build"`
+	BuildType                     string                              `json:"buildType" description:"the type of build this is, branch, pullrequest, or promote"`
+	Kubernetes                    string                              `json:"kubernetes" description:"the name of the cluster that this hosts this environment"`
+	LagoonVersion                 string                              `json:"lagoonVersion" description:"the version of lagoon that started this build"`
+	ActiveEnvironment             string                              `json:"activeEnvironment" activestandby:"true" description:"the current active environment"`
+	StandbyEnvironment            string                              `json:"standbyEnvironment" activestandby:"true" description:"the current standby environment"`
+	IsActiveEnvironment           bool                                `json:"isActiveEnvironment" activestandby:"true" description:"flag to determine if this environment is currently an active environment"`
+	IsStandbyEnvironment          bool                                `json:"isStandbyEnvironment" activestandby:"true" description:"flag to determine if this environment is currently a standby environment"`
+	PodSecurityContext            PodSecurityContext                  `json:"podSecurityContext" description:"stores the podsecuritycontext overrides"`
+	Branch                        string                              `json:"branch" buildtype:"branch" description:"the branch used for this environment"`
+	PRNumber                      string                              `json:"prNumber" buildtype:"pullrequest" description:"pullrequest number"`
+	PRTitle                       string                              `json:"prTitle" buildtype:"pullrequest" description:"title of the pullrequest"`
+	PRHeadBranch                  string                              `json:"prHeadBranch" buildtype:"pullrequest" description:"head branch of the pullrequest"`
+	PRBaseBranch                  string                              `json:"prBaseBranch" buildtype:"pullrequest" description:"base branch of the pullrequest"`
+	PRHeadSHA                     string                              `json:"prHeadSHA" buildtype:"pullrequest" description:"head sha of the pullrequest"`
+	PRBaseSHA                     string                              `json:"prBaseSHA" buildtype:"pullrequest" description:"base sha of the pullrequest"`
+	PrivateRegistryURLS           []string                            `json:"privateRegistryURLS" description:"this stores all the private registry urls used by this environment"`
+	Fastly                        Fastly                              `json:"fastly" deprecated:"true" description:"this is the configuration of fastly for this environment"`
+	FastlyCacheNoCache            string                              `json:"fastlyCacheNoCahce" deprecated:"true" description:"this is the service id of a fastly cache-no-cache service"`
+	FastlyAPISecretPrefix         string                              `json:"fastlyAPISecretPrefix" deprecated:"true" description:"this is the fastly-api-secret prefix to use"`
+	ConfigMapSha                  string                              `json:"configMapSha" description:"this is the computed sha of the lagoon-env configmap, it is used to determine if changes are required to deployments"`
+	Route                         string                              `json:"route" description:"this stores the primary determiend route after all have been calculated"`
+	Routes                        []string                            `json:"routes" description:"this stores all routes after they are calculated"`
+	AutogeneratedRoutes           []string                            `json:"autogeneratedRoutes" description:"this stores autogenerated routes after they are calculated"`
+	AutogeneratedRoutesFastly     bool                                `json:"autogeneratedRoutesFastly" deprecated:"true" description:"the flag to determine if autogenerated routes should receive fastly annotations"`
+	Services                      []ServiceValues                     `json:"services" description:"stores all the computed values for all docker-compose services for this environment"`
+	Backup                        BackupConfiguration                 `json:"backup" description:"stores backup configuration"`
+	Monitoring                    MonitoringConfig                    `json:"monitoring" deprecated:"true" description:"stores monitoring configuration"`
+	DBaaSOperatorEndpoint         string                              `json:"dbaasOperatorEndpoint" description:"the dbaas operator to use for provisioning a consumer"`
+	ServiceTypeOverrides          *lagoon.EnvironmentVariable         `json:"serviceTypeOverrides" description:"stores any service type overrides"`
+	DBaaSEnvironmentTypeOverrides *lagoon.EnvironmentVariable         `json:"dbaasEnvironmentTypeOverrides" description:"stores any dbaas type overrides"`
+	DBaaSFallbackSingle           bool                                `json:"dbaasFallbackSingle" description:"the fallback flag to define if a single pod should be used if no provider is found"`
+	IngressClass                  string                              `json:"ingressClass" description:"the ingress class used for this environment"`
+	TaskScaleMaxIterations        int                                 `json:"taskScaleMaxIterations" description:"the number of attempts to wait for pods to scale for pre and post rollout tasks"`
+	TaskScaleWaitTime             int                                 `json:"taskScaleWaitTime" description:"the time to wait for pods to scale for pre and post rollout tasks"`
+	DynamicSecretMounts           []DynamicSecretMounts               `json:"dynamicSecretMounts" description:"stores any dynamic secret mount definitions"`
+	DynamicSecretVolumes          []DynamicSecretVolumes              `json:"dynamicSecretVolumes" description:"stores any dynamic secret volume definitions"`
+	DynamicDBaaSSecrets           []string                            `json:"dynamicDBaaSSecrets" description:"stores any dynamic dbaas secret definitions"`
+	ImageCache                    string                              `json:"imageCache" description:"if an imagecache has been provided for images outside of the imageregistry"`
+	ContainerImageAllowlist       []string                            `json:"containerImageAllowlist,omitempty" description:"the image patterns that the additional containers of a service can use"`
+	DefaultBackupSchedule         string                              `json:"defaultBackupSchedule" description:"the default backup scheduled"`
+	DBaaSClient                   *dbaasclient.Client                 `json:"-" description:"used to store connection information for the dbaas operator endpoint"`
+	ImageReferences               map[string]string                   `json:"imageReferences" description:"the post image build phase storage location of images for this build"`
+	Resources                     Resources                           `json:"resources" description:"this stores resource overrides for this environment"`
+	CronjobsDisabled              bool                                `json:"cronjobsDisabled" description:"this controls whether cronjobs are enabled for this environment or not"`
+	FeatureFlags                  map[string]bool                     `json:"-" description:"these are used by templating systems to turn on or off certain functionality based on if feature flags are defined"`
+	FeatureFlagResults            []FeatureFlagResult                 `json:"-" description:"the feature flags consulted by the generator and the tier that each was resolved from"`
+	LagoonYAMLWarnings            lagoon.Warnings                     `json:"-" description:"the warnings found while unmarshalling the .lagoon.yml files"`
+	ServiceTypeDefinitions        map[string]servicetypes.ServiceType `json:"-" description:"the service types loaded from the cluster provided service type definitions, these are added to the built-in service types"`
+	ImageRegistry                 string                              `json:"imageRegistry" description:"the image registry in use for this environment, usually harbor"`
+	DockerBuildKit                *bool                               `json:"dockerBuildKit" description:"the flag to determine if docker buildkit is used"`
+	ImageBuildArguments           map[string]string                   `json:"imageBuildArguments" description:"where the calculated image build arguments are stored"`
+	EnvironmentVariables          []lagoon.EnvironmentVariable        `json:"environmentVariables" description:"the merged project and environment variables for this environment"`
+	LagoonYAML                    lagoon.YAML                         `json:"lagoonYAML" description:"the unmarshalled lagoon yaml file"`
+	PromotionSourceEnvironment    string                              `json:"promotionSourceEnvironment" buildtype:"promote" description:"the promotion source environment to pull images from"`
+	IsCI                          bool                                `json:"isCI" description:"this controls aspects of the environment or build depending on if a CI job"`
+	RWX2RWO                       bool                                `json:"RWX2RWO" description:"this controls whether the ReadWriteMany to ReadWriteOnce override should be used"`
+	IsolationNetworkPolicy        bool                                `json:"isolationNetworkPolicy" description:"this controls whether isolation network policies should be enabled"`
+	ContainerRegistry             []ContainerRegistry                 `json:"containerRegistry" description:"this contains any private container registries that may exist within the environment that need to be logged into"`
+	RoutesAutogeneratePrefixes    []string                            `json:"routesAutogeneratePrefixes"`
+	BackupsEnabled                bool                                `json:"backupsEnabled"`
+	RouteQuota                    *int                                `json:"routeQuota"`
+	ImageCacheBuildArguments      []ImageCacheBuildArguments          `json:"imageCacheBuildArgs"`
+	IgnoreImageCache              bool                                `json:"ignoreImageCache"`
+	SSHPrivateKey                 string                              `json:"sshPrivateKey"`
+	ForcePullImages               []string                            `json:"forcePullImages"`
+	Volumes                       []ComposeVolume                     `json:"volumes,omitempty" description:"stores any additional persistent volume definitions"`
+	PodAntiAffinity               bool                                `json:"podAntiAffinity"`
+	PodDisruptionBudget           PodDisruptionBudget                 `json:"podDisruptionBudget"`
+	CleanupRemovedRoutes          bool                                `json:"cleanupRemovedRoutes" description:"this controls whether routes that are no longer generated are deleted by prune"`
 }
 
 type Resources struct {
 	Limits   ResourceLimits   `json:"limits"`
 	Requests ResourceRequests `json:"requests"`
+	Ceilings ResourceCeilings `json:"ceilings"`
+}
+
+// ResourceCeilings are the largest requests or limits that a service can set with its resource overrides
+type ResourceCeilings struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
 }
 
 type ResourceLimits struct {
@@ -164,42 +179,62 @@ type ServiceVolume struct {
 
 // ServiceValues is the values for a specific service used by a lagoon build
 type ServiceValues struct {
-	Name                                   string                  `json:"name"`         // the actual compose service name
-	OverrideName                           string                  `json:"overrideName"` // if an override name is provided, use it
-	Type                                   string                  `json:"type"`
-	AutogeneratedRoutesEnabled             bool                    `json:"autogeneratedRoutesEnabled"`
-	AutogeneratedRoutesTLSAcme             bool                    `json:"autogeneratedRoutesTLSAcme"`
-	AutogeneratedRoutesRequestVerification bool                    `json:"autogeneratedRoutesRequestVerification"`
-	AutogeneratedRouteDomain               string                  `json:"autogeneratedRouteDomain"`
-	ShortAutogeneratedRouteDomain          string                  `json:"shortAutogeneratedRouteDomain"`
-	DBaaSEnvironment                       string                  `json:"dbaasEnvironment"`
-	NativeCronjobs                         []lagoon.Cronjob        `json:"nativeCronjobs"`
-	InPodCronjobs                          []lagoon.Cronjob        `json:"inPodCronjobs"`
-	DeploymentServiceType                  string                  `json:"deploymentServiceType"`
-	ServicePort                            int32                   `json:"servicePort,omitempty"`
-	PersistentVolumePath                   string                  `json:"persistentVolumePath,omitempty"`
-	PersistentVolumeName                   string                  `json:"persistentVolumeName,omitempty"`
-	PersistentVolumeSize                   string                  `json:"persistentVolumeSize,omitempty"`
-	UseSpotInstances                       bool                    `json:"useSpot"`
-	ForceSpotInstances                     bool                    `json:"forceUseSpot"`
-	CronjobUseSpotInstances                bool                    `json:"cronjobUseSpot"`
-	CronjobForceSpotInstances              bool                    `json:"cronjobForceUseSpot"`
-	Replicas                               int32                   `json:"replicas"`
-	LinkedService                          *ServiceValues          `json:"linkedService"`
-	PodSecurityContext                     PodSecurityContext      `json:"podSecurityContext"`
-	AdditionalServicePorts                 []AdditionalServicePort `json:"additionalServicePorts,omitempty"`
-	NodeSelectors                          *map[string]string      `json:"nodeSelectors"`
-	Tolerations                            *[]corev1.Toleration    `json:"tolerations"`
-	Affinity                               *corev1.Affinity        `json:"affinity"`
-	CronjobNodeSelectors                   *map[string]string      `json:"cronjobNodeSelectors"`
-	CronjobTolerations                     *[]corev1.Toleration    `json:"cronjobTolerations"`
-	CronjobAffinity                        *corev1.Affinity        `json:"cronjobAffinity"`
-	DBaasReadReplica                       bool                    `json:"dBaasReadReplica"`
-	ImageBuild                             *ImageBuild             `json:"docker,omitempty"`
-	BackupsEnabled                         bool                    `json:"backupsEnabled"`
-	IsDBaaS                                bool                    `json:"isDBaaS"`
-	IsSingle                               bool                    `json:"isSingle"`
-	AdditionalVolumes                      []ServiceVolume         `json:"additonalVolumes,omitempty"`
+	Name                                   string                    `json:"name"`         // the actual compose service name
+	OverrideName                           string                    `json:"overrideName"` // if an override name is provided, use it
+	Type                                   string                    `json:"type"`
+	AutogeneratedRoutesEnabled             bool                      `json:"autogeneratedRoutesEnabled"`
+	AutogeneratedRoutesTLSAcme             bool                      `json:"autogeneratedRoutesTLSAcme"`
+	AutogeneratedRoutesRequestVerification bool                      `json:"autogeneratedRoutesRequestVerification"`
+	AutogeneratedRouteDomain               string                    `json:"autogeneratedRouteDomain"`
+	ShortAutogeneratedRouteDomain          string                    `json:"shortAutogeneratedRouteDomain"`
+	DBaaSEnvironment                       string                    `json:"dbaasEnvironment"`
+	NativeCronjobs                         []lagoon.Cronjob          `json:"nativeCronjobs"`
+	InPodCronjobs                          []lagoon.Cronjob          `json:"inPodCronjobs"`
+	DeploymentServiceType                  string                    `json:"deploymentServiceType"`
+	ServicePort                            int32                     `json:"servicePort,omitempty"`
+	PersistentVolumePath                   string                    `json:"persistentVolumePath,omitempty"`
+	PersistentVolumeName                   string                    `json:"persistentVolumeName,omitempty"`
+	PersistentVolumeSize                   string                    `json:"persistentVolumeSize,omitempty"`
+	UseSpotInstances                       bool                      `json:"useSpot"`
+	ForceSpotInstances                     bool                      `json:"forceUseSpot"`
+	CronjobUseSpotInstances                bool                      `json:"cronjobUseSpot"`
+	CronjobForceSpotInstances              bool                      `json:"cronjobForceUseSpot"`
+	Replicas                               int32                     `json:"replicas"`
+	LinkedService                          *ServiceValues            `json:"linkedService"`
+	PodSecurityContext                     PodSecurityContext        `json:"podSecurityContext"`
+	AdditionalServicePorts                 []AdditionalServicePort   `json:"additionalServicePorts,omitempty"`
+	NodeSelectors                          *map[string]string        `json:"nodeSelectors"`
+	Tolerations                            *[]corev1.Toleration      `json:"tolerations"`
+	Affinity                               *corev1.Affinity          `json:"affinity"`
+	CronjobNodeSelectors                   *map[string]string        `json:"cronjobNodeSelectors"`
+	CronjobTolerations                     *[]corev1.Toleration      `json:"cronjobTolerations"`
+	CronjobAffinity                        *corev1.Affinity          `json:"cronjobAffinity"`
+	DBaasReadReplica                       bool                      `json:"dBaasReadReplica"`
+	ImageBuild                             *ImageBuild               `json:"docker,omitempty"`
+	BackupsEnabled                         bool                      `json:"backupsEnabled"`
+	IsDBaaS                                bool                      `json:"isDBaaS"`
+	IsSingle                               bool                      `json:"isSingle"`
+	DBaaSFallbackReason                    string                    `json:"dbaasFallbackReason,omitempty"`
+	AdditionalVolumes                      []ServiceVolume           `json:"additonalVolumes,omitempty"`
+	Resources                              *lagoon.ResourceOverrides `json:"resources,omitempty"`
+	Probes                                 *lagoon.ProbeOverrides    `json:"probes,omitempty"`
+	Autoscaling                            *ServiceAutoscaling       `json:"autoscaling,omitempty"`
+	Containers                             []lagoon.Container        `json:"containers,omitempty"`
+	InitContainers                         []lagoon.Container        `json:"initContainers,omitempty"`
+}
+
+// PodDisruptionBudget is the pod disruption budget of the services that run more than one replica
+type PodDisruptionBudget struct {
+	Enabled        bool               `json:"enabled"`
+	MaxUnavailable intstr.IntOrString `json:"maxUnavailable"`
+}
+
+// ServiceAutoscaling is the horizontal pod autoscaling of a service, the targets are percentages of the resource requests
+type ServiceAutoscaling struct {
+	MinReplicas             int32 `json:"minReplicas"`
+	MaxReplicas             int32 `json:"maxReplicas"`
+	TargetCPUUtilization    int32 `json:"targetCPUUtilization,omitempty"`
+	TargetMemoryUtilization int32 `json:"targetMemoryUtilization,omitempty"`
 }
 
 type ImageBuild struct {
@@ -0,0 +1,78 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// serviceContainers returns the additional containers and init containers of a service from the overrides for the service in the
+// environment of the .lagoon.yml. An external image has to match the container image allowlist the administrator has set, the
+// images built for the environment can always be used.
+func serviceContainers(
+	buildValues *BuildValues,
+	service string,
+	override lagoon.Override,
+) ([]lagoon.Container, []lagoon.Container, error) {
+	names := map[string]bool{}
+	for _, containers := range [][]lagoon.Container{override.Containers, override.InitContainers} {
+		for _, container := range containers {
+			if err := validateContainer(buildValues, container); err != nil {
+				return nil, nil, fmt.Errorf("service %s: container %s: %v", service, container.Name, err)
+			}
+			if names[container.Name] {
+				return nil, nil, fmt.Errorf("service %s: container %s is defined more than once", service, container.Name)
+			}
+			names[container.Name] = true
+		}
+	}
+	return override.Containers, override.InitContainers, nil
+}
+
+// validateContainer checks that an additional container has a valid name, one image, and volumes with a path
+func validateContainer(buildValues *BuildValues, container lagoon.Container) error {
+	if errs := validation.IsDNS1123Label(container.Name); len(errs) > 0 {
+		return fmt.Errorf("the name is not valid: %s", strings.Join(errs, ", "))
+	}
+	switch {
+	case container.Service != "" && container.Image != "":
+		return fmt.Errorf("only one of service or image can be set")
+	case container.Service == "" && container.Image == "":
+		return fmt.Errorf("one of service or image must be set")
+	case container.Image != "":
+		if !reference.ReferenceRegexp.MatchString(container.Image) {
+			return fmt.Errorf("the image %s is invalid, please ensure it conforms to the structure `[REGISTRY_HOST[:REGISTRY_PORT]/]REPOSITORY[:TAG|@DIGEST]`", container.Image)
+		}
+		if !imageAllowed(buildValues.ContainerImageAllowlist, container.Image) {
+			return fmt.Errorf("the image %s is not in the allowed images for this environment, contact your lagoon administrator", container.Image)
+		}
+	}
+	for _, volume := range container.Volumes {
+		if volume.Name == "" || !strings.HasPrefix(volume.Path, "/") {
+			return fmt.Errorf("volumes need a name and an absolute path")
+		}
+	}
+	return nil
+}
+
+// imageAllowed checks if an image matches one of the allowlist patterns, the patterns were checked when the build values were
+// generated. The image is matched as it is written and in its fully qualified form, so `fluent/fluent-bit` matches
+// `docker.io/fluent/*` and `busybox` matches `docker.io/library/*`
+func imageAllowed(allowlist []string, image string) bool {
+	images := []string{image}
+	if named, err := reference.ParseNormalizedNamed(image); err == nil {
+		images = append(images, named.String())
+	}
+	for _, pattern := range allowlist {
+		for _, i := range images {
+			if ok, _ := path.Match(pattern, i); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
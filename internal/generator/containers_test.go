@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func Test_serviceContainers(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist []string
+		override  lagoon.Override
+		wantErr   string
+	}{
+		{
+			name:      "test1 - allowed external image and a built image",
+			allowlist: []string{"gcr.io/cloud-sql-connectors/*"},
+			override: lagoon.Override{
+				Containers: []lagoon.Container{
+					{Name: "cloud-sql-proxy", Image: "gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.8"},
+				},
+				InitContainers: []lagoon.Container{
+					{Name: "migrate", Service: "node", Command: []string{"yarn", "migrate"}},
+				},
+			},
+		},
+		{
+			name:      "test2 - external image not in the allowlist",
+			allowlist: []string{"gcr.io/cloud-sql-connectors/*"},
+			override: lagoon.Override{
+				Containers: []lagoon.Container{
+					{Name: "log-shipper", Image: "fluent/fluent-bit:3.0"},
+				},
+			},
+			wantErr: "service node: container log-shipper: the image fluent/fluent-bit:3.0 is not in the allowed images for this environment, contact your lagoon administrator",
+		},
+		{
+			name: "test3 - external image without an allowlist",
+			override: lagoon.Override{
+				InitContainers: []lagoon.Container{
+					{Name: "wait", Image: "busybox:1.36"},
+				},
+			},
+			wantErr: "service node: container wait: the image busybox:1.36 is not in the allowed images for this environment, contact your lagoon administrator",
+		},
+		{
+			name: "test4 - service and image",
+			override: lagoon.Override{
+				Containers: []lagoon.Container{
+					{Name: "worker", Service: "node", Image: "busybox:1.36"},
+				},
+			},
+			wantErr: "service node: container worker: only one of service or image can be set",
+		},
+		{
+			name: "test5 - invalid name",
+			override: lagoon.Override{
+				Containers: []lagoon.Container{
+					{Name: "Log_Shipper", Service: "node"},
+				},
+			},
+			wantErr: "service node: container Log_Shipper: the name is not valid: a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')",
+		},
+		{
+			name: "test6 - duplicate name",
+			override: lagoon.Override{
+				Containers: []lagoon.Container{
+					{Name: "worker", Service: "node"},
+				},
+				InitContainers: []lagoon.Container{
+					{Name: "worker", Service: "node"},
+				},
+			},
+			wantErr: "service node: container worker is defined more than once",
+		},
+		{
+			name: "test7 - relative volume path",
+			override: lagoon.Override{
+				Containers: []lagoon.Container{
+					{Name: "worker", Service: "node", Volumes: []lagoon.ContainerVolume{{Name: "files", Path: "app/files"}}},
+				},
+			},
+			wantErr: "service node: container worker: volumes need a name and an absolute path",
+		},
+		{
+			name:      "test8 - docker hub images are matched in their fully qualified form",
+			allowlist: []string{"docker.io/fluent/*", "docker.io/library/busybox:*"},
+			override: lagoon.Override{
+				Containers: []lagoon.Container{
+					{Name: "log-shipper", Image: "fluent/fluent-bit:3.0"},
+				},
+				InitContainers: []lagoon.Container{
+					{Name: "wait", Image: "busybox:1.36"},
+				},
+			},
+		},
+		{
+			name:      "test9 - images of other registries are not docker hub images",
+			allowlist: []string{"docker.io/cloud-sql-connectors/*"},
+			override: lagoon.Override{
+				Containers: []lagoon.Container{
+					{Name: "cloud-sql-proxy", Image: "gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.8"},
+				},
+			},
+			wantErr: "service node: container cloud-sql-proxy: the image gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.8 is not in the allowed images for this environment, contact your lagoon administrator",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buildValues := &BuildValues{
+				ContainerImageAllowlist: tt.allowlist,
+			}
+			_, _, err := serviceContainers(buildValues, "node", tt.override)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("serviceContainers() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Errorf("serviceContainers() error = nil, wantErr %v", tt.wantErr)
+			}
+		})
+	}
+}
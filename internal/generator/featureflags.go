@@ -0,0 +1,215 @@
+package generator
+
+import (
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// FeatureFlagTierBuiltin is used when no tier provided a value for a feature flag, and the default from the registry is used
+const FeatureFlagTierBuiltin = "BUILTIN"
+
+// the allowed values of a feature flag that is either on or off
+var enabledDisabled = []string{"enabled", "disabled"}
+
+// FeatureFlag is a feature flag that the generator knows about
+// admin feature flags can only be set by the `ADMIN_LAGOON_FEATURE_FLAG_` build variable, all other feature flags can be set
+// using the force, lagoon environment variable, or default tiers
+type FeatureFlag struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Values      []string `json:"values,omitempty"`
+	Default     string   `json:"default"`
+	Admin       bool     `json:"admin"`
+}
+
+// FeatureFlags is the registry of every feature flag the generator knows about
+var FeatureFlags = []FeatureFlag{
+	{
+		Name:        "POD_SPREADCONSTRAINTS",
+		Description: "add topology spread constraints and pod anti-affinity to deployments",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "POD_DISRUPTION_BUDGET",
+		Description: "add a pod disruption budget to the services that run more than one replica",
+		Values:      enabledDisabled,
+		Default:     "enabled",
+	},
+	{
+		Name:        "POD_DISRUPTION_BUDGET_MAX_UNAVAILABLE",
+		Description: "the number or percentage of the pods of a service that a pod disruption budget allows to be evicted at once",
+		Default:     "1",
+	},
+	{
+		Name:        "RWX_TO_RWO",
+		Description: "create ReadWriteMany persistent volume claims as ReadWriteOnce",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "ISOLATION_NETWORK_POLICY",
+		Description: "add a network policy that isolates the environment from other namespaces",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "IMAGECACHE_REGISTRY",
+		Description: "the image cache registry used for images that are not in the image registry",
+	},
+	{
+		Name:        "INGRESS_CLASS",
+		Description: "the ingress class used for ingress that do not define one",
+	},
+	{
+		Name:        "ROOTLESS_WORKLOAD",
+		Description: "run workloads as a non-root user",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "FS_ON_ROOT_MISMATCH",
+		Description: "only change the ownership of volumes when the root of the volume does not match the fsGroup",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "FASTLY_AUTOGENERATED",
+		Description: "add fastly annotations to autogenerated routes",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "LAGOONYML_INTERPOLATION",
+		Description: "interpolate variables in the route domains, cronjob commands and task commands of the .lagoon.yml",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "CLEANUP_REMOVED_LAGOON_ROUTES",
+		Description: "delete the routes that have been removed from the .lagoon.yml or the Lagoon API",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "CUSTOM_BACKUP_CONFIG",
+		Description: "allow the backup schedule to be set using the LAGOON_BACKUP_*_SCHEDULE variables",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "K8UP_WEEKLY_RANDOM_CHECK",
+		Description: "use a weekly random schedule for backup checks",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "K8UP_WEEKLY_RANDOM_PRUNE",
+		Description: "use a weekly random schedule for backup prunes",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "SPOT_INSTANCE_PRODUCTION",
+		Description: "schedule the SPOT_INSTANCE_PRODUCTION_TYPES service types onto spot instances in production environments",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "SPOT_INSTANCE_PRODUCTION_TYPES",
+		Description: "the comma separated service types (type or type:force) to run on spot instances in production environments",
+	},
+	{
+		Name:        "SPOT_INSTANCE_PRODUCTION_CRONJOB_TYPES",
+		Description: "the comma separated service types (type or type:force) to run cronjobs on spot instances in production environments",
+	},
+	{
+		Name:        "SPOT_INSTANCE_DEVELOPMENT",
+		Description: "schedule the SPOT_INSTANCE_DEVELOPMENT_TYPES service types onto spot instances in development environments",
+		Values:      enabledDisabled,
+		Default:     "disabled",
+	},
+	{
+		Name:        "SPOT_INSTANCE_DEVELOPMENT_TYPES",
+		Description: "the comma separated service types (type or type:force) to run on spot instances in development environments",
+	},
+	{
+		Name:        "SPOT_INSTANCE_DEVELOPMENT_CRONJOB_TYPES",
+		Description: "the comma separated service types (type or type:force) to run cronjobs on spot instances in development environments",
+	},
+	{
+		Name:        "SPOT_TYPE_REPLICAS_PRODUCTION",
+		Description: "the comma separated service types that run with multiple replicas on spot instances in production environments",
+		Default:     "nginx,nginx-persistent,nginx-php,nginx-php-persistent",
+		Admin:       true,
+	},
+	{
+		Name:        "SPOT_TYPE_REPLICAS_DEVELOPMENT",
+		Description: "the comma separated service types that run with multiple replicas on spot instances in development environments",
+		Admin:       true,
+	},
+	{
+		Name:        "CONTAINER_MEMORY_LIMIT",
+		Description: "the memory limit applied to containers",
+		Admin:       true,
+	},
+	{
+		Name:        "EPHEMERAL_STORAGE_LIMIT",
+		Description: "the ephemeral storage limit applied to containers",
+		Admin:       true,
+	},
+	{
+		Name:        "EPHEMERAL_STORAGE_REQUESTS",
+		Description: "the ephemeral storage request applied to containers",
+		Admin:       true,
+	},
+	{
+		Name:        "CONTAINER_IMAGE_ALLOWLIST",
+		Description: "the comma separated image patterns that the additional containers of a service in the .lagoon.yml can use, only the images built for the environment can be used if it is not set",
+		Admin:       true,
+	},
+	{
+		Name:        "CONTAINER_CPU_CEILING",
+		Description: "the largest cpu request or limit that a service can set with the lagoon.resources labels or .lagoon.yml overrides",
+		Admin:       true,
+	},
+	{
+		Name:        "CONTAINER_MEMORY_CEILING",
+		Description: "the largest memory request or limit that a service can set with the lagoon.resources labels or .lagoon.yml overrides",
+		Admin:       true,
+	},
+}
+
+// GetFeatureFlag returns the registered feature flag with the given name
+func GetFeatureFlag(name string) (FeatureFlag, bool) {
+	for _, flag := range FeatureFlags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return FeatureFlag{}, false
+}
+
+// Resolve resolves the value of the feature flag from the tiers that apply to it, if no tier provides a value then the
+// default is returned with the builtin tier
+func (f FeatureFlag) Resolve(envVariables []lagoon.EnvironmentVariable, debug bool) FeatureFlagResult {
+	var result FeatureFlagResult
+	if f.Admin {
+		result = ResolveAdminFeatureFlag(f.Name, debug)
+	} else {
+		result = ResolveFeatureFlag(f.Name, envVariables, debug)
+	}
+	if result.Tier == "" {
+		result.Value = f.Default
+		result.Tier = FeatureFlagTierBuiltin
+	}
+	return result
+}
+
+// ResolveFeatureFlags resolves every registered feature flag
+func ResolveFeatureFlags(envVariables []lagoon.EnvironmentVariable, debug bool) []FeatureFlagResult {
+	results := []FeatureFlagResult{}
+	for _, flag := range FeatureFlags {
+		results = append(results, flag.Resolve(envVariables, debug))
+	}
+	return results
+}
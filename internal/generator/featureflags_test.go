@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func TestFeatureFlagResolve(t *testing.T) {
+	tests := []struct {
+		name         string
+		flag         string
+		vars         []helpers.EnvironmentVariable
+		envVariables []lagoon.EnvironmentVariable
+		want         FeatureFlagResult
+	}{
+		{
+			name: "test1 - builtin default",
+			flag: "ROOTLESS_WORKLOAD",
+			want: FeatureFlagResult{Name: "ROOTLESS_WORKLOAD", Value: "disabled", Tier: FeatureFlagTierBuiltin},
+		},
+		{
+			name: "test2 - lagoon environment variable",
+			flag: "ROOTLESS_WORKLOAD",
+			vars: []helpers.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_DEFAULT_ROOTLESS_WORKLOAD", Value: "disabled"},
+			},
+			envVariables: []lagoon.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_ROOTLESS_WORKLOAD", Value: "enabled", Scope: "build"},
+			},
+			want: FeatureFlagResult{
+				Name:     "ROOTLESS_WORKLOAD",
+				Value:    "enabled",
+				Tier:     FeatureFlagTierEnvironment,
+				Variable: "LAGOON_FEATURE_FLAG_ROOTLESS_WORKLOAD",
+			},
+		},
+		{
+			name: "test3 - admin flag ignores lagoon environment variables",
+			flag: "CONTAINER_MEMORY_LIMIT",
+			envVariables: []lagoon.EnvironmentVariable{
+				{Name: "LAGOON_FEATURE_FLAG_CONTAINER_MEMORY_LIMIT", Value: "1Gi", Scope: "build"},
+			},
+			want: FeatureFlagResult{Name: "CONTAINER_MEMORY_LIMIT", Value: "", Tier: FeatureFlagTierBuiltin},
+		},
+		{
+			name: "test4 - admin flag",
+			flag: "SPOT_TYPE_REPLICAS_PRODUCTION",
+			vars: []helpers.EnvironmentVariable{
+				{Name: "ADMIN_LAGOON_FEATURE_FLAG_SPOT_TYPE_REPLICAS_PRODUCTION", Value: "nginx"},
+			},
+			want: FeatureFlagResult{
+				Name:     "SPOT_TYPE_REPLICAS_PRODUCTION",
+				Value:    "nginx",
+				Tier:     FeatureFlagTierAdmin,
+				Variable: "ADMIN_LAGOON_FEATURE_FLAG_SPOT_TYPE_REPLICAS_PRODUCTION",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, envVar := range tt.vars {
+				err := os.Setenv(envVar.Name, envVar.Value)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+			}
+			flag, ok := GetFeatureFlag(tt.flag)
+			if !ok {
+				t.Fatalf("GetFeatureFlag() %s is not registered", tt.flag)
+			}
+			if got := flag.Resolve(tt.envVariables, false); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+			t.Cleanup(func() {
+				helpers.UnsetEnvVars(tt.vars)
+			})
+		})
+	}
+}
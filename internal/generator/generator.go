@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 
 	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/servicetypes"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type Generator struct {
@@ -50,6 +54,8 @@ type GeneratorInput struct {
 	FastlyCacheNoCahce         string
 	FastlyAPISecretPrefix      string
 	SavedTemplatesPath         string
+	TemplateOutput             string
+	TemplateOutputFile         string
 	ConfigMapSha               string
 	BackupConfiguration        BackupConfiguration
 	IgnoreNonStringKeyErrors   bool
@@ -66,6 +72,8 @@ type GeneratorInput struct {
 	DynamicDBaaSSecrets        []string
 	ImageCacheBuildArgsJSON    string
 	SSHPrivateKey              string
+	ReportFile                 string
+	ServiceTypeDefinitions     string
 }
 
 func NewGenerator(
@@ -140,12 +148,26 @@ func NewGenerator(
 
 	buildValues.Backup.K8upVersion = helpers.GetEnv("K8UP_VERSION", generator.BackupConfiguration.K8upVersion, generator.Debug)
 
+	// load any additional service types that the cluster provides
+	serviceTypeDefinitions := helpers.GetEnv("LAGOON_SERVICE_TYPE_DEFINITIONS", generator.ServiceTypeDefinitions, generator.Debug)
+	if serviceTypeDefinitions != "" {
+		buildValues.ServiceTypeDefinitions, err = servicetypes.LoadDefinitions(serviceTypeDefinitions)
+		if err != nil {
+			return nil, err
+		}
+		for name := range buildValues.ServiceTypeDefinitions {
+			if isReservedServiceType(name) {
+				return nil, fmt.Errorf("couldn't load the service type definitions: service type %s is reserved", name)
+			}
+		}
+	}
+
 	// get the project and environment variables
 	projectVariables := helpers.GetEnv("LAGOON_PROJECT_VARIABLES", generator.ProjectVariables, generator.Debug)
 	environmentVariables := helpers.GetEnv("LAGOON_ENVIRONMENT_VARIABLES", generator.EnvironmentVariables, generator.Debug)
 
 	// read the .lagoon.yml file and the LAGOON_YAML_OVERRIDE if set
-	if err := LoadAndUnmarshalLagoonYml(generator.LagoonYAML, generator.LagoonYAMLOverride, "LAGOON_YAML_OVERRIDE", lYAML, projectName, generator.Debug); err != nil {
+	if err := LoadAndUnmarshalLagoonYml(generator.LagoonYAML, generator.LagoonYAMLOverride, "LAGOON_YAML_OVERRIDE", lYAML, projectName, generator.Debug, &buildValues.LagoonYAMLWarnings); err != nil {
 		return nil, err
 	}
 	buildValues.LagoonYAML = *lYAML
@@ -259,6 +281,18 @@ func NewGenerator(
 	// this will later be used to add `runtime|global` scope into the `lagoon-env` configmap
 	buildValues.EnvironmentVariables = lagoon.MergeVariables(mergedVariables, configVars)
 
+	// interpolate the variables into the .lagoon.yml if it is enabled
+	lagoonYAMLInterpolation := buildValues.checkFeatureFlag("LAGOONYML_INTERPOLATION", buildValues.EnvironmentVariables, generator.Debug)
+	if lagoonYAMLInterpolation == "enabled" {
+		_, environmentKey, err := buildValues.LagoonYAML.Environments.Resolve(buildValues.Branch, buildValues.Environment)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't resolve the environment in the .lagoon.yml: %v", err)
+		}
+		if err := lagoon.InterpolateLagoonYAML(&buildValues.LagoonYAML, environmentKey, interpolationVariables(buildValues.EnvironmentVariables)); err != nil {
+			return nil, fmt.Errorf("couldn't interpolate the .lagoon.yml: %v", err)
+		}
+	}
+
 	// if the core version is provided from the API, set the buildvalues LagoonVersion to this instead
 	lagoonCoreVersion, _ := lagoon.GetLagoonVariable("LAGOON_SYSTEM_CORE_VERSION", []string{"internal_system"}, buildValues.EnvironmentVariables)
 	if lagoonCoreVersion != nil {
@@ -271,25 +305,47 @@ func NewGenerator(
 	}
 
 	// feature to enable pod antiaffinity on deployments
-	podAntiAffinity := CheckFeatureFlag("POD_SPREADCONSTRAINTS", buildValues.EnvironmentVariables, false)
+	podAntiAffinity := buildValues.checkFeatureFlag("POD_SPREADCONSTRAINTS", buildValues.EnvironmentVariables, false)
 	if podAntiAffinity == "enabled" {
 		buildValues.PodAntiAffinity = true
 	}
 
+	// feature to add pod disruption budgets to services with more than one replica, enabled by default
+	pdb := buildValues.checkFeatureFlag("POD_DISRUPTION_BUDGET", buildValues.EnvironmentVariables, generator.Debug)
+	if pdb != "disabled" {
+		buildValues.PodDisruptionBudget.Enabled = true
+		buildValues.PodDisruptionBudget.MaxUnavailable = intstr.FromInt32(1)
+		maxUnavailable := buildValues.checkFeatureFlag("POD_DISRUPTION_BUDGET_MAX_UNAVAILABLE", buildValues.EnvironmentVariables, generator.Debug)
+		if maxUnavailable != "" {
+			buildValues.PodDisruptionBudget.MaxUnavailable = intstr.Parse(maxUnavailable)
+			// a pod disruption budget that allows no pods to be evicted would block node drains
+			value, err := intstr.GetScaledValueFromIntOrPercent(&buildValues.PodDisruptionBudget.MaxUnavailable, 100, true)
+			if err != nil || value < 1 {
+				return nil, fmt.Errorf("provided pod disruption budget max unavailable %s must be a number or percentage greater than zero", maxUnavailable)
+			}
+		}
+	}
+
+	// check if routes that have been removed can be deleted, disabled by default
+	cleanupRemovedRoutes := buildValues.checkFeatureFlag("CLEANUP_REMOVED_LAGOON_ROUTES", buildValues.EnvironmentVariables, generator.Debug)
+	if cleanupRemovedRoutes == "enabled" {
+		buildValues.CleanupRemovedRoutes = true
+	}
+
 	// check for readwritemany to readwriteonce flag, disabled by default
-	rwx2rwo := CheckFeatureFlag("RWX_TO_RWO", buildValues.EnvironmentVariables, generator.Debug)
+	rwx2rwo := buildValues.checkFeatureFlag("RWX_TO_RWO", buildValues.EnvironmentVariables, generator.Debug)
 	if rwx2rwo == "enabled" {
 		buildValues.RWX2RWO = true
 	}
 
 	// check for isolation network policy, disabled by default
-	isolationNetworkPolicy := CheckFeatureFlag("ISOLATION_NETWORK_POLICY", buildValues.EnvironmentVariables, generator.Debug)
+	isolationNetworkPolicy := buildValues.checkFeatureFlag("ISOLATION_NETWORK_POLICY", buildValues.EnvironmentVariables, generator.Debug)
 	if isolationNetworkPolicy == "enabled" {
 		buildValues.IsolationNetworkPolicy = true
 	}
 
 	// check for imagecache override, disabled by default
-	imageCache := CheckFeatureFlag("IMAGECACHE_REGISTRY", buildValues.EnvironmentVariables, generator.Debug)
+	imageCache := buildValues.checkFeatureFlag("IMAGECACHE_REGISTRY", buildValues.EnvironmentVariables, generator.Debug)
 	if imageCache != "" {
 		// strip the scheme, only provide the host
 		u, _ := url.Parse(imageCache)
@@ -312,11 +368,11 @@ func NewGenerator(
 	}
 
 	// check the environment for INGRESS_CLASS flag, will be "" if there are none found
-	ingressClass := CheckFeatureFlag("INGRESS_CLASS", buildValues.EnvironmentVariables, generator.Debug)
+	ingressClass := buildValues.checkFeatureFlag("INGRESS_CLASS", buildValues.EnvironmentVariables, generator.Debug)
 	buildValues.IngressClass = ingressClass
 
 	// check for rootless workloads
-	rootlessWorkloads := CheckFeatureFlag("ROOTLESS_WORKLOAD", buildValues.EnvironmentVariables, generator.Debug)
+	rootlessWorkloads := buildValues.checkFeatureFlag("ROOTLESS_WORKLOAD", buildValues.EnvironmentVariables, generator.Debug)
 	if rootlessWorkloads == "enabled" {
 		buildValues.FeatureFlags["rootlessworkloads"] = true
 		buildValues.PodSecurityContext = PodSecurityContext{
@@ -326,15 +382,15 @@ func NewGenerator(
 		}
 	}
 
-	fsOnRootMismatch := CheckFeatureFlag("FS_ON_ROOT_MISMATCH", buildValues.EnvironmentVariables, generator.Debug)
+	fsOnRootMismatch := buildValues.checkFeatureFlag("FS_ON_ROOT_MISMATCH", buildValues.EnvironmentVariables, generator.Debug)
 	if fsOnRootMismatch == "enabled" {
 		buildValues.PodSecurityContext.OnRootMismatch = true
 	}
 
 	// check admin features for resources
-	buildValues.Resources.Limits.Memory = CheckAdminFeatureFlag("CONTAINER_MEMORY_LIMIT", false)
-	buildValues.Resources.Limits.EphemeralStorage = CheckAdminFeatureFlag("EPHEMERAL_STORAGE_LIMIT", false)
-	buildValues.Resources.Requests.EphemeralStorage = CheckAdminFeatureFlag("EPHEMERAL_STORAGE_REQUESTS", false)
+	buildValues.Resources.Limits.Memory = buildValues.checkAdminFeatureFlag("CONTAINER_MEMORY_LIMIT", false)
+	buildValues.Resources.Limits.EphemeralStorage = buildValues.checkAdminFeatureFlag("EPHEMERAL_STORAGE_LIMIT", false)
+	buildValues.Resources.Requests.EphemeralStorage = buildValues.checkAdminFeatureFlag("EPHEMERAL_STORAGE_REQUESTS", false)
 	// validate that what is provided
 	if buildValues.Resources.Limits.Memory != "" {
 		err := ValidateResourceQuantity(buildValues.Resources.Limits.Memory)
@@ -354,6 +410,29 @@ func NewGenerator(
 			return nil, fmt.Errorf("provided  ephemeral storage requests %s is not a valid resource quantity", buildValues.Resources.Requests.EphemeralStorage)
 		}
 	}
+	// the images that the additional containers of a service can use, other than the images built for the environment
+	for _, pattern := range strings.Split(buildValues.checkAdminFeatureFlag("CONTAINER_IMAGE_ALLOWLIST", false), ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("provided container image allowlist pattern %s is not valid: %v", pattern, err)
+		}
+		buildValues.ContainerImageAllowlist = append(buildValues.ContainerImageAllowlist, pattern)
+	}
+	buildValues.Resources.Ceilings.CPU = buildValues.checkAdminFeatureFlag("CONTAINER_CPU_CEILING", false)
+	buildValues.Resources.Ceilings.Memory = buildValues.checkAdminFeatureFlag("CONTAINER_MEMORY_CEILING", false)
+	if buildValues.Resources.Ceilings.CPU != "" {
+		if _, err := resource.ParseQuantity(buildValues.Resources.Ceilings.CPU); err != nil {
+			return nil, fmt.Errorf("provided cpu ceiling %s is not a valid resource quantity", buildValues.Resources.Ceilings.CPU)
+		}
+	}
+	if buildValues.Resources.Ceilings.Memory != "" {
+		if _, err := resource.ParseQuantity(buildValues.Resources.Ceilings.Memory); err != nil {
+			return nil, fmt.Errorf("provided memory ceiling %s is not a valid resource quantity", buildValues.Resources.Ceilings.Memory)
+		}
+	}
 
 	// get any variables from the API here that could be used to influence a build or services within the environment
 	// collect docker buildkit value
@@ -375,7 +454,7 @@ func NewGenerator(
 
 	// check autogenerated routes for fastly `LAGOON_FEATURE_FLAG(_FORCE|_DEFAULT)_FASTLY_AUTOGENERATED` using feature flags
 	// @TODO: eventually deprecate fastly functionality in favour of a more generic implementation
-	autogeneratedRoutesFastly := CheckFeatureFlag("FASTLY_AUTOGENERATED", buildValues.EnvironmentVariables, generator.Debug)
+	autogeneratedRoutesFastly := buildValues.checkFeatureFlag("FASTLY_AUTOGENERATED", buildValues.EnvironmentVariables, generator.Debug)
 	if autogeneratedRoutesFastly == "enabled" {
 		buildValues.AutogeneratedRoutesFastly = true
 	} else {
@@ -456,12 +535,20 @@ func NewGenerator(
 	// finally return the generator values, this should be a mostly complete version of the resulting data needed for a build
 	// another step will collect the current or known state of a build.
 	// the output of the generator and the output of that state collector will eventually replace a lot of the legacy BASH script
-	return &Generator{
+	lagoonBuild := &Generator{
 		BuildValues:         &buildValues,
 		ActiveEnvironment:   &buildValues.IsActiveEnvironment,
 		StandbyEnvironment:  &buildValues.IsStandbyEnvironment,
 		AutogeneratedRoutes: autogenRoutes,
 		MainRoutes:          mainRoutes,
 		ActiveStandbyRoutes: activeStandbyRoutes,
-	}, nil
+	}
+
+	// write out the build report if one was requested
+	if generator.ReportFile != "" {
+		if err := WriteBuildReport(lagoonBuild, generator.ReportFile); err != nil {
+			return nil, err
+		}
+	}
+	return lagoonBuild, nil
 }
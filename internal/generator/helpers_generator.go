@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/servicetypes"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
@@ -113,6 +114,14 @@ func GenerateInput(rootCmd cobra.Command, debug bool) (GeneratorInput, error) {
 	if err != nil {
 		return GeneratorInput{}, fmt.Errorf("error reading default-backup-schedule flag: %v", err)
 	}
+	reportFile, err := rootCmd.PersistentFlags().GetString("report-file")
+	if err != nil {
+		return GeneratorInput{}, fmt.Errorf("error reading report-file flag: %v", err)
+	}
+	serviceTypeDefinitions, err := rootCmd.PersistentFlags().GetString("service-type-definitions")
+	if err != nil {
+		return GeneratorInput{}, fmt.Errorf("error reading service-type-definitions flag: %v", err)
+	}
 	// create a dbaas client with the default configuration
 	dbaas := dbaasclient.NewClient(dbaasclient.Client{})
 	return GeneratorInput{
@@ -142,17 +151,43 @@ func GenerateInput(rootCmd cobra.Command, debug bool) (GeneratorInput, error) {
 		IgnoreNonStringKeyErrors: ignoreNonStringKeyErrors,
 		DBaaSClient:              dbaas,
 		DefaultBackupSchedule:    defaultBackupSchedule,
+		ReportFile:               reportFile,
+		ServiceTypeDefinitions:   serviceTypeDefinitions,
 	}, nil
 }
 
+// the tiers that a feature flag value can be resolved from
+const (
+	FeatureFlagTierForce       = "FORCE"
+	FeatureFlagTierEnvironment = "ENVIRONMENT"
+	FeatureFlagTierDefault     = "DEFAULT"
+	FeatureFlagTierAdmin       = "ADMIN"
+)
+
+// FeatureFlagResult is the value of a feature flag and the tier and variable it was resolved from
+// if no tier provided a value, then the value and tier are empty
+type FeatureFlagResult struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Tier     string `json:"tier,omitempty"`
+	Variable string `json:"variable,omitempty"`
+}
+
 // checks the provided environment variables looking for feature flag based variables
 func CheckFeatureFlag(key string, envVariables []lagoon.EnvironmentVariable, debug bool) string {
+	return ResolveFeatureFlag(key, envVariables, debug).Value
+}
+
+// ResolveFeatureFlag checks the force build variable, then the provided environment variables, then the default build variable
+// for a feature flag and returns the first value found along with the tier it came from
+func ResolveFeatureFlag(key string, envVariables []lagoon.EnvironmentVariable, debug bool) FeatureFlagResult {
 	// check for force value
-	if value, ok := os.LookupEnv(fmt.Sprintf("LAGOON_FEATURE_FLAG_FORCE_%s", key)); ok {
+	forceFlag := fmt.Sprintf("LAGOON_FEATURE_FLAG_FORCE_%s", key)
+	if value, ok := os.LookupEnv(forceFlag); ok {
 		if debug {
-			fmt.Printf("Using forced flag value from build variable %s\n", fmt.Sprintf("LAGOON_FEATURE_FLAG_FORCE_%s", key))
+			fmt.Printf("Using forced flag value from build variable %s\n", forceFlag)
 		}
-		return value
+		return FeatureFlagResult{Name: key, Value: value, Tier: FeatureFlagTierForce, Variable: forceFlag}
 	}
 	// check lagoon environment variables
 	for _, lVar := range envVariables {
@@ -160,28 +195,71 @@ func CheckFeatureFlag(key string, envVariables []lagoon.EnvironmentVariable, deb
 			if debug {
 				fmt.Printf("Using flag value from Lagoon environment variable %s\n", fmt.Sprintf("LAGOON_FEATURE_FLAG_%s", key))
 			}
-			return lVar.Value
+			return FeatureFlagResult{Name: key, Value: lVar.Value, Tier: FeatureFlagTierEnvironment, Variable: lVar.Name}
 		}
 	}
 	// return default
-	if value, ok := os.LookupEnv(fmt.Sprintf("LAGOON_FEATURE_FLAG_DEFAULT_%s", key)); ok {
+	defaultFlag := fmt.Sprintf("LAGOON_FEATURE_FLAG_DEFAULT_%s", key)
+	if value, ok := os.LookupEnv(defaultFlag); ok {
 		if debug {
-			fmt.Printf("Using default flag value from build variable %s\n", fmt.Sprintf("LAGOON_FEATURE_FLAG_DEFAULT_%s", key))
+			fmt.Printf("Using default flag value from build variable %s\n", defaultFlag)
 		}
-		return value
+		return FeatureFlagResult{Name: key, Value: value, Tier: FeatureFlagTierDefault, Variable: defaultFlag}
 	}
 	// otherwise nothing
-	return ""
+	return FeatureFlagResult{Name: key}
 }
 
 func CheckAdminFeatureFlag(key string, debug bool) string {
-	if value, ok := os.LookupEnv(fmt.Sprintf("ADMIN_LAGOON_FEATURE_FLAG_%s", key)); ok {
+	return ResolveAdminFeatureFlag(key, debug).Value
+}
+
+// ResolveAdminFeatureFlag checks the admin build variable for a feature flag
+func ResolveAdminFeatureFlag(key string, debug bool) FeatureFlagResult {
+	adminFlag := fmt.Sprintf("ADMIN_LAGOON_FEATURE_FLAG_%s", key)
+	if value, ok := os.LookupEnv(adminFlag); ok {
 		if debug {
-			fmt.Printf("Using admin feature flag value from build variable %s\n", fmt.Sprintf("ADMIN_LAGOON_FEATURE_FLAG_%s", key))
+			fmt.Printf("Using admin feature flag value from build variable %s\n", adminFlag)
+		}
+		return FeatureFlagResult{Name: key, Value: value, Tier: FeatureFlagTierAdmin, Variable: adminFlag}
+	}
+	return FeatureFlagResult{Name: key}
+}
+
+// GetServiceType returns the service type with the name, from the built-in service types or the service types loaded from the
+// service type definitions
+func (b *BuildValues) GetServiceType(name string) (servicetypes.ServiceType, bool) {
+	if serviceType, ok := servicetypes.ServiceTypes[name]; ok {
+		return serviceType, true
+	}
+	serviceType, ok := b.ServiceTypeDefinitions[name]
+	return serviceType, ok
+}
+
+// checkFeatureFlag resolves a feature flag and records the result against the build values so that it can be reported
+func (b *BuildValues) checkFeatureFlag(key string, envVariables []lagoon.EnvironmentVariable, debug bool) string {
+	result := ResolveFeatureFlag(key, envVariables, debug)
+	b.recordFeatureFlag(result)
+	return result.Value
+}
+
+// checkAdminFeatureFlag resolves an admin feature flag and records the result against the build values so that it can be reported
+func (b *BuildValues) checkAdminFeatureFlag(key string, debug bool) string {
+	result := ResolveAdminFeatureFlag(key, debug)
+	b.recordFeatureFlag(result)
+	return result.Value
+}
+
+// recordFeatureFlag stores a feature flag result, flags that are checked more than once (like the per service spot flags) are
+// only recorded once
+func (b *BuildValues) recordFeatureFlag(result FeatureFlagResult) {
+	for idx, r := range b.FeatureFlagResults {
+		if r.Name == result.Name {
+			b.FeatureFlagResults[idx] = result
+			return
 		}
-		return value
 	}
-	return ""
+	b.FeatureFlagResults = append(b.FeatureFlagResults, result)
 }
 
 func ValidateResourceQuantity(s string) (err error) {
@@ -272,7 +350,7 @@ func getDBaasEnvironment(
 	}
 	exists, err := buildValues.DBaaSClient.CheckProvider(buildValues.DBaaSOperatorEndpoint, lagoonType, *dbaasEnvironment)
 	if err != nil {
-		return exists, fmt.Errorf("there was an error checking DBaaS endpoint %s: %v", buildValues.DBaaSOperatorEndpoint, err)
+		return exists, fmt.Errorf("there was an error checking DBaaS endpoint %s: %w", buildValues.DBaaSOperatorEndpoint, err)
 	}
 	return exists, nil
 }
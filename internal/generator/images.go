@@ -15,6 +15,10 @@ import (
 func generateImageBuild(buildValues BuildValues, composeServiceValues composetypes.ServiceConfig, composeService string) (ImageBuild, error) {
 	// create a holder for all the docker related information, if this is a pull through image or a build image
 	imageBuild := ImageBuild{}
+	environment, err := lagoonEnvironment(buildValues)
+	if err != nil {
+		return imageBuild, err
+	}
 	// if this is not a promote environment, then attempt to work out the image build information that is required for the builder
 	if buildValues.BuildType != "promote" {
 		// handle extracting the built image name from the provided image references
@@ -33,8 +37,8 @@ func generateImageBuild(buildValues BuildValues, composeServiceValues composetyp
 			imageBuild.Target = composeServiceValues.Build.Target
 		}
 		// if there is a dockerfile defined in the
-		if buildValues.LagoonYAML.Environments[buildValues.Environment].Overrides[composeService].Build.Dockerfile != "" {
-			imageBuild.DockerFile = buildValues.LagoonYAML.Environments[buildValues.Environment].Overrides[composeService].Build.Dockerfile
+		if environment.Overrides[composeService].Build.Dockerfile != "" {
+			imageBuild.DockerFile = environment.Overrides[composeService].Build.Dockerfile
 			if imageBuild.Context == "" {
 				// if we get here, it means that a dockerfile override was defined in the .lagoon.yml file
 				// but there was no `build` spec defined in the docker-compose file, so this just sets the context to the default `.`
@@ -52,8 +56,8 @@ func generateImageBuild(buildValues BuildValues, composeServiceValues composetyp
 			// check docker-compose override image
 			pullImage := lagoon.CheckDockerComposeLagoonLabel(composeServiceValues.Labels, "lagoon.image")
 			// check lagoon.yml override image
-			if buildValues.LagoonYAML.Environments[buildValues.Environment].Overrides[composeService].Image != "" {
-				pullImage = buildValues.LagoonYAML.Environments[buildValues.Environment].Overrides[composeService].Image
+			if environment.Overrides[composeService].Image != "" {
+				pullImage = environment.Overrides[composeService].Image
 			}
 			if pullImage != "" {
 				// if an override image is provided, envsubst it
@@ -89,8 +93,8 @@ func generateImageBuild(buildValues BuildValues, composeServiceValues composetyp
 			// set temporary image to prevent clashes?? not sure this is even required, the temporary name is just as unique as the final image name eventually is
 			// so clashing would occur in both situations
 			imageBuild.TemporaryImage = fmt.Sprintf("%s-%s", buildValues.Namespace, composeService) //@TODO maybe get rid of this
-			if buildValues.LagoonYAML.Environments[buildValues.Environment].Overrides[composeService].Build.Context != "" {
-				imageBuild.Context = buildValues.LagoonYAML.Environments[buildValues.Environment].Overrides[composeService].Build.Context
+			if environment.Overrides[composeService].Build.Context != "" {
+				imageBuild.Context = environment.Overrides[composeService].Build.Context
 			}
 			// check the dockerfile exists
 			if _, err := os.Stat(fmt.Sprintf("%s/%s", imageBuild.Context, imageBuild.DockerFile)); errors.Is(err, os.ErrNotExist) {
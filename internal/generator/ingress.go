@@ -127,6 +127,10 @@ func generateAutogenRoutes(
 	buildValues *BuildValues,
 	autogenRoutes *lagoon.RoutesV2,
 ) error {
+	environment, err := lagoonEnvironment(*buildValues)
+	if err != nil {
+		return err
+	}
 	// generate autogenerated routes for the services
 	// get the router pattern
 	lagoonRouterPattern, err := lagoon.GetLagoonVariable("LAGOON_SYSTEM_ROUTER_PATTERN", []string{"internal_system"}, envVars)
@@ -171,7 +175,7 @@ func generateAutogenRoutes(
 				var pathRoutes []lagoon.PathRoute
 				// calculate path based routing for autogenerated routes
 				// check for environment specific path routes
-				agPathRoutes := buildValues.LagoonYAML.Environments[buildValues.Environment].AutogeneratePathRoutes
+				agPathRoutes := environment.AutogeneratePathRoutes
 				if agPathRoutes == nil {
 					// if none, check for global path routes
 					agPathRoutes = buildValues.LagoonYAML.Routes.Autogenerate.PathRoutes
@@ -343,9 +347,13 @@ func generateAndMerge(
 	buildValues BuildValues,
 ) (lagoon.RoutesV2, error) {
 	n := &lagoon.RoutesV2{} // placeholder for generated routes
+	environment, err := lagoonEnvironment(buildValues)
+	if err != nil {
+		return *n, err
+	}
 
 	// otherwise it just uses the default environment name
-	for _, routeMap := range buildValues.LagoonYAML.Environments[buildValues.Branch].Routes {
+	for _, routeMap := range environment.Routes {
 		err := lagoon.GenerateRoutesV2(n, routeMap, envVars, buildValues.IngressClass, buildValues.FastlyAPISecretPrefix, false)
 		if err != nil {
 			return *n, err
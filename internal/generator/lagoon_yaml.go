@@ -7,24 +7,28 @@ import (
 
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
-	"sigs.k8s.io/yaml"
 )
 
-func LoadAndUnmarshalLagoonYml(lagoonYml string, lagoonYmlOverride string, lagoonYmlOverrideEnvVarName string, lYAML *lagoon.YAML, projectName string, debug bool) error {
+// LoadAndUnmarshalLagoonYml loads the .lagoon.yml, and merges in the override file and the override environment variable if
+// they exist. Any warnings found are recorded in the warnings collector, which can be nil.
+func LoadAndUnmarshalLagoonYml(lagoonYml string, lagoonYmlOverride string, lagoonYmlOverrideEnvVarName string, lYAML *lagoon.YAML, projectName string, debug bool, warnings *lagoon.Warnings) error {
 
 	// First we load the primary file
-	if err := lagoon.UnmarshalLagoonYAML(lagoonYml, lYAML, projectName); err != nil {
+	if err := lagoon.UnmarshalLagoonYAML(lagoonYml, lYAML, projectName, warnings); err != nil {
 		return fmt.Errorf("couldn't unmarshal file %v: %v", lagoonYml, err)
 	}
 
 	// Here we try and merge in .lagoon.yml override
 	if _, err := os.Stat(lagoonYmlOverride); err == nil {
-		overLagoonYaml := &lagoon.YAML{}
-		if err := lagoon.UnmarshalLagoonYAML(lagoonYmlOverride, overLagoonYaml, projectName); err != nil {
+		rawYAML, err := os.ReadFile(lagoonYmlOverride)
+		if err != nil {
+			return fmt.Errorf("couldn't read file %v: %v", lagoonYmlOverride, err)
+		}
+		if err := warnings.Collect(lagoonYmlOverride, rawYAML, projectName); err != nil {
 			return fmt.Errorf("couldn't unmarshal file %v: %v", lagoonYmlOverride, err)
 		}
 		//now we merge
-		if err := lagoon.MergeLagoonYAMLs(lYAML, overLagoonYaml); err != nil {
+		if err := lagoon.MergeLagoonYAMLOverride(lYAML, rawYAML, projectName); err != nil {
 			return fmt.Errorf("unable to merge %v over %v: %v", lagoonYmlOverride, lagoonYml, err)
 		}
 	}
@@ -36,26 +40,40 @@ func LoadAndUnmarshalLagoonYml(lagoonYml string, lagoonYmlOverride string, lagoo
 		if err != nil {
 			return fmt.Errorf("unable to decode %v - is it base64 encoded?", lagoonYmlOverrideEnvVarName)
 		}
-		envLagoonYaml := &lagoon.YAML{}
-		lEnvLagoonPolysite := make(map[string]interface{})
-
-		err = yaml.Unmarshal(envLagoonYamlString, envLagoonYaml)
-		if err != nil {
+		if err := warnings.Collect(lagoonYmlOverrideEnvVarName, envLagoonYamlString, projectName); err != nil {
 			return fmt.Errorf("unable to unmarshal env var %v: %v", lagoonYmlOverrideEnvVarName, err)
 		}
-		err = yaml.Unmarshal(envLagoonYamlString, lEnvLagoonPolysite)
-		if err != nil {
-			return fmt.Errorf("unable to unmarshal env var %v: %v", lagoonYmlOverrideEnvVarName, err)
-		}
-
-		if _, ok := lEnvLagoonPolysite[projectName]; ok {
-			s, _ := yaml.Marshal(lEnvLagoonPolysite[projectName])
-			_ = yaml.Unmarshal(s, &envLagoonYaml)
-		}
 		//now we merge
-		if err := lagoon.MergeLagoonYAMLs(lYAML, envLagoonYaml); err != nil {
+		if err := lagoon.MergeLagoonYAMLOverride(lYAML, envLagoonYamlString, projectName); err != nil {
 			return fmt.Errorf("unable to merge LAGOON_YAML_OVERRIDE over %v: %v", lagoonYml, err)
 		}
 	}
 	return nil
 }
+
+// lagoonEnvironment returns the environments block of the .lagoon.yml that applies to this build. The branch (or `pr-NUMBER`
+// for a pullrequest) and the environment name are both checked, and an exact match for either wins over any pattern.
+func lagoonEnvironment(buildValues BuildValues) (lagoon.Environment, error) {
+	environment, _, err := buildValues.LagoonYAML.Environments.Resolve(buildValues.Branch, buildValues.Environment)
+	if err != nil {
+		return lagoon.Environment{}, fmt.Errorf("couldn't resolve the environment in the .lagoon.yml: %v", err)
+	}
+	return environment, nil
+}
+
+// interpolationVariables returns the variables that can be used in the .lagoon.yml. These are the global, build and runtime
+// scoped variables, which includes the standard build variables from collectBuildVariables. The route variables aren't known
+// until the routes have been generated, so they can't be used.
+func interpolationVariables(envVars []lagoon.EnvironmentVariable) map[string]string {
+	variables := map[string]string{}
+	for _, envVar := range envVars {
+		if !helpers.Contains([]string{"global", "build", "runtime"}, envVar.Scope) {
+			continue
+		}
+		if helpers.Contains([]string{"LAGOON_ROUTE", "LAGOON_ROUTES", "LAGOON_AUTOGENERATED_ROUTES"}, envVar.Name) {
+			continue
+		}
+		variables[envVar.Name] = envVar.Value
+	}
+	return variables
+}
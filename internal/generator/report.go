@@ -0,0 +1,164 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// BuildReportVersion is the version of the build report format, it should be incremented if fields are removed or changed
+// in a way that consumers of the report would need to handle
+const BuildReportVersion = "v1"
+
+// the sources that a route can come from
+const (
+	RouteSourceAutogenerated    = "autogenerated"
+	RouteSourceLagoonYAML       = ".lagoon.yml"
+	RouteSourceAPI              = "api"
+	RouteSourceProductionRoutes = "production_routes"
+)
+
+// BuildReport is a summary of the decisions made by the generator for a build
+type BuildReport struct {
+	Version         string              `json:"version"`
+	Project         string              `json:"project"`
+	Environment     string              `json:"environment"`
+	EnvironmentType string              `json:"environmentType"`
+	Namespace       string              `json:"namespace"`
+	BuildType       string              `json:"buildType"`
+	BuildName       string              `json:"buildName,omitempty"`
+	LagoonVersion   string              `json:"lagoonVersion,omitempty"`
+	Services        []ServiceReport     `json:"services"`
+	Routes          []RouteReport       `json:"routes"`
+	FeatureFlags    []FeatureFlagResult `json:"featureFlags"`
+	Backups         BackupReport        `json:"backups"`
+	Warnings        lagoon.Warnings     `json:"warnings"`
+}
+
+// ServiceReport is the resolved type of a service
+type ServiceReport struct {
+	Name                string `json:"name"`
+	OverrideName        string `json:"overrideName"`
+	Type                string `json:"type"`
+	DBaaSEnvironment    string `json:"dbaasEnvironment,omitempty"`
+	DBaaSFallbackReason string `json:"dbaasFallbackReason,omitempty"`
+	UseSpotInstances    bool   `json:"useSpot"`
+	CronjobUseSpot      bool   `json:"cronjobUseSpot"`
+}
+
+// RouteReport is a route and where it was defined
+type RouteReport struct {
+	Domain           string   `json:"domain"`
+	Service          string   `json:"service"`
+	Source           string   `json:"source"`
+	AlternativeNames []string `json:"alternativeNames,omitempty"`
+}
+
+// BackupReport is the resolved backup schedules
+type BackupReport struct {
+	BackupSchedule string `json:"backupSchedule"`
+	CheckSchedule  string `json:"checkSchedule"`
+	PruneSchedule  string `json:"pruneSchedule"`
+}
+
+// NewBuildReport creates a build report from a generator
+func NewBuildReport(g *Generator) (BuildReport, error) {
+	bv := g.BuildValues
+	report := BuildReport{
+		Version:         BuildReportVersion,
+		Project:         bv.Project,
+		Environment:     bv.Environment,
+		EnvironmentType: bv.EnvironmentType,
+		Namespace:       bv.Namespace,
+		BuildType:       bv.BuildType,
+		BuildName:       bv.BuildName,
+		LagoonVersion:   bv.LagoonVersion,
+		Services:        []ServiceReport{},
+		Routes:          []RouteReport{},
+		FeatureFlags:    []FeatureFlagResult{},
+		Warnings:        lagoon.Warnings{},
+		Backups: BackupReport{
+			BackupSchedule: bv.Backup.BackupSchedule,
+			CheckSchedule:  bv.Backup.CheckSchedule,
+			PruneSchedule:  bv.Backup.PruneSchedule,
+		},
+	}
+	for _, service := range bv.Services {
+		report.Services = append(report.Services, ServiceReport{
+			Name:                service.Name,
+			OverrideName:        service.OverrideName,
+			Type:                service.Type,
+			DBaaSEnvironment:    dbaasEnvironmentForReport(service),
+			DBaaSFallbackReason: service.DBaaSFallbackReason,
+			UseSpotInstances:    service.UseSpotInstances,
+			CronjobUseSpot:      service.CronjobUseSpotInstances,
+		})
+	}
+	// the main routes are a merge of the .lagoon.yml and the api routes, the api takes precedence so any domain that is in the api
+	// routes is reported as coming from the api
+	apiRoutes, err := getRoutesFromAPIEnvVar(bv.EnvironmentVariables, false)
+	if err != nil {
+		return report, err
+	}
+	apiDomains := map[string]bool{}
+	for _, route := range apiRoutes.Routes {
+		apiDomains[route.Domain] = true
+	}
+	report.Routes = append(report.Routes, routesForReport(g.AutogeneratedRoutes, func(lagoon.RouteV2) string {
+		return RouteSourceAutogenerated
+	})...)
+	report.Routes = append(report.Routes, routesForReport(g.MainRoutes, func(route lagoon.RouteV2) string {
+		if apiDomains[route.Domain] {
+			return RouteSourceAPI
+		}
+		return RouteSourceLagoonYAML
+	})...)
+	report.Routes = append(report.Routes, routesForReport(g.ActiveStandbyRoutes, func(lagoon.RouteV2) string {
+		return RouteSourceProductionRoutes
+	})...)
+	report.FeatureFlags = append(report.FeatureFlags, bv.FeatureFlagResults...)
+	report.Warnings = append(report.Warnings, bv.LagoonYAMLWarnings...)
+	return report, nil
+}
+
+// WriteBuildReport writes the build report for a generator to the given file as json
+func WriteBuildReport(g *Generator, file string) error {
+	report, err := NewBuildReport(g)
+	if err != nil {
+		return fmt.Errorf("couldn't generate build report: %v", err)
+	}
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't marshal build report: %v", err)
+	}
+	if err := os.WriteFile(file, reportBytes, 0644); err != nil {
+		return fmt.Errorf("couldn't write build report %s: %v", file, err)
+	}
+	return nil
+}
+
+// the dbaas environment is only relevant for the services that went through the dbaas checks
+func dbaasEnvironmentForReport(service ServiceValues) string {
+	if service.IsDBaaS || service.IsSingle || service.DBaaSFallbackReason != "" {
+		return service.DBaaSEnvironment
+	}
+	return ""
+}
+
+func routesForReport(routes *lagoon.RoutesV2, source func(lagoon.RouteV2) string) []RouteReport {
+	reports := []RouteReport{}
+	if routes == nil {
+		return reports
+	}
+	for _, route := range routes.Routes {
+		reports = append(reports, RouteReport{
+			Domain:           route.Domain,
+			Service:          route.LagoonService,
+			Source:           source(route),
+			AlternativeNames: route.AlternativeNames,
+		})
+	}
+	return reports
+}
@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// serviceResourceOverrides returns the resource and probe overrides of a service. The `lagoon.resources.*` and `lagoon.probes.*`
+// labels are applied first, then the overrides for the service in the environment of the .lagoon.yml. The resources are checked
+// against the ceilings the administrator has set.
+func serviceResourceOverrides(
+	buildValues *BuildValues,
+	service string,
+	labels map[string]string,
+	override lagoon.Override,
+) (*lagoon.ResourceOverrides, *lagoon.ProbeOverrides, error) {
+	labelResources, err := lagoon.ResourceOverridesFromLabels(labels)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %s: %v", service, err)
+	}
+	labelProbes, err := lagoon.ProbeOverridesFromLabels(labels)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %s: %v", service, err)
+	}
+	resources := lagoon.MergeResourceOverrides(labelResources, override.Resources)
+	probes := lagoon.MergeProbeOverrides(labelProbes, override.Probes)
+	if err := validateResourceOverrides(buildValues.Resources, resources); err != nil {
+		return nil, nil, fmt.Errorf("service %s: %v", service, err)
+	}
+	if err := validateProbeOverrides(probes); err != nil {
+		return nil, nil, fmt.Errorf("service %s: %v", service, err)
+	}
+	return resources, probes, nil
+}
+
+// validateResourceOverrides checks that the requests and limits are quantities, that they don't exceed the ceilings, and that the
+// requests don't exceed the limits
+func validateResourceOverrides(defaults Resources, resources *lagoon.ResourceOverrides) error {
+	if resources == nil {
+		return nil
+	}
+	var requests, limits lagoon.ResourceList
+	if resources.Requests != nil {
+		requests = *resources.Requests
+	}
+	if resources.Limits != nil {
+		limits = *resources.Limits
+	}
+	quantities := map[string]*resource.Quantity{}
+	for _, r := range []struct {
+		name    string
+		value   string
+		ceiling string
+	}{
+		{name: "cpu request", value: requests.CPU, ceiling: defaults.Ceilings.CPU},
+		{name: "cpu limit", value: limits.CPU, ceiling: defaults.Ceilings.CPU},
+		{name: "memory request", value: requests.Memory, ceiling: defaults.Ceilings.Memory},
+		{name: "memory limit", value: limits.Memory, ceiling: defaults.Ceilings.Memory},
+	} {
+		if r.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(r.value)
+		if err != nil {
+			return fmt.Errorf("%s %s is not a valid resource quantity: %v", r.name, r.value, err)
+		}
+		if quantity.Sign() <= 0 {
+			return fmt.Errorf("%s %s must be greater than zero", r.name, r.value)
+		}
+		if r.ceiling != "" {
+			// the ceiling was already checked when the build values were generated
+			ceiling := resource.MustParse(r.ceiling)
+			if quantity.Cmp(ceiling) > 0 {
+				return fmt.Errorf("%s %s exceeds the maximum of %s allowed for this environment", r.name, r.value, r.ceiling)
+			}
+		}
+		quantities[r.name] = &quantity
+	}
+	// the memory limit set by the administrator is used if the service doesn't set one, the service can't raise it
+	if defaults.Limits.Memory != "" {
+		adminLimit := resource.MustParse(defaults.Limits.Memory)
+		if limit, ok := quantities["memory limit"]; ok && limit.Cmp(adminLimit) > 0 {
+			return fmt.Errorf("memory limit %s exceeds the limit of %s for this environment", limits.Memory, defaults.Limits.Memory)
+		}
+		if _, ok := quantities["memory limit"]; !ok {
+			quantities["memory limit"] = &adminLimit
+		}
+	}
+	for _, r := range []string{"cpu", "memory"} {
+		request, hasRequest := quantities[r+" request"]
+		limit, hasLimit := quantities[r+" limit"]
+		if hasRequest && hasLimit && request.Cmp(*limit) > 0 {
+			return fmt.Errorf("%s request %s exceeds the %s limit %s", r, request.String(), r, limit.String())
+		}
+	}
+	return nil
+}
+
+// validateProbeOverrides checks that the probe overrides are values that kubernetes will accept
+func validateProbeOverrides(probes *lagoon.ProbeOverrides) error {
+	if probes == nil {
+		return nil
+	}
+	for _, p := range []struct {
+		name  string
+		probe *lagoon.ProbeOverride
+	}{
+		{name: "readiness", probe: probes.Readiness},
+		{name: "liveness", probe: probes.Liveness},
+	} {
+		if p.probe == nil {
+			continue
+		}
+		if p.probe.InitialDelaySeconds != nil && *p.probe.InitialDelaySeconds < 0 {
+			return fmt.Errorf("%s probe initialDelaySeconds must not be negative", p.name)
+		}
+		for _, f := range []struct {
+			field string
+			value *int32
+		}{
+			{field: "periodSeconds", value: p.probe.PeriodSeconds},
+			{field: "timeoutSeconds", value: p.probe.TimeoutSeconds},
+			{field: "successThreshold", value: p.probe.SuccessThreshold},
+			{field: "failureThreshold", value: p.probe.FailureThreshold},
+		} {
+			if f.value != nil && *f.value < 1 {
+				return fmt.Errorf("%s probe %s must be at least 1", p.name, f.field)
+			}
+		}
+	}
+	// kubernetes requires the liveness probe success threshold to be 1
+	if probes.Liveness != nil && probes.Liveness.SuccessThreshold != nil && *probes.Liveness.SuccessThreshold != 1 {
+		return fmt.Errorf("liveness probe successThreshold must be 1")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package generator
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -12,7 +13,6 @@ import (
 	composetypes "github.com/compose-spec/compose-go/types"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
-	"github.com/uselagoon/build-deploy-tool/internal/servicetypes"
 )
 
 // this is a map that maps old service types to their new service types
@@ -24,7 +24,8 @@ var oldServiceMap = map[string]string{
 	"mongo":                 "mongodb",
 }
 
-// these are lagoon types that support autogenerated routes
+// these are lagoon types that support autogenerated routes, the service types from the service type definitions
+// define if they support autogenerated routes themselves
 var supportedAutogeneratedTypes = []string{
 	// "kibana", //@TODO: don't even need this anymore?
 	"basic",
@@ -57,7 +58,8 @@ var supportedDBTypes = []string{
 	"mongodb-dbaas",
 }
 
-// these are lagoon types that come with resources requiring backups
+// these are lagoon types that come with resources requiring backups, the service types from the service type definitions
+// define if they require backups themselves
 var typesWithBackups = []string{
 	"basic-persistent",
 	"node-persistent",
@@ -136,7 +138,8 @@ func generateServicesFromDockerCompose(
 			}
 		}
 	}
-	return nil
+	// services that share a deployment autoscale together
+	return checkLinkedAutoscaling(buildValues.Services)
 }
 
 // composeToServiceValues is the primary function used to pre-seed how templates are created
@@ -161,6 +164,10 @@ func composeToServiceValues(
 		)
 	} else {
 		// if the lagoontype is populated, even none is valid as there may be a servicetype override in an environment variable
+		environment, err := lagoonEnvironment(*buildValues)
+		if err != nil {
+			return nil, err
+		}
 		autogenEnabled := true
 		autogenTLSAcmeEnabled := true
 		autogeRequestVerification := false
@@ -179,8 +186,8 @@ func composeToServiceValues(
 			}
 		}
 		// check if this environment has autogenerated routes disabled
-		if buildValues.LagoonYAML.Environments[buildValues.Branch].AutogenerateRoutes != nil {
-			if !*buildValues.LagoonYAML.Environments[buildValues.Branch].AutogenerateRoutes {
+		if environment.AutogenerateRoutes != nil {
+			if !*environment.AutogenerateRoutes {
 				autogenEnabled = false
 			} else {
 				autogenEnabled = true
@@ -201,7 +208,7 @@ func composeToServiceValues(
 			}
 		}
 		// check lagoon yaml for an override for this service
-		if value, ok := buildValues.LagoonYAML.Environments[buildValues.Environment].Types[composeService]; ok {
+		if value, ok := environment.Types[composeService]; ok {
 			lagoonType = value
 		}
 		// check if the service has a specific override
@@ -253,7 +260,7 @@ func composeToServiceValues(
 		servicePersistentPath := lagoon.CheckDockerComposeLagoonLabel(composeServiceValues.Labels, "lagoon.persistent")
 		if servicePersistentPath == "" {
 			// if there is no persistent path, check if the service type has a default path
-			if val, ok := servicetypes.ServiceTypes[lagoonType]; ok {
+			if val, ok := buildValues.GetServiceType(lagoonType); ok {
 				servicePersistentPath = val.Volumes.PersistentVolumePath
 				// check if the service type provides or consumes a default persistent volume
 				if (val.ProvidesPersistentVolume || val.ConsumesPersistentVolume) && servicePersistentPath == "" {
@@ -270,7 +277,7 @@ func composeToServiceValues(
 		servicePersistentSize := lagoon.CheckDockerComposeLagoonLabel(composeServiceValues.Labels, "lagoon.persistent.size")
 		if servicePersistentSize == "" {
 			// if there is no persistent size, check if the service type has a default size allocated
-			if val, ok := servicetypes.ServiceTypes[lagoonType]; ok {
+			if val, ok := buildValues.GetServiceType(lagoonType); ok {
 				servicePersistentSize = val.Volumes.PersistentVolumeSize
 				// check if the service type provides persistent volume, and that a size was detected
 				if val.ProvidesPersistentVolume && servicePersistentSize == "" {
@@ -323,6 +330,7 @@ func composeToServiceValues(
 		dbaasEnvironment := buildValues.EnvironmentType
 		svcIsDBaaS := false
 		svcIsSingle := false
+		dbaasFallbackReason := ""
 		if helpers.Contains(supportedDBTypes, lagoonType) {
 			// strip the dbaas off the supplied type for checking against providers, it gets added again later
 			lagoonType = strings.Split(lagoonType, "-dbaas")[0]
@@ -340,6 +348,7 @@ func composeToServiceValues(
 				// the old bash check is the following
 				// elif [[ "${CAPABILITIES[@]}" =~ "mariadb.amazee.io/v1/MariaDBConsumer" ]] && ! checkDBaaSHealth ; then
 				lagoonType = fmt.Sprintf("%s-single", lagoonType)
+				dbaasFallbackReason = fmt.Sprintf("unable to check the DBaaS endpoint %s: %v", buildValues.DBaaSOperatorEndpoint, err)
 			} else {
 				// if there is a `lagoon.%s-dbaas.environment` label on this service, this should be used as an the environment type for the dbaas
 				dbaasLabelOverride := lagoon.CheckDockerComposeLagoonLabel(composeServiceValues.Labels, fmt.Sprintf("lagoon.%s-dbaas.environment", lagoonType))
@@ -367,6 +376,7 @@ func composeToServiceValues(
 							buildValues.DBaaSOperatorEndpoint, lagoonType, err,
 						)
 					}
+					dbaasFallbackReason = fmt.Sprintf("the DBaaS operator returned an error for %s in the %s environment: %v", lagoonType, dbaasEnvironment, errors.Unwrap(err))
 				}
 
 				// if the requested dbaas environment exists, then set the type to be the requested type with `-dbaas`
@@ -375,6 +385,9 @@ func composeToServiceValues(
 					svcIsDBaaS = true
 				} else {
 					// otherwise fallback to -single (if DBaaSFallbackSingle is enabled, otherwise it will error out prior)
+					if dbaasFallbackReason == "" {
+						dbaasFallbackReason = fmt.Sprintf("no DBaaS provider found for %s in the %s environment", lagoonType, dbaasEnvironment)
+					}
 					lagoonType = fmt.Sprintf("%s-single", lagoonType)
 					svcIsSingle = true
 				}
@@ -399,24 +412,24 @@ func composeToServiceValues(
 
 		// these services can support multiple replicas in production
 		// @TODO this should probably be an admin only feature flag though
-		prodSpotReplicaTypes := CheckAdminFeatureFlag("SPOT_TYPE_REPLICAS_PRODUCTION", debug)
+		prodSpotReplicaTypes := buildValues.checkAdminFeatureFlag("SPOT_TYPE_REPLICAS_PRODUCTION", debug)
 		if prodSpotReplicaTypes == "" {
 			prodSpotReplicaTypes = "nginx,nginx-persistent,nginx-php,nginx-php-persistent"
 		}
-		devSpotReplicaTypes := CheckAdminFeatureFlag("SPOT_TYPE_REPLICAS_DEVELOPMENT", debug)
+		devSpotReplicaTypes := buildValues.checkAdminFeatureFlag("SPOT_TYPE_REPLICAS_DEVELOPMENT", debug)
 		if devSpotReplicaTypes == "" {
 			devSpotReplicaTypes = ""
 		}
 
-		productionSpot := CheckFeatureFlag("SPOT_INSTANCE_PRODUCTION", buildValues.EnvironmentVariables, debug)
-		developmentSpot := CheckFeatureFlag("SPOT_INSTANCE_DEVELOPMENT", buildValues.EnvironmentVariables, debug)
+		productionSpot := buildValues.checkFeatureFlag("SPOT_INSTANCE_PRODUCTION", buildValues.EnvironmentVariables, debug)
+		developmentSpot := buildValues.checkFeatureFlag("SPOT_INSTANCE_DEVELOPMENT", buildValues.EnvironmentVariables, debug)
 		if productionSpot == "enabled" && buildValues.EnvironmentType == "production" {
-			spotTypes = CheckFeatureFlag("SPOT_INSTANCE_PRODUCTION_TYPES", buildValues.EnvironmentVariables, debug)
-			cronjobSpotTypes = CheckFeatureFlag("SPOT_INSTANCE_PRODUCTION_CRONJOB_TYPES", buildValues.EnvironmentVariables, debug)
+			spotTypes = buildValues.checkFeatureFlag("SPOT_INSTANCE_PRODUCTION_TYPES", buildValues.EnvironmentVariables, debug)
+			cronjobSpotTypes = buildValues.checkFeatureFlag("SPOT_INSTANCE_PRODUCTION_CRONJOB_TYPES", buildValues.EnvironmentVariables, debug)
 		}
 		if developmentSpot == "enabled" && buildValues.EnvironmentType == "development" {
-			spotTypes = CheckFeatureFlag("SPOT_INSTANCE_DEVELOPMENT_TYPES", buildValues.EnvironmentVariables, debug)
-			cronjobSpotTypes = CheckFeatureFlag("SPOT_INSTANCE_DEVELOPMENT_CRONJOB_TYPES", buildValues.EnvironmentVariables, debug)
+			spotTypes = buildValues.checkFeatureFlag("SPOT_INSTANCE_DEVELOPMENT_TYPES", buildValues.EnvironmentVariables, debug)
+			cronjobSpotTypes = buildValues.checkFeatureFlag("SPOT_INSTANCE_DEVELOPMENT_CRONJOB_TYPES", buildValues.EnvironmentVariables, debug)
 		}
 		// check if the provided spot instance types against the current lagoonType
 		for _, t := range strings.Split(spotTypes, ",") {
@@ -465,11 +478,11 @@ func composeToServiceValues(
 		inpodcronjobs := []lagoon.Cronjob{}
 		nativecronjobs := []lagoon.Cronjob{}
 		// check if there are any duplicate named cronjobs
-		if err := checkDuplicateCronjobs(buildValues.LagoonYAML.Environments[buildValues.Branch].Cronjobs); err != nil {
+		if err := checkDuplicateCronjobs(environment.Cronjobs); err != nil {
 			return nil, err
 		}
 		if !buildValues.CronjobsDisabled {
-			for _, cronjob := range buildValues.LagoonYAML.Environments[buildValues.Branch].Cronjobs {
+			for _, cronjob := range environment.Cronjobs {
 				// if this cronjob is meant for this service, add it
 				if cronjob.Service == composeService {
 					var err error
@@ -499,14 +512,15 @@ func composeToServiceValues(
 		}
 
 		// check if this service is one that supports autogenerated routes
-		if !helpers.Contains(supportedAutogeneratedTypes, lagoonType) {
+		serviceType, definedType := buildValues.ServiceTypeDefinitions[lagoonType]
+		if !helpers.Contains(supportedAutogeneratedTypes, lagoonType) && !(definedType && serviceType.AutogeneratedRoutes) {
 			autogenEnabled = false
 			autogenTLSAcmeEnabled = false
 		}
 
 		// check if this service is one that supports backups
 		backupsEnabled := false
-		if helpers.Contains(typesWithBackups, lagoonType) {
+		if helpers.Contains(typesWithBackups, lagoonType) || (definedType && serviceType.Backups) {
 			backupsEnabled = true
 
 		}
@@ -533,6 +547,7 @@ func composeToServiceValues(
 			PodSecurityContext:                     buildValues.PodSecurityContext,
 			IsDBaaS:                                svcIsDBaaS,
 			IsSingle:                               svcIsSingle,
+			DBaaSFallbackReason:                    dbaasFallbackReason,
 			BackupsEnabled:                         backupsEnabled,
 			AdditionalVolumes:                      serviceVolumes,
 		}
@@ -559,6 +574,29 @@ func composeToServiceValues(
 			}
 			cService.ServicePort = int32(sPort)
 		}
+
+		// check if the service has any resource or probe overrides, from its labels or the .lagoon.yml
+		resources, probes, err := serviceResourceOverrides(buildValues, composeService, composeServiceValues.Labels, environment.Overrides[composeService])
+		if err != nil {
+			return nil, err
+		}
+		cService.Resources = resources
+		cService.Probes = probes
+
+		// check if the service autoscales, the utilization targets are measured against the resource requests
+		autoscaling, err := serviceAutoscaling(buildValues, composeService, lagoonType, composeServiceValues.Labels, environment.Overrides[composeService], resources, debug)
+		if err != nil {
+			return nil, err
+		}
+		cService.Autoscaling = autoscaling
+
+		// check if the service has any additional containers or init containers
+		containers, initContainers, err := serviceContainers(buildValues, composeService, environment.Overrides[composeService])
+		if err != nil {
+			return nil, err
+		}
+		cService.Containers = containers
+		cService.InitContainers = initContainers
 		useComposeServices := lagoon.CheckDockerComposeLagoonLabel(composeServiceValues.Labels, "lagoon.service.usecomposeports")
 		if useComposeServices == "true" {
 			for _, compPort := range composeServiceValues.Ports {
@@ -573,3 +611,14 @@ func composeToServiceValues(
 		return cService, nil
 	}
 }
+
+// isReservedServiceType checks if a service type from the service type definitions would clash with a type that the generator
+// handles itself, like the dbaas types
+func isReservedServiceType(name string) bool {
+	if _, ok := oldServiceMap[name]; ok {
+		return true
+	}
+	return name == "none" ||
+		helpers.Contains(supportedDBTypes, name) ||
+		helpers.Contains(ignoredImageTypes, name)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/uselagoon/build-deploy-tool/internal/dbaasclient"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	"github.com/uselagoon/build-deploy-tool/internal/servicetypes"
 )
 
 func Test_composeToServiceValues(t *testing.T) {
@@ -565,8 +566,9 @@ func Test_composeToServiceValues(t *testing.T) {
 					PullImage:  "uselagoon/fake-mariadb:latest",
 					BuildImage: "harbor.example/example-project/main/mariadb:latest",
 				},
-				BackupsEnabled: true,
-				IsSingle:       true,
+				BackupsEnabled:      true,
+				IsSingle:            true,
+				DBaaSFallbackReason: "the DBaaS operator returned an error for mariadb in the development2 environment: no providers for dbaas environment development2",
 			},
 		},
 		{
@@ -1236,6 +1238,306 @@ func Test_composeToServiceValues(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "test24 - environment pattern",
+			args: args{
+				buildValues: &BuildValues{
+					Namespace:            "example-project-feature-login",
+					Project:              "example-project",
+					ImageRegistry:        "harbor.example",
+					Environment:          "feature-login",
+					Branch:               "feature/login",
+					BuildType:            "branch",
+					ServiceTypeOverrides: &lagoon.EnvironmentVariable{},
+					LagoonYAML: lagoon.YAML{
+						Environments: lagoon.Environments{
+							"main": lagoon.Environment{
+								Types: map[string]string{
+									"redis": "none",
+								},
+							},
+							"feature/*": lagoon.Environment{
+								Types: map[string]string{
+									"redis": "redis-persistent",
+								},
+								Cronjobs: []lagoon.Cronjob{
+									{
+										Name:     "My Cronjob",
+										Command:  "env",
+										Service:  "redis",
+										Schedule: "5 2 * * *",
+									},
+								},
+							},
+							"/^feature/": lagoon.Environment{
+								Types: map[string]string{
+									"redis": "none",
+								},
+							},
+						},
+					},
+				},
+				composeService: "redis",
+				composeServiceValues: composetypes.ServiceConfig{
+					Labels: composetypes.Labels{
+						"lagoon.type": "redis",
+					},
+					Image: "uselagoon/fake-redis:7",
+				},
+			},
+			want: &ServiceValues{
+				Name:          "redis",
+				OverrideName:  "redis",
+				Type:          "redis-persistent",
+				InPodCronjobs: []lagoon.Cronjob{},
+				NativeCronjobs: []lagoon.Cronjob{
+					{
+						Name:     "cronjob-redis-my-cronjob",
+						Service:  "redis",
+						Schedule: "5 2 * * *",
+						Command:  "env",
+					},
+				},
+				ImageBuild: &ImageBuild{
+					PullImage:  "uselagoon/fake-redis:7",
+					BuildImage: "harbor.example/example-project/feature-login/redis:latest",
+				},
+				PersistentVolumePath: "/data",
+				PersistentVolumeName: "redis",
+				PersistentVolumeSize: "5Gi",
+				BackupsEnabled:       true,
+			},
+		},
+		{
+			name: "test25 - invalid environment pattern",
+			args: args{
+				buildValues: &BuildValues{
+					Namespace:            "example-project-main",
+					Project:              "example-project",
+					ImageRegistry:        "harbor.example",
+					Environment:          "main",
+					Branch:               "main",
+					BuildType:            "branch",
+					ServiceTypeOverrides: &lagoon.EnvironmentVariable{},
+					LagoonYAML: lagoon.YAML{
+						Environments: lagoon.Environments{
+							"/release-(/": lagoon.Environment{},
+						},
+					},
+				},
+				composeService: "redis",
+				composeServiceValues: composetypes.ServiceConfig{
+					Labels: composetypes.Labels{
+						"lagoon.type": "redis",
+					},
+					Image: "uselagoon/fake-redis:7",
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "test26 - service type from the service type definitions",
+			args: args{
+				buildValues: &BuildValues{
+					Namespace:            "example-project-main",
+					Project:              "example-project",
+					ImageRegistry:        "harbor.example",
+					Environment:          "main",
+					Branch:               "main",
+					BuildType:            "branch",
+					ServiceTypeOverrides: &lagoon.EnvironmentVariable{},
+					ServiceTypeDefinitions: map[string]servicetypes.ServiceType{
+						"memcached": {
+							Name:                     "memcached",
+							ProvidesPersistentVolume: true,
+							Volumes: servicetypes.ServiceVolume{
+								PersistentVolumeSize: "1Gi",
+								PersistentVolumePath: "/var/lib/memcached",
+							},
+							AutogeneratedRoutes: true,
+							Backups:             true,
+						},
+					},
+					LagoonYAML: lagoon.YAML{
+						Environments: lagoon.Environments{
+							"main": lagoon.Environment{},
+						},
+					},
+				},
+				composeService: "memcached",
+				composeServiceValues: composetypes.ServiceConfig{
+					Labels: composetypes.Labels{
+						"lagoon.type": "memcached",
+					},
+					Image: "memcached:1.6",
+				},
+			},
+			want: &ServiceValues{
+				Name:                       "memcached",
+				OverrideName:               "memcached",
+				Type:                       "memcached",
+				AutogeneratedRoutesEnabled: true,
+				AutogeneratedRoutesTLSAcme: true,
+				InPodCronjobs:              []lagoon.Cronjob{},
+				NativeCronjobs:             []lagoon.Cronjob{},
+				ImageBuild: &ImageBuild{
+					PullImage:  "library/memcached:1.6",
+					BuildImage: "harbor.example/example-project/main/memcached:latest",
+				},
+				PersistentVolumePath: "/var/lib/memcached",
+				PersistentVolumeName: "memcached",
+				PersistentVolumeSize: "1Gi",
+				BackupsEnabled:       true,
+			},
+		},
+		{
+			name: "test27 - resource and probe overrides from labels and lagoon.yml",
+			args: args{
+				buildValues: &BuildValues{
+					Namespace:            "example-project-main",
+					Project:              "example-project",
+					ImageRegistry:        "harbor.example",
+					Environment:          "main",
+					Branch:               "main",
+					BuildType:            "branch",
+					ServiceTypeOverrides: &lagoon.EnvironmentVariable{},
+					Resources: Resources{
+						Ceilings: ResourceCeilings{
+							CPU:    "2",
+							Memory: "4Gi",
+						},
+					},
+					LagoonYAML: lagoon.YAML{
+						Environments: lagoon.Environments{
+							"main": lagoon.Environment{
+								Overrides: map[string]lagoon.Override{
+									"node": {
+										Resources: &lagoon.ResourceOverrides{
+											Requests: &lagoon.ResourceList{
+												Memory: "512Mi",
+											},
+										},
+										Probes: &lagoon.ProbeOverrides{
+											Liveness: &lagoon.ProbeOverride{
+												FailureThreshold: helpers.Int32Ptr(10),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				composeService: "node",
+				composeServiceValues: composetypes.ServiceConfig{
+					Labels: composetypes.Labels{
+						"lagoon.type":                                 "node",
+						"lagoon.resources.requests.cpu":               "100m",
+						"lagoon.resources.requests.memory":            "256Mi",
+						"lagoon.resources.limits.memory":              "1Gi",
+						"lagoon.probes.readiness.initialDelaySeconds": "30",
+					},
+					Image: "uselagoon/node-20:latest",
+				},
+			},
+			want: &ServiceValues{
+				Name:                       "node",
+				OverrideName:               "node",
+				Type:                       "node",
+				AutogeneratedRoutesEnabled: true,
+				AutogeneratedRoutesTLSAcme: true,
+				InPodCronjobs:              []lagoon.Cronjob{},
+				NativeCronjobs:             []lagoon.Cronjob{},
+				ImageBuild: &ImageBuild{
+					PullImage:  "uselagoon/node-20:latest",
+					BuildImage: "harbor.example/example-project/main/node:latest",
+				},
+				Resources: &lagoon.ResourceOverrides{
+					Requests: &lagoon.ResourceList{
+						CPU:    "100m",
+						Memory: "512Mi",
+					},
+					Limits: &lagoon.ResourceList{
+						Memory: "1Gi",
+					},
+				},
+				Probes: &lagoon.ProbeOverrides{
+					Readiness: &lagoon.ProbeOverride{
+						InitialDelaySeconds: helpers.Int32Ptr(30),
+					},
+					Liveness: &lagoon.ProbeOverride{
+						FailureThreshold: helpers.Int32Ptr(10),
+					},
+				},
+			},
+		},
+		{
+			name: "test28 - resource override exceeds the ceiling",
+			args: args{
+				buildValues: &BuildValues{
+					Namespace:            "example-project-main",
+					Project:              "example-project",
+					ImageRegistry:        "harbor.example",
+					Environment:          "main",
+					Branch:               "main",
+					BuildType:            "branch",
+					ServiceTypeOverrides: &lagoon.EnvironmentVariable{},
+					Resources: Resources{
+						Ceilings: ResourceCeilings{
+							Memory: "1Gi",
+						},
+					},
+					LagoonYAML: lagoon.YAML{
+						Environments: lagoon.Environments{
+							"main": lagoon.Environment{},
+						},
+					},
+				},
+				composeService: "node",
+				composeServiceValues: composetypes.ServiceConfig{
+					Labels: composetypes.Labels{
+						"lagoon.type":                    "node",
+						"lagoon.resources.limits.memory": "2Gi",
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "test29 - resource request exceeds the memory limit set by the administrator",
+			args: args{
+				buildValues: &BuildValues{
+					Namespace:            "example-project-main",
+					Project:              "example-project",
+					ImageRegistry:        "harbor.example",
+					Environment:          "main",
+					Branch:               "main",
+					BuildType:            "branch",
+					ServiceTypeOverrides: &lagoon.EnvironmentVariable{},
+					Resources: Resources{
+						Limits: ResourceLimits{
+							Memory: "1Gi",
+						},
+					},
+					LagoonYAML: lagoon.YAML{
+						Environments: lagoon.Environments{
+							"main": lagoon.Environment{},
+						},
+					},
+				},
+				composeService: "node",
+				composeServiceValues: composetypes.ServiceConfig{
+					Labels: composetypes.Labels{
+						"lagoon.type":                      "node",
+						"lagoon.resources.requests.memory": "2Gi",
+					},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
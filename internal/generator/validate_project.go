@@ -0,0 +1,270 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+// ProjectError is a reference in the .lagoon.yml that doesn't match the docker-compose file
+type ProjectError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ProjectError) String() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// projectService is a docker-compose service and the names that it can be referenced by in the .lagoon.yml
+type projectService struct {
+	name         string
+	overrideName string
+	lagoonType   string
+	ports        []string
+}
+
+type projectValidator struct {
+	lagoonYAML *lagoon.YAML
+	services   []projectService
+	errors     []ProjectError
+}
+
+// ValidateProject loads the .lagoon.yml and docker-compose file the same way that the generator does, and checks that every
+// service referenced in the .lagoon.yml exists in the docker-compose file. All errors are returned, rather than only the first.
+func ValidateProject(generator GeneratorInput) ([]ProjectError, error) {
+	projectName := helpers.GetEnv("PROJECT", generator.ProjectName, generator.Debug)
+	projectVariables := helpers.GetEnv("LAGOON_PROJECT_VARIABLES", generator.ProjectVariables, generator.Debug)
+	environmentVariables := helpers.GetEnv("LAGOON_ENVIRONMENT_VARIABLES", generator.EnvironmentVariables, generator.Debug)
+
+	lYAML := &lagoon.YAML{}
+	if err := LoadAndUnmarshalLagoonYml(generator.LagoonYAML, generator.LagoonYAMLOverride, "LAGOON_YAML_OVERRIDE", lYAML, projectName, generator.Debug, nil); err != nil {
+		return nil, err
+	}
+
+	// the docker-compose file is interpolated with the project and environment variables the same as the generator
+	projectVars := []lagoon.EnvironmentVariable{}
+	envVars := []lagoon.EnvironmentVariable{}
+	json.Unmarshal([]byte(projectVariables), &projectVars)
+	json.Unmarshal([]byte(environmentVariables), &envVars)
+	composeVars := make(map[string]string)
+	for _, envvar := range lagoon.MergeVariables(projectVars, envVars) {
+		composeVars[envvar.Name] = envvar.Value
+	}
+	lCompose, lComposeOrder, _, err := lagoon.UnmarshaDockerComposeYAML(
+		lYAML.DockerComposeYAML,
+		generator.IgnoreNonStringKeyErrors,
+		generator.IgnoreMissingEnvFiles,
+		composeVars,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &projectValidator{lagoonYAML: lYAML, errors: []ProjectError{}}
+	for _, service := range lComposeOrder {
+		for _, composeServiceValues := range lCompose.Services {
+			if service.Name != composeServiceValues.Name {
+				continue
+			}
+			ps := projectService{name: composeServiceValues.Name, overrideName: composeServiceValues.Name}
+			if composeServiceValues.Labels != nil {
+				ps.lagoonType = lagoon.CheckDockerComposeLagoonLabel(composeServiceValues.Labels, "lagoon.type")
+				if name := lagoon.CheckDockerComposeLagoonLabel(composeServiceValues.Labels, "lagoon.name"); name != "" {
+					ps.overrideName = name
+				}
+				if lagoon.CheckDockerComposeLagoonLabel(composeServiceValues.Labels, "lagoon.service.usecomposeports") == "true" {
+					for _, port := range composeServiceValues.Ports {
+						ps.ports = append(ps.ports, fmt.Sprintf("%s-%d", ps.name, port.Target))
+					}
+				}
+			}
+			if ps.lagoonType == "" {
+				v.add([]string{"docker-compose", ps.name}, "no lagoon.type has been set for service %s", ps.name)
+			}
+			v.services = append(v.services, ps)
+		}
+	}
+	v.validate()
+	return v.errors, nil
+}
+
+func (v *projectValidator) add(path []string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ProjectError{
+		Path:    lagoon.FormatPath(path),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// service returns the service that matches the name, this is the same matching that checkServiceInServices does
+func (v *projectValidator) service(name string) (projectService, bool) {
+	for _, s := range v.services {
+		if s.name == name {
+			return s, true
+		}
+		for _, port := range s.ports {
+			if port == name {
+				return s, true
+			}
+		}
+	}
+	return projectService{}, false
+}
+
+// serviceType returns the lagoon type of the service in an environment, including the .lagoon.yml type overrides
+func (v *projectValidator) serviceType(environment string, s projectService) string {
+	lagoonType := s.lagoonType
+	if value, ok := v.lagoonYAML.Environments[environment].Types[s.name]; ok {
+		lagoonType = value
+	}
+	if val, ok := oldServiceMap[lagoonType]; ok {
+		lagoonType = val
+	}
+	return lagoonType
+}
+
+// checkService adds an error if the service doesn't exist, or if the service has the type none in the environment
+func (v *projectValidator) checkService(path []string, environment, name, kind string) {
+	if name == "" {
+		v.add(path, "%s has no service defined", kind)
+		return
+	}
+	s, ok := v.service(name)
+	if !ok {
+		v.add(path, "%s references the service %s that is not defined in the docker-compose file", kind, name)
+		return
+	}
+	if environment != "" && v.serviceType(environment, s) == "none" {
+		v.add(path, "%s references the service %s that has the type none in the %s environment", kind, name, environment)
+	}
+}
+
+func (v *projectValidator) validate() {
+	environments := []string{}
+	for name := range v.lagoonYAML.Environments {
+		environments = append(environments, name)
+	}
+	sort.Strings(environments)
+	for _, name := range environments {
+		environment := v.lagoonYAML.Environments[name]
+		path := []string{"environments", name}
+		types := []string{}
+		for service := range environment.Types {
+			types = append(types, service)
+		}
+		sort.Strings(types)
+		for _, service := range types {
+			if _, ok := v.service(service); !ok {
+				v.add(append(append([]string{}, path...), "types", service),
+					"type override references the service %s that is not defined in the docker-compose file", service)
+			}
+		}
+		overrides := []string{}
+		for service := range environment.Overrides {
+			overrides = append(overrides, service)
+		}
+		sort.Strings(overrides)
+		for _, service := range overrides {
+			if _, ok := v.service(service); !ok {
+				v.add(append(append([]string{}, path...), "overrides", service),
+					"override references the service %s that is not defined in the docker-compose file", service)
+			}
+			// the additional containers of a service can use the image built for another service
+			override := environment.Overrides[service]
+			for _, oc := range []struct {
+				field      string
+				containers []lagoon.Container
+			}{
+				{field: "containers", containers: override.Containers},
+				{field: "initContainers", containers: override.InitContainers},
+			} {
+				for idx, container := range oc.containers {
+					if container.Service != "" {
+						v.checkService(append(append([]string{}, path...), "overrides", service, oc.field, strconv.Itoa(idx), "service"), name,
+							container.Service, fmt.Sprintf("container %s", container.Name))
+					}
+				}
+			}
+		}
+		v.routes(append(append([]string{}, path...), "routes"), name, environment.Routes)
+		v.pathRoutes(append(append([]string{}, path...), "autogeneratePathRoutes"), name, environment.AutogeneratePathRoutes)
+		for idx, cronjob := range environment.Cronjobs {
+			v.checkService(append(append([]string{}, path...), "cronjobs", strconv.Itoa(idx), "service"), name, cronjob.Service,
+				fmt.Sprintf("cronjob %s", cronjob.Name))
+		}
+	}
+	v.pathRoutes([]string{"routes", "autogenerate", "pathRoutes"}, "", v.lagoonYAML.Routes.Autogenerate.PathRoutes)
+	if v.lagoonYAML.ProductionRoutes != nil {
+		if v.lagoonYAML.ProductionRoutes.Active != nil {
+			v.routes([]string{"production_routes", "active", "routes"}, "", v.lagoonYAML.ProductionRoutes.Active.Routes)
+		}
+		if v.lagoonYAML.ProductionRoutes.Standby != nil {
+			v.routes([]string{"production_routes", "standby", "routes"}, "", v.lagoonYAML.ProductionRoutes.Standby.Routes)
+		}
+	}
+	for idx, task := range v.lagoonYAML.Tasks.Prerollout {
+		v.task([]string{"tasks", "pre-rollout", strconv.Itoa(idx), "run", "service"}, task.Run)
+	}
+	for idx, task := range v.lagoonYAML.Tasks.Postrollout {
+		v.task([]string{"tasks", "post-rollout", strconv.Itoa(idx), "run", "service"}, task.Run)
+	}
+}
+
+func (v *projectValidator) routes(path []string, environment string, routes []map[string][]lagoon.Route) {
+	for idx, routeMap := range routes {
+		services := []string{}
+		for service := range routeMap {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		for _, service := range services {
+			servicePath := append(append([]string{}, path...), strconv.Itoa(idx), service)
+			v.checkService(servicePath, environment, service, "route")
+			for ridx, route := range routeMap[service] {
+				domains := []string{}
+				for domain := range route.Ingresses {
+					domains = append(domains, domain)
+				}
+				sort.Strings(domains)
+				for _, domain := range domains {
+					for pidx, pr := range route.Ingresses[domain].PathRoutes {
+						prPath := append(append([]string{}, servicePath...), strconv.Itoa(ridx), domain, "pathRoutes", strconv.Itoa(pidx))
+						if pr.Path == "" {
+							v.add(prPath, "path route for %s has no path defined", domain)
+						}
+						v.checkService(append(prPath, "toService"), environment, pr.ToService, fmt.Sprintf("path route for %s", domain))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (v *projectValidator) pathRoutes(path []string, environment string, pathRoutes []lagoon.AutogeneratePathRoute) {
+	for idx, pr := range pathRoutes {
+		prPath := append(append([]string{}, path...), strconv.Itoa(idx))
+		if pr.Path == "" {
+			v.add(prPath, "autogenerated path route has no path defined")
+		}
+		v.checkService(append(append([]string{}, prPath...), "fromService"), environment, pr.FromService, "autogenerated path route")
+		v.checkService(append(append([]string{}, prPath...), "toService"), environment, pr.ToService, "autogenerated path route")
+	}
+}
+
+func (v *projectValidator) task(path []string, task lagoon.Task) {
+	if task.Service == "" {
+		v.add(path, "task %s has no service defined", task.Name)
+		return
+	}
+	// tasks find the deployment by the lagoon.sh/service label, which is the lagoon.name override if there is one
+	for _, s := range v.services {
+		if s.name == task.Service || s.overrideName == task.Service {
+			return
+		}
+	}
+	v.add(path, "task %s references the service %s that is not defined in the docker-compose file", task.Name, task.Service)
+}
@@ -5,7 +5,6 @@ import (
 
 	composetypes "github.com/compose-spec/compose-go/types"
 	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
-	"github.com/uselagoon/build-deploy-tool/internal/servicetypes"
 )
 
 var (
@@ -96,7 +95,7 @@ func composeToVolumeValues(
 // are to be attached to this service or not
 func calculateServiceVolumes(buildValues *BuildValues, lagoonType, servicePersistentName string, serviceLabels composetypes.Labels) ([]ServiceVolume, error) {
 	serviceVolumes := []ServiceVolume{}
-	if val, ok := servicetypes.ServiceTypes[lagoonType]; ok {
+	if val, ok := buildValues.GetServiceType(lagoonType); ok {
 		for i := 0; i < len(buildValues.Volumes); i++ {
 			vol := &buildValues.Volumes[i]
 			volName := lagoon.GetVolumeNameFromLagoonVolume(vol.Name)
@@ -0,0 +1,149 @@
+package lagoon
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// The keys of the environments block are normally the exact name of a branch or environment, but they can also be a
+// pattern that matches many environments
+//   - a glob, like `feature/*` or `pr-*`, that is matched with path.Match, so `*` doesn't match a `/`
+//   - a regular expression wrapped in slashes, like `/^release-.*/`
+//
+// An exact key always wins over a pattern. When more than one pattern matches, the most specific pattern wins: globs are
+// more specific than regular expressions, then the pattern with the most literal characters (for a regular expression
+// the literal prefix that every match must start with) wins, and finally the keys are compared so the result is stable.
+
+// the kinds of environment keys
+const (
+	environmentKeyExact = iota
+	environmentKeyGlob
+	environmentKeyRegex
+)
+
+type environmentKey struct {
+	key      string
+	kind     int
+	literals int
+	regex    *regexp.Regexp
+}
+
+// parseEnvironmentKey works out the kind of an environment key and how specific it is
+func parseEnvironmentKey(key string) (environmentKey, error) {
+	if len(key) > 2 && strings.HasPrefix(key, "/") && strings.HasSuffix(key, "/") {
+		expr := key[1 : len(key)-1]
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return environmentKey{}, fmt.Errorf("couldn't compile the environment pattern %s: %v", key, err)
+		}
+		// the literal prefix isn't calculated for every expression that is anchored to the start, so use the expression
+		// without the anchor to calculate it, removing the anchor can leave an expression that doesn't compile, like `*main`
+		// from `^*main`, which has no literal prefix
+		literals := 0
+		if unanchored, err := regexp.Compile(strings.TrimPrefix(expr, "^")); err == nil {
+			prefix, _ := unanchored.LiteralPrefix()
+			literals = len(prefix)
+		}
+		return environmentKey{key: key, kind: environmentKeyRegex, literals: literals, regex: regex}, nil
+	}
+	if strings.ContainsAny(key, "*?[") {
+		if _, err := path.Match(key, ""); err != nil {
+			return environmentKey{}, fmt.Errorf("couldn't parse the environment pattern %s: %v", key, err)
+		}
+		return environmentKey{key: key, kind: environmentKeyGlob, literals: globLiterals(key)}, nil
+	}
+	return environmentKey{key: key, kind: environmentKeyExact, literals: len(key)}, nil
+}
+
+// globLiterals counts the characters of a glob that aren't wildcards or character classes
+func globLiterals(glob string) int {
+	literals := 0
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*', '?':
+		case '[':
+			// skip to the end of the character class, path.Match has already checked that it is closed
+			for i++; i < len(glob) && glob[i] != ']'; i++ {
+				if glob[i] == '\\' {
+					i++
+				}
+			}
+		case '\\':
+			i++
+			literals++
+		default:
+			literals++
+		}
+	}
+	return literals
+}
+
+// matches checks if a pattern key matches any of the names
+func (k environmentKey) matches(names []string) bool {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		switch k.kind {
+		case environmentKeyGlob:
+			if match, _ := path.Match(k.key, name); match {
+				return true
+			}
+		case environmentKeyRegex:
+			if k.regex.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// moreSpecific checks if the key is more specific than another key
+func (k environmentKey) moreSpecific(other environmentKey) bool {
+	if k.kind != other.kind {
+		return k.kind < other.kind
+	}
+	if k.literals != other.literals {
+		return k.literals > other.literals
+	}
+	return k.key < other.key
+}
+
+// ValidateEnvironmentKey checks that an environment key is a valid pattern, exact keys are always valid
+func ValidateEnvironmentKey(key string) error {
+	_, err := parseEnvironmentKey(key)
+	return err
+}
+
+// Resolve returns the environment that applies to a build, and the key it was found with. The names are the names the
+// environment is known by, the branch (or `pr-NUMBER` for a pullrequest) and the environment name, and an exact match for
+// any of them wins over a pattern. If nothing matches an empty environment is returned.
+func (e Environments) Resolve(names ...string) (Environment, string, error) {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if environment, ok := e[name]; ok {
+			return environment, name, nil
+		}
+	}
+	var best *environmentKey
+	for key := range e {
+		k, err := parseEnvironmentKey(key)
+		if err != nil {
+			return Environment{}, "", err
+		}
+		if k.kind == environmentKeyExact || !k.matches(names) {
+			continue
+		}
+		if best == nil || k.moreSpecific(*best) {
+			best = &k
+		}
+	}
+	if best == nil {
+		return Environment{}, "", nil
+	}
+	return e[best.key], best.key, nil
+}
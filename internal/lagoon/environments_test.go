@@ -0,0 +1,153 @@
+package lagoon
+
+import (
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+)
+
+func TestEnvironmentsResolve(t *testing.T) {
+	environments := Environments{
+		"main":            {AutogenerateRoutes: helpers.BoolPtr(true)},
+		"feature-exact":   {AutogenerateRoutes: helpers.BoolPtr(true)},
+		"feature/*":       {},
+		"feature/login-*": {},
+		"pr-*":            {},
+		"pr-1?":           {},
+		"/^release-.*/":   {},
+		"/^release-2\\./": {},
+		"/.*-hotfix$/":    {},
+		"*-hotfix":        {},
+	}
+	tests := []struct {
+		name         string
+		environments Environments
+		names        []string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "test1 - exact branch",
+			environments: environments,
+			names:        []string{"main", "main"},
+			want:         "main",
+		},
+		{
+			name:         "test2 - exact environment name wins over a pattern that matches the branch",
+			environments: environments,
+			names:        []string{"feature/exact", "feature-exact"},
+			want:         "feature-exact",
+		},
+		{
+			name:         "test3 - glob",
+			environments: environments,
+			names:        []string{"feature/search", "feature-search"},
+			want:         "feature/*",
+		},
+		{
+			name:         "test4 - the glob with the most literal characters wins",
+			environments: environments,
+			names:        []string{"feature/login-sso", "feature-login-sso"},
+			want:         "feature/login-*",
+		},
+		{
+			name:         "test5 - glob wildcards don't match a slash",
+			environments: environments,
+			names:        []string{"feature/login/sso", "feature-login-sso"},
+			want:         "",
+		},
+		{
+			name:         "test6 - pullrequest",
+			environments: environments,
+			names:        []string{"pr-123", "pr-123"},
+			want:         "pr-*",
+		},
+		{
+			name:         "test7 - single character wildcard",
+			environments: environments,
+			names:        []string{"pr-12", "pr-12"},
+			want:         "pr-1?",
+		},
+		{
+			name:         "test8 - regular expression",
+			environments: environments,
+			names:        []string{"release-1.0", "release-1-0"},
+			want:         "/^release-.*/",
+		},
+		{
+			name:         "test9 - the regular expression with the longest literal prefix wins",
+			environments: environments,
+			names:        []string{"release-2.1", "release-2-1"},
+			want:         "/^release-2\\./",
+		},
+		{
+			name:         "test10 - glob wins over a regular expression",
+			environments: environments,
+			names:        []string{"login-hotfix", "login-hotfix"},
+			want:         "*-hotfix",
+		},
+		{
+			name:         "test11 - no match",
+			environments: environments,
+			names:        []string{"develop", "develop"},
+			want:         "",
+		},
+		{
+			name: "test12 - equally specific patterns are ordered by key",
+			environments: Environments{
+				"dev-?": {},
+				"dev-*": {},
+			},
+			names: []string{"dev-1", "dev-1"},
+			want:  "dev-*",
+		},
+		{
+			name: "test13 - invalid regular expression",
+			environments: Environments{
+				"/release-(/": {},
+			},
+			names:   []string{"develop", "develop"},
+			wantErr: true,
+		},
+		{
+			name: "test14 - invalid glob",
+			environments: Environments{
+				"feature/[": {},
+			},
+			names:   []string{"develop", "develop"},
+			wantErr: true,
+		},
+		{
+			name: "test15 - an exact match doesn't check the patterns",
+			environments: Environments{
+				"develop":     {},
+				"/release-(/": {},
+			},
+			names: []string{"develop", "develop"},
+			want:  "develop",
+		},
+		{
+			name: "test16 - a regular expression that doesn't compile without its anchor",
+			environments: Environments{
+				"/^*main/": {},
+			},
+			names: []string{"main", "main"},
+			want:  "/^*main/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, key, err := tt.environments.Resolve(tt.names...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if key != tt.want {
+				t.Errorf("Resolve() key = %v, want %v", key, tt.want)
+			}
+			if key != "" && got.AutogenerateRoutes != tt.environments[key].AutogenerateRoutes {
+				t.Errorf("Resolve() didn't return the environment for %v", key)
+			}
+		})
+	}
+}
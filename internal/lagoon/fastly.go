@@ -13,6 +13,18 @@ type Fastly struct {
 	Watch         bool   `json:"watch,omitempty"`
 }
 
+// FastlyConfiguration is the fastly configuration of a project in the .lagoon.yml
+type FastlyConfiguration struct {
+	APISecrets []FastlyAPISecret `json:"api-secrets,omitempty"`
+}
+
+// FastlyAPISecret is a fastly api token from a Lagoon variable, that a route can use with its api-secret-name
+type FastlyAPISecret struct {
+	Name                     string `json:"name"`
+	APITokenVariableName     string `json:"apiTokenVariableName"`
+	PlatformTLSConfiguration string `json:"platformTLSConfiguration,omitempty"`
+}
+
 // GenerateFastlyConfiguration generates the fastly configuration for a specific route from Lagoon variables.
 func GenerateFastlyConfiguration(f *Fastly, noCacheServiceID, serviceID, route, secretPrefix string, variables []EnvironmentVariable) error {
 	f.ServiceID = serviceID
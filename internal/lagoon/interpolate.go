@@ -0,0 +1,154 @@
+package lagoon
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/drone/envsubst"
+	"github.com/drone/envsubst/parse"
+)
+
+// The string fields of the .lagoon.yml that support `${VARIABLE}` interpolation are
+//   - route domains and alternativenames, in the environments and production_routes blocks
+//   - cronjob commands
+//   - pre-rollout and post-rollout task commands
+//
+// Interpolation uses the same engine as the image overrides (drone/envsubst), so the shell style functions like
+// `${VARIABLE:-default}` work too. Only the braced form is interpolated, `$VARIABLE` is left for the shell that runs a command.
+// A `$$` is an escaped `$`, so `$${VARIABLE}` is left as `${VARIABLE}`. A variable that isn't defined is an error, unless a
+// default is provided.
+
+// the functions that provide a value when the variable isn't defined
+var interpolateDefaultFuncs = []string{"-", ":-", "=", ":="}
+
+// InterpolateLagoonYAML replaces the variables in the string fields of the .lagoon.yml that support interpolation. Only the
+// environments block with the given key is interpolated, the blocks of the other environments can use variables that are only
+// defined in those environments.
+func InterpolateLagoonYAML(l *YAML, environment string, variables map[string]string) error {
+	i := &interpolator{variables: variables}
+	if e, ok := l.Environments[environment]; ok {
+		path := []string{"environments", environment}
+		if err := i.routes(append(path, "routes"), e.Routes); err != nil {
+			return err
+		}
+		for idx := range e.Cronjobs {
+			if err := i.value(append(path, "cronjobs", strconv.Itoa(idx), "command"), &e.Cronjobs[idx].Command); err != nil {
+				return err
+			}
+		}
+		l.Environments[environment] = e
+	}
+	if l.ProductionRoutes != nil {
+		if l.ProductionRoutes.Active != nil {
+			if err := i.routes([]string{"production_routes", "active", "routes"}, l.ProductionRoutes.Active.Routes); err != nil {
+				return err
+			}
+		}
+		if l.ProductionRoutes.Standby != nil {
+			if err := i.routes([]string{"production_routes", "standby", "routes"}, l.ProductionRoutes.Standby.Routes); err != nil {
+				return err
+			}
+		}
+	}
+	for idx := range l.Tasks.Prerollout {
+		if err := i.value([]string{"tasks", "pre-rollout", strconv.Itoa(idx), "run", "command"}, &l.Tasks.Prerollout[idx].Run.Command); err != nil {
+			return err
+		}
+	}
+	for idx := range l.Tasks.Postrollout {
+		if err := i.value([]string{"tasks", "post-rollout", strconv.Itoa(idx), "run", "command"}, &l.Tasks.Postrollout[idx].Run.Command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Interpolate replaces the variables in a single value, see InterpolateLagoonYAML
+func Interpolate(value string, variables map[string]string) (string, error) {
+	tree, err := parse.Parse(value)
+	if err != nil {
+		return "", err
+	}
+	if err := checkInterpolationVariables(tree.Root, variables); err != nil {
+		return "", err
+	}
+	return envsubst.Eval(value, func(name string) string {
+		return variables[name]
+	})
+}
+
+// checkInterpolationVariables checks that every variable in the tree is defined, or has a default
+func checkInterpolationVariables(node parse.Node, variables map[string]string) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			if err := checkInterpolationVariables(child, variables); err != nil {
+				return err
+			}
+		}
+	case *parse.FuncNode:
+		for _, arg := range n.Args {
+			if err := checkInterpolationVariables(arg, variables); err != nil {
+				return err
+			}
+		}
+		if _, ok := variables[n.Param]; ok {
+			return nil
+		}
+		for _, f := range interpolateDefaultFuncs {
+			if n.Name == f {
+				return nil
+			}
+		}
+		return fmt.Errorf("the variable %s is not defined, use $$ to escape a $", n.Param)
+	}
+	return nil
+}
+
+type interpolator struct {
+	variables map[string]string
+}
+
+func (i *interpolator) value(path []string, value *string) error {
+	interpolated, err := Interpolate(*value, i.variables)
+	if err != nil {
+		return fmt.Errorf("couldn't interpolate %s: %v", FormatPath(path), err)
+	}
+	*value = interpolated
+	return nil
+}
+
+// routes interpolates the domains and alternativenames of the routes, the routes are replaced in place
+func (i *interpolator) routes(path []string, routes []map[string][]Route) error {
+	for idx, routeMap := range routes {
+		for service, serviceRoutes := range routeMap {
+			for ridx, route := range serviceRoutes {
+				routePath := append(append([]string{}, path...), strconv.Itoa(idx), service, strconv.Itoa(ridx))
+				if route.Name != "" {
+					if err := i.value(routePath, &route.Name); err != nil {
+						return err
+					}
+				}
+				if route.Ingresses != nil {
+					ingresses := map[string]Ingress{}
+					for domain, ingress := range route.Ingresses {
+						ingressPath := append(append([]string{}, routePath...), domain)
+						interpolated := domain
+						if err := i.value(ingressPath, &interpolated); err != nil {
+							return err
+						}
+						for aidx := range ingress.AlternativeNames {
+							if err := i.value(append(append([]string{}, ingressPath...), "alternativenames", strconv.Itoa(aidx)), &ingress.AlternativeNames[aidx]); err != nil {
+								return err
+							}
+						}
+						ingresses[interpolated] = ingress
+					}
+					route.Ingresses = ingresses
+				}
+				serviceRoutes[ridx] = route
+			}
+		}
+	}
+	return nil
+}
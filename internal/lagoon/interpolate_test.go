@@ -0,0 +1,204 @@
+package lagoon
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestInterpolate(t *testing.T) {
+	variables := map[string]string{
+		"LAGOON_PROJECT":     "example-project",
+		"LAGOON_ENVIRONMENT": "main",
+		"EMPTY":              "",
+	}
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "test1 - no variables",
+			value: "example.com",
+			want:  "example.com",
+		},
+		{
+			name:  "test2 - braced variables",
+			value: "${LAGOON_ENVIRONMENT}.${LAGOON_PROJECT}.example.com",
+			want:  "main.example-project.example.com",
+		},
+		{
+			name:  "test3 - unbraced variables are left for the shell",
+			value: "drush -l $LAGOON_ENVIRONMENT cron",
+			want:  "drush -l $LAGOON_ENVIRONMENT cron",
+		},
+		{
+			name:  "test4 - escaped variable",
+			value: "echo $${HOME} $$LAGOON_PROJECT ${LAGOON_PROJECT}",
+			want:  "echo ${HOME} $LAGOON_PROJECT example-project",
+		},
+		{
+			name:  "test5 - default for an undefined variable",
+			value: "${NOT_DEFINED:-example.org}",
+			want:  "example.org",
+		},
+		{
+			name:  "test6 - defined but empty",
+			value: "a${EMPTY}b",
+			want:  "ab",
+		},
+		{
+			name:    "test7 - undefined variable",
+			value:   "${NOT_DEFINED}.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "test8 - undefined variable in a function",
+			value:   "${NOT_DEFINED,,}.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "test9 - bad substitution",
+			value:   "${LAGOON_PROJECT",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Interpolate(tt.value, variables)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Interpolate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Interpolate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolateLagoonYAML(t *testing.T) {
+	variables := map[string]string{
+		"LAGOON_PROJECT":     "example-project",
+		"LAGOON_ENVIRONMENT": "main",
+	}
+	tests := []struct {
+		name        string
+		environment string
+		lagoonYAML  string
+		want        string
+		wantErr     string
+	}{
+		{
+			name:        "test1 - routes, cronjobs and tasks",
+			environment: "main",
+			lagoonYAML: `
+environments:
+  main:
+    routes:
+      - nginx:
+          - ${LAGOON_ENVIRONMENT}.example.com
+          - www.${LAGOON_PROJECT}.com:
+              tls-acme: false
+              alternativenames:
+                - en.${LAGOON_PROJECT}.com
+    cronjobs:
+      - name: drush cron
+        schedule: "H * * * *"
+        command: drush -l ${LAGOON_ENVIRONMENT}.example.com cron
+        service: cli
+  dev:
+    routes:
+      - nginx:
+          - ${ONLY_IN_DEV}.example.com
+production_routes:
+  active:
+    routes:
+      - nginx:
+          - active.${LAGOON_PROJECT}.com
+tasks:
+  post-rollout:
+    - run:
+        name: echo
+        command: echo ${LAGOON_PROJECT} $${HOME}
+        service: cli
+`,
+			want: `
+environments:
+  main:
+    routes:
+      - nginx:
+          - main.example.com
+          - www.example-project.com:
+              tls-acme: false
+              alternativenames:
+                - en.example-project.com
+    cronjobs:
+      - name: drush cron
+        schedule: "H * * * *"
+        command: drush -l main.example.com cron
+        service: cli
+  dev:
+    routes:
+      - nginx:
+          - ${ONLY_IN_DEV}.example.com
+production_routes:
+  active:
+    routes:
+      - nginx:
+          - active.example-project.com
+tasks:
+  post-rollout:
+    - run:
+        name: echo
+        command: echo example-project ${HOME}
+        service: cli
+`,
+		},
+		{
+			name:        "test2 - undefined variable",
+			environment: "main",
+			lagoonYAML: `
+environments:
+  main:
+    cronjobs:
+      - name: drush cron
+        schedule: "H * * * *"
+        command: drush -l ${NOT_DEFINED} cron
+        service: cli
+`,
+			wantErr: "couldn't interpolate environments.main.cronjobs.0.command: the variable NOT_DEFINED is not defined, use $$ to escape a $",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &YAML{}
+			if err := yaml.Unmarshal([]byte(tt.lagoonYAML), l); err != nil {
+				t.Fatalf("couldn't unmarshal lagoonYAML: %v", err)
+			}
+			err := InterpolateLagoonYAML(l, tt.environment, variables)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("InterpolateLagoonYAML() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Errorf("InterpolateLagoonYAML() error = nil, wantErr %v", tt.wantErr)
+				return
+			}
+			want := &YAML{}
+			if err := yaml.Unmarshal([]byte(tt.want), want); err != nil {
+				t.Fatalf("couldn't unmarshal want: %v", err)
+			}
+			if !reflect.DeepEqual(l, want) {
+				lJSON, _ := json.Marshal(l)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("InterpolateLagoonYAML() = %v, want %v", string(lJSON), string(wantJSON))
+			}
+		})
+	}
+}
@@ -5,10 +5,9 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"sort"
 	"strconv"
 
-	"dario.cat/mergo"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon/yamlv2"
 	"sigs.k8s.io/yaml"
 )
 
@@ -38,10 +37,26 @@ type Cronjob struct {
 }
 
 type Override struct {
-	Build Build  `json:"build,omitempty"`
-	Image string `json:"image,omitempty"`
+	Build          Build              `json:"build,omitempty"`
+	Image          string             `json:"image,omitempty"`
+	Resources      *ResourceOverrides `json:"resources,omitempty"`
+	Probes         *ProbeOverrides    `json:"probes,omitempty"`
+	Autoscaling    *Autoscaling       `json:"autoscaling,omitempty"`
+	Containers     []Container        `json:"containers,omitempty"`
+	InitContainers []Container        `json:"initContainers,omitempty"`
 }
 
+// the resource, probe, autoscaling and container overrides of a service are the same in both versions of the .lagoon.yml
+type (
+	ResourceOverrides = yamlv2.ResourceOverrides
+	ResourceList      = yamlv2.ResourceList
+	ProbeOverrides    = yamlv2.ProbeOverrides
+	ProbeOverride     = yamlv2.ProbeOverride
+	Autoscaling       = yamlv2.Autoscaling
+	Container         = yamlv2.Container
+	ContainerVolume   = yamlv2.ContainerVolume
+)
+
 type Build struct {
 	Dockerfile string `json:"dockerfile,omitempty"`
 	Context    string `json:"context,omitempty"`
@@ -72,6 +87,7 @@ type YAML struct {
 	BackupSchedule       BackupSchedule               `json:"backup-schedule"`
 	EnvironmentVariables EnvironmentVariables         `json:"environment_variables,omitempty"`
 	ContainerRegistries  map[string]ContainerRegistry `json:"container-registries,omitempty"`
+	Fastly               *FastlyConfiguration         `json:"fastly,omitempty"`
 }
 
 type ContainerRegistry struct {
@@ -133,8 +149,7 @@ func (a *Routes) UnmarshalJSON(data []byte) error {
 			if reflect.TypeOf(value.(map[string]interface{})["tls-acme"]).Kind() == reflect.String {
 				vBool, err := strconv.ParseBool(value.(map[string]interface{})["tls-acme"].(string))
 				if err == nil {
-					// @TODO: add warning functionality here to inform that users should fix their yaml to be boolean not string
-					// this could warn in a yaml validation step at the start of builds
+					// the conversion is recorded as a warning by Warnings.Collect
 					value.(map[string]interface{})["tls-acme"] = vBool
 				}
 			}
@@ -143,8 +158,7 @@ func (a *Routes) UnmarshalJSON(data []byte) error {
 			if reflect.TypeOf(value.(map[string]interface{})["enabled"]).Kind() == reflect.String {
 				vBool, err := strconv.ParseBool(value.(map[string]interface{})["enabled"].(string))
 				if err == nil {
-					// @TODO: add warning functionality here to inform that users should fix their yaml to be boolean not string
-					// this could warn in a yaml validation step at the start of builds
+					// the conversion is recorded as a warning by Warnings.Collect
 					value.(map[string]interface{})["enabled"] = vBool
 				}
 			}
@@ -153,8 +167,7 @@ func (a *Routes) UnmarshalJSON(data []byte) error {
 			if reflect.TypeOf(value.(map[string]interface{})["allowPullRequests"]).Kind() == reflect.String {
 				vBool, err := strconv.ParseBool(value.(map[string]interface{})["allowPullRequests"].(string))
 				if err == nil {
-					// @TODO: add warning functionality here to inform that users should fix their yaml to be boolean not string
-					// this could warn in a yaml validation step at the start of builds
+					// the conversion is recorded as a warning by Warnings.Collect
 					value.(map[string]interface{})["allowPullRequests"] = vBool
 				}
 			}
@@ -176,8 +189,7 @@ func (a *EnvironmentVariables) UnmarshalJSON(data []byte) error {
 		if reflect.TypeOf(value).Kind() == reflect.String {
 			vBool, err := strconv.ParseBool(value.(string))
 			if err == nil {
-				// @TODO: add warning functionality here to inform that users should fix their yaml to be boolean not string
-				// this could warn in a yaml validation step at the start of builds
+				// the conversion is recorded as a warning by Warnings.Collect
 				value = vBool
 			}
 		}
@@ -188,11 +200,25 @@ func (a *EnvironmentVariables) UnmarshalJSON(data []byte) error {
 }
 
 // UnmarshalLagoonYAML unmarshal the lagoon.yml file into a YAML and map for consumption.
-func UnmarshalLagoonYAML(file string, l *YAML, project string) error {
+// Both version 1 and version 2 files are unmarshalled into the same YAML.
+// Any warnings found in the file are recorded in the warnings collector, which can be nil.
+func UnmarshalLagoonYAML(file string, l *YAML, project string, warnings *Warnings) error {
 	rawYAML, err := os.ReadFile(file)
 	if err != nil {
 		return fmt.Errorf("couldn't read %v: %v", file, err)
 	}
+	return unmarshalLagoonYAML(file, rawYAML, l, project, warnings)
+}
+
+func unmarshalLagoonYAML(source string, rawYAML []byte, l *YAML, project string, warnings *Warnings) error {
+	version, err := LagoonYAMLVersion(rawYAML)
+	if err != nil {
+		return err
+	}
+	if version == yamlv2.Version {
+		// version 2 has no polysite blocks and nothing to warn about, anything unexpected is an error
+		return unmarshalLagoonYAMLV2(rawYAML, l)
+	}
 	// lagoon.yml
 	err = yaml.Unmarshal(rawYAML, l)
 	if err != nil {
@@ -228,60 +254,9 @@ func UnmarshalLagoonYAML(file string, l *YAML, project string) error {
 		val := polycrons[en]
 		// check if the two aren't already the same, no need to do anything otherwise
 		if !reflect.DeepEqual(e.Cronjobs, val) {
-			if len(e.Cronjobs) == 0 {
-				// if there are no cronjobs from the polysite project, set the cronjobs to be the older top level cronjobs only
-				e.Cronjobs = val
-			} else {
-				for _, c1 := range e.Cronjobs {
-					for _, c2 := range val {
-						// check if the original top level cronjobs exist
-						if c1.Name == c2.Name {
-							continue
-						}
-						e.Cronjobs = append(e.Cronjobs, c2)
-					}
-				}
-			}
+			e.Cronjobs = mergePolysiteCronjobs(e.Cronjobs, val)
 			l.Environments[en] = e
 		}
 	}
-	return nil
-}
-
-func MergeLagoonYAMLs(destination *YAML, source *YAML) error {
-	if err := mergeLagoonYAMLTasks(&destination.Tasks.Prerollout, &source.Tasks.Prerollout); err != nil {
-		return err
-	}
-	if err := mergeLagoonYAMLTasks(&destination.Tasks.Postrollout, &source.Tasks.Postrollout); err != nil {
-		return err
-	}
-	sortLagoonYamlTasksByWeight(destination.Tasks.Prerollout)
-	sortLagoonYamlTasksByWeight(destination.Tasks.Postrollout)
-	return nil
-}
-
-func sortLagoonYamlTasksByWeight(tasks []TaskRun) {
-	sort.Slice(tasks, func(i int, j int) bool {
-		return tasks[i].Run.Weight < tasks[j].Run.Weight
-	})
-}
-
-func mergeLagoonYAMLTasks(left *[]TaskRun, right *[]TaskRun) error {
-	for i, rightTask := range *right {
-		appendToLeft := true
-		for j, leftTask := range *left {
-			if leftTask.Run.Name != "" && leftTask.Run.Name == rightTask.Run.Name {
-				//here we merge the two, rather than appending
-				appendToLeft = false
-				if err := mergo.Merge(&(*left)[j].Run, &(*right)[i].Run, mergo.WithOverride); err != nil {
-					return err
-				}
-
-			}
-		}
-		if appendToLeft {
-			*left = append(*left, rightTask)
-		}
-	}
-	return nil
+	return warnings.Collect(source, rawYAML, project)
 }
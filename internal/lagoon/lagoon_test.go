@@ -527,10 +527,92 @@ func TestUnmarshalLagoonYAML(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "test-polysite top level cronjobs are only added once",
+			args: args{
+				file:    "test-resources/lagoon-yaml/test12/lagoon.yml",
+				l:       &YAML{},
+				project: "multiproject1",
+			},
+			want: &YAML{
+				DockerComposeYAML: "docker-compose.yml",
+				Environments: Environments{
+					"main": Environment{
+						Cronjobs: []Cronjob{
+							{
+								Name:     "drush cron",
+								Command:  "drush cron",
+								Service:  "cli",
+								Schedule: "*/15 * * * *",
+							},
+							{
+								Name:     "project cron",
+								Command:  "project cron",
+								Service:  "cli",
+								Schedule: "H * * * *",
+							},
+							{
+								Name:     "some other drush cron",
+								Command:  "drush cron",
+								Service:  "cli",
+								Schedule: "*/5 * * * *",
+							},
+							{
+								Name:     "another drush cron",
+								Command:  "drush cron",
+								Service:  "cli",
+								Schedule: "H 1 * * *",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test-polysite other project top level cronjobs are only added once",
+			args: args{
+				file:    "test-resources/lagoon-yaml/test12/lagoon.yml",
+				l:       &YAML{},
+				project: "multiproject2",
+			},
+			want: &YAML{
+				DockerComposeYAML: "docker-compose.yml",
+				Environments: Environments{
+					"main": Environment{
+						Cronjobs: []Cronjob{
+							{
+								Name:     "notdrush cron",
+								Command:  "notdrush cron",
+								Service:  "cli",
+								Schedule: "*/15 * * * *",
+							},
+							{
+								Name:     "drush cron",
+								Command:  "drush cron",
+								Service:  "cli",
+								Schedule: "*/5 * * * *",
+							},
+							{
+								Name:     "some other drush cron",
+								Command:  "drush cron",
+								Service:  "cli",
+								Schedule: "*/5 * * * *",
+							},
+							{
+								Name:     "another drush cron",
+								Command:  "drush cron",
+								Service:  "cli",
+								Schedule: "H 1 * * *",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := UnmarshalLagoonYAML(tt.args.file, tt.args.l, tt.args.project); (err != nil) != tt.wantErr {
+			if err := UnmarshalLagoonYAML(tt.args.file, tt.args.l, tt.args.project, nil); (err != nil) != tt.wantErr {
 				t.Errorf("UnmarshalLagoonYAML() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			if !reflect.DeepEqual(tt.args.l, tt.want) {
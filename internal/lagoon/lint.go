@@ -0,0 +1,265 @@
+package lagoon
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// LintRule is a check that is run against a .lagoon.yml file
+type LintRule struct {
+	ID          string `json:"id"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// LintResult is a problem found by a lint rule
+type LintResult struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+	// the keys of the path, used to locate the result in the .lagoon.yml files
+	path []string
+}
+
+// Keys returns the keys of the path of the lint result, used to locate the result in the .lagoon.yml files
+func (r LintResult) Keys() []string {
+	return append([]string{}, r.path...)
+}
+
+// Environment returns the key of the environments block that the lint result was found in, or an empty string if it wasn't
+// found in an environments block
+func (r LintResult) Environment() string {
+	if len(r.path) > 1 && r.path[0] == "environments" {
+		return r.path[1]
+	}
+	return ""
+}
+
+func (r LintResult) String() string {
+	return fmt.Sprintf("%s: %s [%s]", r.Path, r.Message, r.Rule)
+}
+
+// the ids of the lint rules
+const (
+	LintRuleRouteSnippetAnnotation    = "route-snippet-annotation"
+	LintRuleRouteHostname             = "route-hostname"
+	LintRuleRouteWildcardTLSAcme      = "route-wildcard-tls-acme"
+	LintRuleRouteWildcardAlternatives = "route-wildcard-alternativenames"
+	LintRuleRouteMonitoringPath       = "route-monitoring-path"
+	LintRuleCronjobSchedule           = "cronjob-schedule"
+	LintRuleCronjobInPod              = "cronjob-in-pod"
+	LintRuleEnvironmentPattern        = "environment-pattern"
+)
+
+// LintRules is every lint rule that is run against a .lagoon.yml file
+var LintRules = []LintRule{
+	{
+		ID:          LintRuleRouteSnippetAnnotation,
+		Severity:    SeverityError,
+		Description: "routes must not use the nginx server-snippet or configuration-snippet annotations",
+	},
+	{
+		ID:          LintRuleRouteHostname,
+		Severity:    SeverityError,
+		Description: "route domains and alternativenames must be valid hostnames, domains that use variables are checked once they are interpolated",
+	},
+	{
+		ID:          LintRuleRouteWildcardTLSAcme,
+		Severity:    SeverityError,
+		Description: "wildcard routes must set tls-acme to false",
+	},
+	{
+		ID:          LintRuleRouteWildcardAlternatives,
+		Severity:    SeverityError,
+		Description: "wildcard routes must not define alternativenames",
+	},
+	{
+		ID:          LintRuleRouteMonitoringPath,
+		Severity:    SeverityError,
+		Description: "the monitoring-path of a route must be an absolute path without whitespace",
+	},
+	{
+		ID:          LintRuleCronjobSchedule,
+		Severity:    SeverityError,
+		Description: "cronjob schedules must be valid",
+	},
+	{
+		ID:          LintRuleCronjobInPod,
+		Severity:    SeverityWarning,
+		Description: "cronjobs that run too frequently are run inside the service pod instead of as a kubernetes cronjob",
+	},
+	{
+		ID:          LintRuleEnvironmentPattern,
+		Severity:    SeverityError,
+		Description: "environment keys that are globs or regular expressions must be valid patterns",
+	},
+}
+
+// the route annotations that are not allowed
+var disallowedRouteAnnotations = []string{
+	"nginx.ingress.kubernetes.io/server-snippet",
+	"nginx.ingress.kubernetes.io/configuration-snippet",
+}
+
+// GetLintRule returns the lint rule with the given id
+func GetLintRule(id string) (LintRule, bool) {
+	for _, rule := range LintRules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return LintRule{}, false
+}
+
+// Lint runs the lint rules against a .lagoon.yml, any rules in disabled are not run
+func Lint(l *YAML, disabled []string) ([]LintResult, error) {
+	for _, id := range disabled {
+		if _, ok := GetLintRule(id); !ok {
+			return nil, fmt.Errorf("unknown lint rule %s", id)
+		}
+	}
+	linter := &linter{disabled: disabled, results: []LintResult{}}
+	environments := []string{}
+	for name := range l.Environments {
+		environments = append(environments, name)
+	}
+	sort.Strings(environments)
+	for _, name := range environments {
+		environment := l.Environments[name]
+		if err := ValidateEnvironmentKey(name); err != nil {
+			linter.add(LintRuleEnvironmentPattern, []string{"environments", name}, "%v", err)
+		}
+		linter.routes([]string{"environments", name, "routes"}, environment.Routes)
+		for idx, cronjob := range environment.Cronjobs {
+			linter.cronjob([]string{"environments", name, "cronjobs", strconv.Itoa(idx)}, cronjob)
+		}
+	}
+	if l.ProductionRoutes != nil {
+		if l.ProductionRoutes.Active != nil {
+			linter.routes([]string{"production_routes", "active", "routes"}, l.ProductionRoutes.Active.Routes)
+		}
+		if l.ProductionRoutes.Standby != nil {
+			linter.routes([]string{"production_routes", "standby", "routes"}, l.ProductionRoutes.Standby.Routes)
+		}
+	}
+	return linter.results, nil
+}
+
+type linter struct {
+	disabled []string
+	results  []LintResult
+}
+
+func (l *linter) add(id string, path []string, format string, args ...interface{}) {
+	for _, d := range l.disabled {
+		if d == id {
+			return
+		}
+	}
+	rule, _ := GetLintRule(id)
+	l.results = append(l.results, LintResult{
+		Rule:     rule.ID,
+		Severity: rule.Severity,
+		Path:     FormatPath(path),
+		Message:  fmt.Sprintf(format, args...),
+		path:     append([]string{}, path...),
+	})
+}
+
+func (l *linter) routes(path []string, routes []map[string][]Route) {
+	for idx, routeMap := range routes {
+		services := []string{}
+		for service := range routeMap {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		for _, service := range services {
+			for ridx, route := range routeMap[service] {
+				routePath := append(append([]string{}, path...), strconv.Itoa(idx), service, strconv.Itoa(ridx))
+				if route.Name != "" {
+					l.hostname(routePath, route.Name)
+					continue
+				}
+				domains := []string{}
+				for domain := range route.Ingresses {
+					domains = append(domains, domain)
+				}
+				sort.Strings(domains)
+				for _, domain := range domains {
+					l.ingress(append(append([]string{}, routePath...), domain), domain, route.Ingresses[domain])
+				}
+			}
+		}
+	}
+}
+
+func (l *linter) ingress(path []string, domain string, ingress Ingress) {
+	l.hostname(path, domain)
+	for idx, name := range ingress.AlternativeNames {
+		l.hostname(append(append([]string{}, path...), "alternativenames", strconv.Itoa(idx)), name)
+	}
+	for _, annotation := range disallowedRouteAnnotations {
+		if _, ok := ingress.Annotations[annotation]; ok {
+			l.add(LintRuleRouteSnippetAnnotation, append(append([]string{}, path...), "annotations", annotation),
+				"the %s annotation is not allowed", annotation)
+		}
+	}
+	if ingress.Wildcard != nil && *ingress.Wildcard {
+		// tls-acme defaults to true, so it must be disabled for wildcard routes
+		if ingress.TLSAcme == nil || *ingress.TLSAcme {
+			l.add(LintRuleRouteWildcardTLSAcme, append(append([]string{}, path...), "wildcard"),
+				"route %s has wildcard: true and tls-acme: true, this is not supported", domain)
+		}
+		if ingress.AlternativeNames != nil {
+			l.add(LintRuleRouteWildcardAlternatives, append(append([]string{}, path...), "wildcard"),
+				"route %s has wildcard: true and alternativenames defined, this is not supported", domain)
+		}
+	}
+	if ingress.MonitoringPath != "" {
+		if !strings.HasPrefix(ingress.MonitoringPath, "/") || strings.ContainsAny(ingress.MonitoringPath, " \t\n") {
+			l.add(LintRuleRouteMonitoringPath, append(append([]string{}, path...), "monitoring-path"),
+				"monitoring-path %q must be an absolute path starting with / and must not contain whitespace", ingress.MonitoringPath)
+		}
+	}
+}
+
+// hostname checks that a domain is a valid hostname, a domain that uses a variable is only known once the .lagoon.yml has
+// been interpolated, so it is checked when the routes are generated instead
+func (l *linter) hostname(path []string, hostname string) {
+	if strings.Contains(hostname, "${") {
+		return
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.ToLower(hostname)); len(errs) > 0 {
+		l.add(LintRuleRouteHostname, path, "%s is not a valid hostname: %s", hostname, strings.Join(errs, ", "))
+	}
+}
+
+func (l *linter) cronjob(path []string, cronjob Cronjob) {
+	if _, err := helpers.ConvertCrontab("lint", cronjob.Schedule); err != nil {
+		l.add(LintRuleCronjobSchedule, append(append([]string{}, path...), "schedule"), "cronjob %s: %v", cronjob.Name, err)
+		return
+	}
+	if cronjob.InPod != nil && *cronjob.InPod {
+		// the cronjob is already run in the pod
+		return
+	}
+	inPod, err := helpers.IsInPodCronjob(cronjob.Schedule)
+	if err != nil {
+		l.add(LintRuleCronjobSchedule, append(append([]string{}, path...), "schedule"), "cronjob %s: %v", cronjob.Name, err)
+		return
+	}
+	if inPod {
+		l.add(LintRuleCronjobInPod, append(append([]string{}, path...), "schedule"),
+			"cronjob %s with schedule %q runs too frequently to be a kubernetes cronjob, it will be run inside the %s pod",
+			cronjob.Name, cronjob.Schedule, cronjob.Service)
+	}
+}
@@ -0,0 +1,78 @@
+package lagoon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		disabled []string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name: "test1 - all rules",
+			file: "test-resources/lagoon-yaml/lint/lagoon.yml",
+			want: []string{
+				"error environment-pattern environments./release-(/",
+				"error route-snippet-annotation environments.main.routes.0.nginx.0.\"example.com\".annotations.\"nginx.ingress.kubernetes.io/server-snippet\"",
+				"error route-monitoring-path environments.main.routes.0.nginx.0.\"example.com\".monitoring-path",
+				"error route-wildcard-tls-acme environments.main.routes.0.nginx.1.\"wildcard.example.com\".wildcard",
+				"error route-wildcard-alternativenames environments.main.routes.0.nginx.1.\"wildcard.example.com\".wildcard",
+				"error route-hostname environments.main.routes.0.nginx.2",
+				"warning cronjob-in-pod environments.main.cronjobs.0.schedule",
+				"error cronjob-schedule environments.main.cronjobs.2.schedule",
+				"error route-snippet-annotation production_routes.active.routes.0.nginx.0.\"active.example.com\".annotations.\"nginx.ingress.kubernetes.io/configuration-snippet\"",
+			},
+		},
+		{
+			name:     "test2 - disabled rules",
+			file:     "test-resources/lagoon-yaml/lint/lagoon.yml",
+			disabled: []string{LintRuleRouteSnippetAnnotation, LintRuleCronjobInPod, LintRuleRouteWildcardTLSAcme, LintRuleEnvironmentPattern},
+			want: []string{
+				"error route-monitoring-path environments.main.routes.0.nginx.0.\"example.com\".monitoring-path",
+				"error route-wildcard-alternativenames environments.main.routes.0.nginx.1.\"wildcard.example.com\".wildcard",
+				"error route-hostname environments.main.routes.0.nginx.2",
+				"error cronjob-schedule environments.main.cronjobs.2.schedule",
+			},
+		},
+		{
+			name:     "test3 - unknown rule",
+			file:     "test-resources/lagoon-yaml/lint/lagoon.yml",
+			disabled: []string{"not-a-rule"},
+			wantErr:  true,
+		},
+		{
+			name: "test4 - no problems",
+			file: "test-resources/lagoon-yaml/test2/lagoon.yml",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &YAML{}
+			if err := UnmarshalLagoonYAML(tt.file, l, "", nil); err != nil {
+				t.Errorf("UnmarshalLagoonYAML() error = %v", err)
+				return
+			}
+			results, err := Lint(l, tt.disabled)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Lint() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got := []string{}
+			for _, r := range results {
+				got = append(got, r.Severity+" "+r.Rule+" "+r.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
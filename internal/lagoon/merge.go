@@ -0,0 +1,442 @@
+package lagoon
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon/yamlv2"
+	"sigs.k8s.io/yaml"
+)
+
+// The merge strategy for .lagoon.override.yml and LAGOON_YAML_OVERRIDE
+//
+// The override is merged over the .lagoon.yml key by key
+//   - maps are merged by key, so only the keys in the override are changed
+//   - scalar values in the override replace the value in the .lagoon.yml
+//   - null values in the override are ignored, use the delete marker to remove a value
+//   - lists that have a natural key are merged by that key, items in the override that match an item in the .lagoon.yml are
+//     merged into it, and items that don't match are appended
+//   - all other lists in the override replace the list in the .lagoon.yml
+//
+// The lists that have a natural key are
+//   - tasks.pre-rollout and tasks.post-rollout, by run.name
+//   - environments.*.cronjobs, by name
+//   - environments.*.routes and production_routes.*.routes, by service name
+//   - the routes of a service, by domain
+//   - the pathRoutes of a route, by path
+//   - environments.*.autogeneratePathRoutes and routes.autogenerate.pathRoutes, by fromService and path
+//   - fastly.api-secrets, by name
+//
+// Any map or list item in the override can contain a marker
+//   - `$delete: true` removes the matching value or list item from the .lagoon.yml
+//   - `$replace: true` replaces the matching value or list item in the .lagoon.yml instead of merging into it
+//
+// A list item that is left empty after a delete, such as a route whose domain was deleted, is removed from the list.
+const (
+	MergeMarkerDelete  = "$delete"
+	MergeMarkerReplace = "$replace"
+)
+
+// naturalKey returns the key of a list item, and false if the item has no key
+type naturalKey func(item interface{}) (string, bool)
+
+// the lists that are merged by a natural key, * matches any map key or list item
+var mergeKeys = []struct {
+	path []string
+	key  naturalKey
+}{
+	{path: []string{"tasks", "pre-rollout"}, key: nestedKey("run", "name")},
+	{path: []string{"tasks", "post-rollout"}, key: nestedKey("run", "name")},
+	{path: []string{"environments", "*", "cronjobs"}, key: nestedKey("name")},
+	{path: []string{"environments", "*", "routes"}, key: singleKey},
+	{path: []string{"production_routes", "*", "routes"}, key: singleKey},
+	{path: []string{"environments", "*", "routes", "*", "*"}, key: routeKey},
+	{path: []string{"production_routes", "*", "routes", "*", "*"}, key: routeKey},
+	{path: []string{"environments", "*", "routes", "*", "*", "*", "*", "pathRoutes"}, key: nestedKey("path")},
+	{path: []string{"production_routes", "*", "routes", "*", "*", "*", "*", "pathRoutes"}, key: nestedKey("path")},
+	{path: []string{"environments", "*", "autogeneratePathRoutes"}, key: nestedKey("fromService", "path")},
+	{path: []string{"routes", "autogenerate", "pathRoutes"}, key: nestedKey("fromService", "path")},
+	{path: []string{"fastly", "api-secrets"}, key: nestedKey("name")},
+}
+
+// nestedKey returns the key of a list item from the values at the given fields, any nested maps are traversed
+// in order, so nestedKey("run", "name") is the value of run.name
+// if more than one field is given for the same map, like nestedKey("fromService", "path"), the values are joined
+func nestedKey(fields ...string) naturalKey {
+	return func(item interface{}) (string, bool) {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if nested, ok := m[fields[0]].(map[string]interface{}); ok && len(fields) > 1 {
+			return nestedKey(fields[1:]...)(nested)
+		}
+		values := []string{}
+		for _, field := range fields {
+			value, ok := m[field].(string)
+			if !ok || value == "" {
+				return "", false
+			}
+			values = append(values, value)
+		}
+		return strings.Join(values, "\x00"), true
+	}
+}
+
+// singleKey is the key of a list item that is a map with a single key, like a service in the routes
+func singleKey(item interface{}) (string, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	keys := []string{}
+	for key := range m {
+		if key != MergeMarkerDelete && key != MergeMarkerReplace {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) != 1 {
+		return "", false
+	}
+	return keys[0], true
+}
+
+// routeKey is the domain of a route, which is either the route itself or the single key of the route map
+func routeKey(item interface{}) (string, bool) {
+	if domain, ok := item.(string); ok {
+		return domain, true
+	}
+	return singleKey(item)
+}
+
+func mergeKeyFor(path []string) naturalKey {
+	for _, mk := range mergeKeys {
+		if len(mk.path) != len(path) {
+			continue
+		}
+		match := true
+		for i := range path {
+			if mk.path[i] != "*" && mk.path[i] != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return mk.key
+		}
+	}
+	return nil
+}
+
+// MergeLagoonYAMLOverride merges the contents of an override file over the destination using the merge strategy above.
+// If the override contains a polysite block for the project, only the polysite block is merged.
+// A version 2 override is converted and merged the same as MergeLagoonYAMLs, so the markers can't be used in it, a
+// boolean that is set to false in it is still merged.
+func MergeLagoonYAMLOverride(destination *YAML, override []byte, project string) error {
+	version, err := LagoonYAMLVersion(override)
+	if err != nil {
+		return err
+	}
+	if version == yamlv2.Version {
+		source := &YAML{}
+		if err := unmarshalLagoonYAMLV2(override, source); err != nil {
+			return err
+		}
+		return MergeLagoonYAMLs(destination, source)
+	}
+	overrideJSON, err := yaml.YAMLToJSON(override)
+	if err != nil {
+		return err
+	}
+	var overrideValues interface{}
+	if err := json.Unmarshal(overrideJSON, &overrideValues); err != nil {
+		return err
+	}
+	if m, ok := overrideValues.(map[string]interface{}); ok && project != "" {
+		if polysite, ok := m[project]; ok {
+			overrideValues = polysite
+		}
+	}
+	return mergeLagoonYAML(destination, overrideValues)
+}
+
+// MergeLagoonYAMLs merges the source over the destination using the merge strategy above, only the values that were set
+// in the source are merged, see pruneUnset
+func MergeLagoonYAMLs(destination *YAML, source *YAML) error {
+	sourceValues, err := toMergeValues(source)
+	if err != nil {
+		return err
+	}
+	sourceValues, _ = pruneUnset(sourceValues)
+	return mergeLagoonYAML(destination, sourceValues)
+}
+
+func mergeLagoonYAML(destination *YAML, override interface{}) error {
+	destinationValues, err := toMergeValues(destination)
+	if err != nil {
+		return err
+	}
+	merged, _, err := mergeValues(destinationValues, override, nil)
+	if err != nil {
+		return err
+	}
+	mergedJSON, err := json.Marshal(pruneNull(merged))
+	if err != nil {
+		return err
+	}
+	result := YAML{}
+	if err := json.Unmarshal(mergedJSON, &result); err != nil {
+		return fmt.Errorf("the merged .lagoon.yml is not valid: %v", err)
+	}
+	sortLagoonYamlTasksByWeight(result.Tasks.Prerollout)
+	sortLagoonYamlTasksByWeight(result.Tasks.Postrollout)
+	*destination = result
+	return nil
+}
+
+func sortLagoonYamlTasksByWeight(tasks []TaskRun) {
+	sort.SliceStable(tasks, func(i int, j int) bool {
+		return tasks[i].Run.Weight < tasks[j].Run.Weight
+	})
+}
+
+// toMergeValues converts a YAML into the generic values that are merged, null values are removed as they are the same as
+// the value not being set
+func toMergeValues(l *YAML) (interface{}, error) {
+	lJSON, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	var values interface{}
+	if err := json.Unmarshal(lJSON, &values); err != nil {
+		return nil, err
+	}
+	return pruneNull(values), nil
+}
+
+func pruneNull(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if child == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = pruneNull(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = pruneNull(child)
+		}
+	}
+	return value
+}
+
+// pruneUnset removes the values from maps that were not set, and returns false if the value itself was not set
+//   - every boolean in a YAML that can be left out is a pointer, so a boolean that is there was set, even if it is false
+//   - empty strings, zero numbers and empty maps are the values of fields that are always written, and are removed
+//
+// items in lists are never removed, as that would change which items are appended
+func pruneUnset(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if pruned, ok := pruneUnset(child); ok {
+				v[key] = pruned
+			} else {
+				delete(v, key)
+			}
+		}
+		return v, len(v) > 0
+	case []interface{}:
+		for i, child := range v {
+			v[i], _ = pruneUnset(child)
+		}
+		return v, len(v) > 0
+	case string:
+		return v, v != ""
+	case float64:
+		return v, v != 0
+	case bool:
+		return v, true
+	}
+	return value, value != nil
+}
+
+// isMarked returns true if the value is a map that has the marker set to true
+func isMarked(value interface{}, marker string) bool {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	set, ok := m[marker].(bool)
+	return ok && set
+}
+
+// withoutMarkers returns a copy of the value with every marker removed
+func withoutMarkers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		clean := map[string]interface{}{}
+		for key, child := range v {
+			if key == MergeMarkerDelete || key == MergeMarkerReplace {
+				continue
+			}
+			clean[key] = withoutMarkers(child)
+		}
+		return clean
+	case []interface{}:
+		clean := []interface{}{}
+		for _, child := range v {
+			if isMarked(child, MergeMarkerDelete) {
+				continue
+			}
+			clean = append(clean, withoutMarkers(child))
+		}
+		return clean
+	}
+	return value
+}
+
+// withoutMarkerKeys returns a copy of the value with the marker keys removed, but unlike withoutMarkers the values that
+// are marked to be deleted are kept
+func withoutMarkerKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		clean := map[string]interface{}{}
+		for key, child := range v {
+			if key == MergeMarkerDelete || key == MergeMarkerReplace {
+				continue
+			}
+			clean[key] = withoutMarkerKeys(child)
+		}
+		return clean
+	case []interface{}:
+		clean := []interface{}{}
+		for _, child := range v {
+			clean = append(clean, withoutMarkerKeys(child))
+		}
+		return clean
+	}
+	return value
+}
+
+// isMarkedDeleted returns true if the value at the path, or any value along the path, is marked to be deleted
+func isMarkedDeleted(value interface{}, path []string) bool {
+	for _, p := range path {
+		if isMarked(value, MergeMarkerDelete) {
+			return true
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[p]
+		case []interface{}:
+			idx, err := strconv.Atoi(p)
+			if err != nil || idx >= len(v) {
+				return false
+			}
+			value = v[idx]
+		default:
+			return false
+		}
+	}
+	return isMarked(value, MergeMarkerDelete)
+}
+
+// mergeValues merges the override over the base, and returns true if the value should be deleted
+func mergeValues(base, override interface{}, path []string) (interface{}, bool, error) {
+	if override == nil {
+		return base, false, nil
+	}
+	if isMarked(override, MergeMarkerDelete) {
+		return nil, true, nil
+	}
+	if isMarked(override, MergeMarkerReplace) {
+		return withoutMarkers(override), false, nil
+	}
+	switch o := override.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return withoutMarkers(o), false, nil
+		}
+		merged := map[string]interface{}{}
+		for key, value := range b {
+			merged[key] = value
+		}
+		for key, value := range o {
+			if key == MergeMarkerDelete || key == MergeMarkerReplace {
+				continue
+			}
+			child, deleted, err := mergeValues(merged[key], value, append(append([]string{}, path...), key))
+			if err != nil {
+				return nil, false, err
+			}
+			if deleted {
+				delete(merged, key)
+				continue
+			}
+			merged[key] = child
+		}
+		return merged, false, nil
+	case []interface{}:
+		b, ok := base.([]interface{})
+		key := mergeKeyFor(path)
+		if !ok || key == nil {
+			return withoutMarkers(o), false, nil
+		}
+		return mergeKeyedList(b, o, key, path)
+	}
+	return override, false, nil
+}
+
+// mergeKeyedList merges the items in the override list into the base list by their natural key
+func mergeKeyedList(base, override []interface{}, key naturalKey, path []string) (interface{}, bool, error) {
+	merged := append([]interface{}{}, base...)
+	itemPath := append(append([]string{}, path...), "*")
+	for _, item := range override {
+		itemKey, ok := key(item)
+		if !ok {
+			if !isDeleted(item) {
+				merged = append(merged, withoutMarkers(item))
+			}
+			continue
+		}
+		matched := false
+		for i := 0; i < len(merged); i++ {
+			if baseKey, ok := key(merged[i]); !ok || baseKey != itemKey {
+				continue
+			}
+			matched = true
+			child, deleted, err := mergeValues(merged[i], item, itemPath)
+			if err != nil {
+				return nil, false, err
+			}
+			if m, ok := child.(map[string]interface{}); deleted || (ok && len(m) == 0) {
+				merged = append(merged[:i], merged[i+1:]...)
+				i--
+				continue
+			}
+			merged[i] = child
+		}
+		if !matched && !isDeleted(item) {
+			merged = append(merged, withoutMarkers(item))
+		}
+	}
+	return merged, false, nil
+}
+
+// isDeleted returns true if a list item is marked to be deleted, either directly or as the value of a single key map like
+// a route with `example.com: {$delete: true}`
+func isDeleted(item interface{}) bool {
+	if isMarked(item, MergeMarkerDelete) {
+		return true
+	}
+	if key, ok := singleKey(item); ok {
+		return isMarked(item.(map[string]interface{})[key], MergeMarkerDelete)
+	}
+	return false
+}
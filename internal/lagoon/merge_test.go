@@ -0,0 +1,636 @@
+package lagoon
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestMergeLagoonYAMLOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		project  string
+		base     string
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "environments - maps are merged by key",
+			base: `
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+    autogenerateRoutes: true
+  dev:
+    types:
+      mariadb: mariadb-single
+`,
+			override: `
+environments:
+  main:
+    types:
+      redis: redis-persistent
+    autogenerateRoutes: false
+  pr-1:
+    autogenerateRoutes: false
+`,
+			want: `
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+      redis: redis-persistent
+    autogenerateRoutes: false
+  dev:
+    types:
+      mariadb: mariadb-single
+  pr-1:
+    autogenerateRoutes: false
+`,
+		},
+		{
+			name: "environments - null values are ignored",
+			base: `
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+`,
+			override: `
+environments:
+  main:
+    types:
+`,
+			want: `
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+`,
+		},
+		{
+			name: "routes - merged by service and domain",
+			base: `
+environments:
+  main:
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: true
+              pathRoutes:
+                - toService: node
+                  path: /api
+          - b.example.com
+      - varnish:
+          - c.example.com
+`,
+			override: `
+environments:
+  main:
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: false
+              pathRoutes:
+                - toService: php
+                  path: /api
+                - toService: node
+                  path: /node
+          - d.example.com
+      - node:
+          - e.example.com
+`,
+			want: `
+environments:
+  main:
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: false
+              pathRoutes:
+                - toService: php
+                  path: /api
+                - toService: node
+                  path: /node
+          - b.example.com
+          - d.example.com
+      - varnish:
+          - c.example.com
+      - node:
+          - e.example.com
+`,
+		},
+		{
+			name: "routes - a string route is replaced by a map route with the same domain",
+			base: `
+environments:
+  main:
+    routes:
+      - nginx:
+          - a.example.com
+`,
+			override: `
+environments:
+  main:
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: false
+`,
+			want: `
+environments:
+  main:
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: false
+`,
+		},
+		{
+			name: "routes - production routes are merged by service and domain",
+			base: `
+production_routes:
+  active:
+    routes:
+      - nginx:
+          - active.example.com:
+              tls-acme: true
+`,
+			override: `
+production_routes:
+  active:
+    routes:
+      - nginx:
+          - active.example.com:
+              insecure: Redirect
+  standby:
+    routes:
+      - nginx:
+          - standby.example.com
+`,
+			want: `
+production_routes:
+  active:
+    routes:
+      - nginx:
+          - active.example.com:
+              tls-acme: true
+              insecure: Redirect
+  standby:
+    routes:
+      - nginx:
+          - standby.example.com
+`,
+		},
+		{
+			name: "cronjobs - merged by name",
+			base: `
+environments:
+  main:
+    cronjobs:
+      - name: drush cron
+        schedule: "H * * * *"
+        command: drush cron
+        service: cli
+      - name: nightly
+        schedule: "H 1 * * *"
+        command: ./nightly.sh
+        service: cli
+`,
+			override: `
+environments:
+  main:
+    cronjobs:
+      - name: drush cron
+        schedule: "*/15 * * * *"
+      - name: weekly
+        schedule: "H 1 * * 0"
+        command: ./weekly.sh
+        service: cli
+`,
+			want: `
+environments:
+  main:
+    cronjobs:
+      - name: drush cron
+        schedule: "*/15 * * * *"
+        command: drush cron
+        service: cli
+      - name: nightly
+        schedule: "H 1 * * *"
+        command: ./nightly.sh
+        service: cli
+      - name: weekly
+        schedule: "H 1 * * 0"
+        command: ./weekly.sh
+        service: cli
+`,
+		},
+		{
+			name: "backup-retention - merged by key",
+			base: `
+backup-retention:
+  production:
+    hourly: 0
+    daily: 7
+    weekly: 6
+    monthly: 1
+backup-schedule:
+  production: "M/15 5 * * 0"
+`,
+			override: `
+backup-retention:
+  production:
+    daily: 14
+`,
+			want: `
+backup-retention:
+  production:
+    hourly: 0
+    daily: 14
+    weekly: 6
+    monthly: 1
+backup-schedule:
+  production: "M/15 5 * * 0"
+`,
+		},
+		{
+			name: "container-registries - merged by registry name",
+			base: `
+container-registries:
+  my-registry:
+    username: myuser
+    password: REGISTRY_PASSWORD
+    url: registry.example.com
+  other-registry:
+    username: otheruser
+    password: OTHER_PASSWORD
+    url: other.example.com
+`,
+			override: `
+container-registries:
+  my-registry:
+    password: NEW_REGISTRY_PASSWORD
+  new-registry:
+    username: newuser
+    password: NEW_PASSWORD
+    url: new.example.com
+`,
+			want: `
+container-registries:
+  my-registry:
+    username: myuser
+    password: NEW_REGISTRY_PASSWORD
+    url: registry.example.com
+  other-registry:
+    username: otheruser
+    password: OTHER_PASSWORD
+    url: other.example.com
+  new-registry:
+    username: newuser
+    password: NEW_PASSWORD
+    url: new.example.com
+`,
+		},
+		{
+			name: "tasks - merged by name and sorted by weight",
+			base: `
+tasks:
+  post-rollout:
+    - run:
+        name: drush cim
+        command: drush cim -y
+        service: cli
+    - run:
+        command: echo unnamed
+        service: cli
+`,
+			override: `
+tasks:
+  post-rollout:
+    - run:
+        name: drush cim
+        command: drush cim -y --partial
+    - run:
+        name: drush cr
+        command: drush cr
+        service: cli
+        weight: -1
+    - run:
+        command: echo unnamed
+        service: cli
+`,
+			want: `
+tasks:
+  post-rollout:
+    - run:
+        name: drush cr
+        command: drush cr
+        service: cli
+        weight: -1
+    - run:
+        name: drush cim
+        command: drush cim -y --partial
+        service: cli
+    - run:
+        command: echo unnamed
+        service: cli
+    - run:
+        command: echo unnamed
+        service: cli
+`,
+		},
+		{
+			name: "lists without a natural key are replaced",
+			base: `
+routes:
+  autogenerate:
+    prefixes:
+      - www
+      - en
+`,
+			override: `
+routes:
+  autogenerate:
+    prefixes:
+      - de
+`,
+			want: `
+routes:
+  autogenerate:
+    prefixes:
+      - de
+`,
+		},
+		{
+			name: "markers - delete removes map entries and list items",
+			base: `
+container-registries:
+  my-registry:
+    username: myuser
+    password: REGISTRY_PASSWORD
+    url: registry.example.com
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: true
+          - b.example.com
+      - varnish:
+          - c.example.com
+    cronjobs:
+      - name: drush cron
+        schedule: "H * * * *"
+        command: drush cron
+        service: cli
+  dev:
+    types:
+      mariadb: mariadb-single
+tasks:
+  post-rollout:
+    - run:
+        name: drush cim
+        command: drush cim -y
+        service: cli
+`,
+			override: `
+container-registries:
+  my-registry:
+    $delete: true
+environments:
+  main:
+    routes:
+      - nginx:
+          - a.example.com:
+              $delete: true
+      - varnish:
+          $delete: true
+    cronjobs:
+      - name: drush cron
+        $delete: true
+      - name: not in the base
+        $delete: true
+  dev:
+    $delete: true
+tasks:
+  post-rollout:
+    - run:
+        name: drush cim
+      $delete: true
+`,
+			want: `
+container-registries: {}
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+    routes:
+      - nginx:
+          - b.example.com
+    cronjobs: []
+tasks:
+  post-rollout: []
+`,
+		},
+		{
+			name: "markers - replace replaces instead of merging",
+			base: `
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: true
+              insecure: Redirect
+      - varnish:
+          - c.example.com
+    cronjobs:
+      - name: drush cron
+        schedule: "H * * * *"
+        command: drush cron
+        service: cli
+`,
+			override: `
+environments:
+  main:
+    types:
+      $replace: true
+      redis: redis-persistent
+    routes:
+      - nginx:
+          - a.example.com:
+              $replace: true
+              tls-acme: false
+    cronjobs:
+      - name: drush cron
+        $replace: true
+        schedule: "H 2 * * *"
+        command: drush cron
+`,
+			want: `
+environments:
+  main:
+    types:
+      redis: redis-persistent
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: false
+      - varnish:
+          - c.example.com
+    cronjobs:
+      - name: drush cron
+        schedule: "H 2 * * *"
+        command: drush cron
+`,
+		},
+		{
+			name:    "polysite - only the project block is merged",
+			project: "example-project",
+			base: `
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+`,
+			override: `
+environments:
+  main:
+    types:
+      solr: solr-php
+example-project:
+  environments:
+    main:
+      types:
+        redis: redis-persistent
+`,
+			want: `
+environments:
+  main:
+    types:
+      mariadb: mariadb-single
+      redis: redis-persistent
+`,
+		},
+		{
+			name: "version 2 - false overrides are merged",
+			base: `
+routes:
+  autogenerate:
+    enabled: true
+    insecure: Redirect
+environments:
+  main:
+    autogenerateRoutes: true
+    types:
+      mariadb: mariadb-single
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: true
+              hstsEnabled: true
+tasks:
+  post-rollout:
+    - run:
+        name: drush cim
+        command: drush cim
+        service: cli
+        requiresEnvironment: true
+`,
+			override: `
+version: 2
+routes:
+  autogenerate:
+    enabled: false
+environments:
+  main:
+    autogenerateRoutes: false
+    routes:
+      - service: nginx
+        domain: a.example.com
+        tlsAcme: false
+tasks:
+  postRollout:
+    - name: drush cim
+      command: drush cim -y
+      service: cli
+`,
+			want: `
+routes:
+  autogenerate:
+    enabled: false
+    insecure: Redirect
+environments:
+  main:
+    autogenerateRoutes: false
+    types:
+      mariadb: mariadb-single
+    routes:
+      - nginx:
+          - a.example.com:
+              tls-acme: false
+              hstsEnabled: true
+tasks:
+  post-rollout:
+    - run:
+        name: drush cim
+        command: drush cim -y
+        service: cli
+        requiresEnvironment: true
+`,
+		},
+		{
+			name: "invalid merge result",
+			base: `
+environments:
+  main:
+    cronjobs:
+      - name: drush cron
+        schedule: "H * * * *"
+`,
+			override: `
+environments:
+  main:
+    cronjobs:
+      - name: drush cron
+        schedule:
+          - "H * * * *"
+`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &YAML{}
+			if err := yaml.Unmarshal([]byte(tt.base), l); err != nil {
+				t.Fatalf("couldn't unmarshal base: %v", err)
+			}
+			err := MergeLagoonYAMLOverride(l, []byte(tt.override), tt.project)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MergeLagoonYAMLOverride() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			want := &YAML{}
+			if err := yaml.Unmarshal([]byte(tt.want), want); err != nil {
+				t.Fatalf("couldn't unmarshal want: %v", err)
+			}
+			if !reflect.DeepEqual(l, want) {
+				lJSON, _ := json.Marshal(l)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("MergeLagoonYAMLOverride() = %v, want %v", string(lJSON), string(wantJSON))
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package lagoon
+
+import (
+	"sort"
+
+	"sigs.k8s.io/yaml"
+)
+
+// PolysiteProjects returns the names of the polysite projects defined in a .lagoon.yml, these are the top-level keys that
+// aren't a .lagoon.yml field and contain a .lagoon.yml for the project. A top-level map that doesn't use any of the
+// .lagoon.yml fields isn't a project
+func PolysiteProjects(data []byte) ([]string, error) {
+	version, err := LagoonYAMLVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version != 1 {
+		// only version 1 supports polysite projects
+		return []string{}, nil
+	}
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return polysiteProjects(doc), nil
+}
+
+func polysiteProjects(doc map[string]interface{}) []string {
+	projects := []string{}
+	fields := GenerateSchema()["properties"].(map[string]interface{})
+	for key, value := range doc {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		block, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for field := range block {
+			if _, ok := fields[field]; ok {
+				projects = append(projects, key)
+				break
+			}
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// mergePolysiteCronjobs adds the top-level cronjobs of an environment to the cronjobs of the polysite project environment,
+// unless the polysite project environment has a cronjob with the same name
+func mergePolysiteCronjobs(polysite, topLevel []Cronjob) []Cronjob {
+	if len(polysite) == 0 {
+		// if there are no cronjobs from the polysite project, set the cronjobs to be the older top level cronjobs only
+		return topLevel
+	}
+	merged := append([]Cronjob{}, polysite...)
+	for _, c2 := range topLevel {
+		found := false
+		for _, c1 := range polysite {
+			if c1.Name == c2.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, c2)
+		}
+	}
+	return merged
+}
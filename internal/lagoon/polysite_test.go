@@ -0,0 +1,53 @@
+package lagoon
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestPolysiteProjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "test1 - polysite projects",
+			file: "test-resources/lagoon-yaml/test6/lagoon.yml",
+			want: []string{"multiproject1", "multiproject2"},
+		},
+		{
+			name: "test2 - not a polysite",
+			file: "test-resources/lagoon-yaml/test2/lagoon.yml",
+			want: []string{},
+		},
+		{
+			name: "test3 - version 2 has no polysite projects",
+			file: "test-resources/lagoon-yaml/convert/lagoon.v2.yml",
+			want: []string{},
+		},
+		{
+			name: "test4 - top-level maps that aren't a .lagoon.yml are not projects",
+			file: "test-resources/lagoon-yaml/test13/lagoon.yml",
+			want: []string{"multiproject1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("couldn't read %v: %v", tt.file, err)
+			}
+			got, err := PolysiteProjects(data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PolysiteProjects() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PolysiteProjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
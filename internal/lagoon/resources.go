@@ -0,0 +1,238 @@
+package lagoon
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the prefixes of the docker-compose labels that override the resources, probes and autoscaling of a service
+//   - lagoon.resources.<requests|limits>.<cpu|memory>
+//   - lagoon.probes.<readiness|liveness>.<initialDelaySeconds|periodSeconds|timeoutSeconds|successThreshold|failureThreshold>
+//   - lagoon.autoscaling.<minReplicas|maxReplicas|targetCPUUtilization|targetMemoryUtilization>
+const (
+	resourceLabelPrefix    = "lagoon.resources."
+	probeLabelPrefix       = "lagoon.probes."
+	autoscalingLabelPrefix = "lagoon.autoscaling."
+)
+
+// ResourceOverridesFromLabels returns the resource overrides from the `lagoon.resources.*` labels of a docker-compose service, or
+// nil if there are none. The values are only checked to be quantities by the generator.
+func ResourceOverridesFromLabels(labels map[string]string) (*ResourceOverrides, error) {
+	var resources *ResourceOverrides
+	for _, label := range sortedLabels(labels, resourceLabelPrefix) {
+		value := labels[label]
+		parts := strings.Split(strings.TrimPrefix(label, resourceLabelPrefix), ".")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("unknown label %s, must be lagoon.resources.<requests|limits>.<cpu|memory>", label)
+		}
+		if resources == nil {
+			resources = &ResourceOverrides{}
+		}
+		var list **ResourceList
+		switch parts[0] {
+		case "requests":
+			list = &resources.Requests
+		case "limits":
+			list = &resources.Limits
+		default:
+			return nil, fmt.Errorf("unknown label %s, must be lagoon.resources.<requests|limits>.<cpu|memory>", label)
+		}
+		if *list == nil {
+			*list = &ResourceList{}
+		}
+		switch parts[1] {
+		case "cpu":
+			(*list).CPU = value
+		case "memory":
+			(*list).Memory = value
+		default:
+			return nil, fmt.Errorf("unknown label %s, must be lagoon.resources.<requests|limits>.<cpu|memory>", label)
+		}
+	}
+	return resources, nil
+}
+
+// ProbeOverridesFromLabels returns the probe overrides from the `lagoon.probes.*` labels of a docker-compose service, or nil if
+// there are none
+func ProbeOverridesFromLabels(labels map[string]string) (*ProbeOverrides, error) {
+	var probes *ProbeOverrides
+	for _, label := range sortedLabels(labels, probeLabelPrefix) {
+		parts := strings.Split(strings.TrimPrefix(label, probeLabelPrefix), ".")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("unknown label %s, must be lagoon.probes.<readiness|liveness>.<field>", label)
+		}
+		if probes == nil {
+			probes = &ProbeOverrides{}
+		}
+		var probe **ProbeOverride
+		switch parts[0] {
+		case "readiness":
+			probe = &probes.Readiness
+		case "liveness":
+			probe = &probes.Liveness
+		default:
+			return nil, fmt.Errorf("unknown label %s, must be lagoon.probes.<readiness|liveness>.<field>", label)
+		}
+		if *probe == nil {
+			*probe = &ProbeOverride{}
+		}
+		value, err := strconv.ParseInt(labels[label], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("label %s must be a number of seconds or a threshold: %v", label, err)
+		}
+		seconds := int32(value)
+		switch parts[1] {
+		case "initialDelaySeconds":
+			(*probe).InitialDelaySeconds = &seconds
+		case "periodSeconds":
+			(*probe).PeriodSeconds = &seconds
+		case "timeoutSeconds":
+			(*probe).TimeoutSeconds = &seconds
+		case "successThreshold":
+			(*probe).SuccessThreshold = &seconds
+		case "failureThreshold":
+			(*probe).FailureThreshold = &seconds
+		default:
+			return nil, fmt.Errorf("unknown label %s, the probe fields are initialDelaySeconds, periodSeconds, timeoutSeconds, successThreshold and failureThreshold", label)
+		}
+	}
+	return probes, nil
+}
+
+// AutoscalingFromLabels returns the autoscaling from the `lagoon.autoscaling.*` labels of a docker-compose service, or nil if
+// there are none
+func AutoscalingFromLabels(labels map[string]string) (*Autoscaling, error) {
+	var autoscaling *Autoscaling
+	for _, label := range sortedLabels(labels, autoscalingLabelPrefix) {
+		if autoscaling == nil {
+			autoscaling = &Autoscaling{}
+		}
+		value, err := strconv.ParseInt(labels[label], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("label %s must be a number: %v", label, err)
+		}
+		number := int32(value)
+		switch strings.TrimPrefix(label, autoscalingLabelPrefix) {
+		case "minReplicas":
+			autoscaling.MinReplicas = &number
+		case "maxReplicas":
+			autoscaling.MaxReplicas = &number
+		case "targetCPUUtilization":
+			autoscaling.TargetCPUUtilization = &number
+		case "targetMemoryUtilization":
+			autoscaling.TargetMemoryUtilization = &number
+		default:
+			return nil, fmt.Errorf("unknown label %s, the autoscaling fields are minReplicas, maxReplicas, targetCPUUtilization and targetMemoryUtilization", label)
+		}
+	}
+	return autoscaling, nil
+}
+
+// sortedLabels returns the labels with the prefix, sorted so that errors are reported in the same order every time
+func sortedLabels(labels map[string]string, prefix string) []string {
+	keys := []string{}
+	for label := range labels {
+		if strings.HasPrefix(label, prefix) {
+			keys = append(keys, label)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MergeResourceOverrides returns the resource overrides with the values that are set in override replacing those in base
+func MergeResourceOverrides(base, override *ResourceOverrides) *ResourceOverrides {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	return &ResourceOverrides{
+		Requests: mergeResourceList(base.Requests, override.Requests),
+		Limits:   mergeResourceList(base.Limits, override.Limits),
+	}
+}
+
+func mergeResourceList(base, override *ResourceList) *ResourceList {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	merged := *base
+	if override.CPU != "" {
+		merged.CPU = override.CPU
+	}
+	if override.Memory != "" {
+		merged.Memory = override.Memory
+	}
+	return &merged
+}
+
+// MergeProbeOverrides returns the probe overrides with the values that are set in override replacing those in base
+func MergeProbeOverrides(base, override *ProbeOverrides) *ProbeOverrides {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	return &ProbeOverrides{
+		Readiness: mergeProbeOverride(base.Readiness, override.Readiness),
+		Liveness:  mergeProbeOverride(base.Liveness, override.Liveness),
+	}
+}
+
+func mergeProbeOverride(base, override *ProbeOverride) *ProbeOverride {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	merged := *base
+	if override.InitialDelaySeconds != nil {
+		merged.InitialDelaySeconds = override.InitialDelaySeconds
+	}
+	if override.PeriodSeconds != nil {
+		merged.PeriodSeconds = override.PeriodSeconds
+	}
+	if override.TimeoutSeconds != nil {
+		merged.TimeoutSeconds = override.TimeoutSeconds
+	}
+	if override.SuccessThreshold != nil {
+		merged.SuccessThreshold = override.SuccessThreshold
+	}
+	if override.FailureThreshold != nil {
+		merged.FailureThreshold = override.FailureThreshold
+	}
+	return &merged
+}
+
+// MergeAutoscaling returns the autoscaling with the values that are set in override replacing those in base
+func MergeAutoscaling(base, override *Autoscaling) *Autoscaling {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	merged := *base
+	if override.MinReplicas != nil {
+		merged.MinReplicas = override.MinReplicas
+	}
+	if override.MaxReplicas != nil {
+		merged.MaxReplicas = override.MaxReplicas
+	}
+	if override.TargetCPUUtilization != nil {
+		merged.TargetCPUUtilization = override.TargetCPUUtilization
+	}
+	if override.TargetMemoryUtilization != nil {
+		merged.TargetMemoryUtilization = override.TargetMemoryUtilization
+	}
+	return &merged
+}
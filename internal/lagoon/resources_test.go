@@ -0,0 +1,160 @@
+package lagoon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+)
+
+func TestResourceOverridesFromLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    *ResourceOverrides
+		wantErr string
+	}{
+		{
+			name: "test1 - requests and limits",
+			labels: map[string]string{
+				"lagoon.type":                      "node",
+				"lagoon.resources.requests.cpu":    "100m",
+				"lagoon.resources.requests.memory": "256Mi",
+				"lagoon.resources.limits.memory":   "1Gi",
+			},
+			want: &ResourceOverrides{
+				Requests: &ResourceList{CPU: "100m", Memory: "256Mi"},
+				Limits:   &ResourceList{Memory: "1Gi"},
+			},
+		},
+		{
+			name: "test2 - no resource labels",
+			labels: map[string]string{
+				"lagoon.type": "node",
+			},
+			want: nil,
+		},
+		{
+			name: "test3 - unknown resource",
+			labels: map[string]string{
+				"lagoon.resources.limits.gpu": "1",
+			},
+			wantErr: "unknown label lagoon.resources.limits.gpu, must be lagoon.resources.<requests|limits>.<cpu|memory>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResourceOverridesFromLabels(tt.labels)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("ResourceOverridesFromLabels() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Errorf("ResourceOverridesFromLabels() error = nil, wantErr %v", tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResourceOverridesFromLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeOverridesFromLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    *ProbeOverrides
+		wantErr string
+	}{
+		{
+			name: "test1 - readiness and liveness",
+			labels: map[string]string{
+				"lagoon.probes.readiness.initialDelaySeconds": "30",
+				"lagoon.probes.readiness.periodSeconds":       "5",
+				"lagoon.probes.liveness.failureThreshold":     "10",
+			},
+			want: &ProbeOverrides{
+				Readiness: &ProbeOverride{InitialDelaySeconds: helpers.Int32Ptr(30), PeriodSeconds: helpers.Int32Ptr(5)},
+				Liveness:  &ProbeOverride{FailureThreshold: helpers.Int32Ptr(10)},
+			},
+		},
+		{
+			name: "test2 - unknown field",
+			labels: map[string]string{
+				"lagoon.probes.liveness.delay": "10",
+			},
+			wantErr: "unknown label lagoon.probes.liveness.delay, the probe fields are initialDelaySeconds, periodSeconds, timeoutSeconds, successThreshold and failureThreshold",
+		},
+		{
+			name: "test3 - not a number",
+			labels: map[string]string{
+				"lagoon.probes.readiness.timeoutSeconds": "5s",
+			},
+			wantErr: `label lagoon.probes.readiness.timeoutSeconds must be a number of seconds or a threshold: strconv.ParseInt: parsing "5s": invalid syntax`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProbeOverridesFromLabels(tt.labels)
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("ProbeOverridesFromLabels() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Errorf("ProbeOverridesFromLabels() error = nil, wantErr %v", tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProbeOverridesFromLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeResourceOverrides(t *testing.T) {
+	base := &ResourceOverrides{
+		Requests: &ResourceList{CPU: "100m", Memory: "256Mi"},
+	}
+	override := &ResourceOverrides{
+		Requests: &ResourceList{Memory: "512Mi"},
+		Limits:   &ResourceList{Memory: "1Gi"},
+	}
+	want := &ResourceOverrides{
+		Requests: &ResourceList{CPU: "100m", Memory: "512Mi"},
+		Limits:   &ResourceList{Memory: "1Gi"},
+	}
+	if got := MergeResourceOverrides(base, override); !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeResourceOverrides() = %v, want %v", got, want)
+	}
+	// the base isn't changed
+	if base.Requests.Memory != "256Mi" {
+		t.Errorf("MergeResourceOverrides() changed the base to %v", base.Requests)
+	}
+}
+
+func TestAutoscalingFromLabels(t *testing.T) {
+	got, err := AutoscalingFromLabels(map[string]string{
+		"lagoon.autoscaling.minReplicas":          "2",
+		"lagoon.autoscaling.maxReplicas":          "6",
+		"lagoon.autoscaling.targetCPUUtilization": "75",
+	})
+	if err != nil {
+		t.Fatalf("AutoscalingFromLabels() error = %v", err)
+	}
+	want := &Autoscaling{
+		MinReplicas:          helpers.Int32Ptr(2),
+		MaxReplicas:          helpers.Int32Ptr(6),
+		TargetCPUUtilization: helpers.Int32Ptr(75),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AutoscalingFromLabels() = %v, want %v", got, want)
+	}
+	if _, err := AutoscalingFromLabels(map[string]string{"lagoon.autoscaling.replicas": "2"}); err == nil {
+		t.Errorf("AutoscalingFromLabels() error = nil, want an unknown label error")
+	}
+}
@@ -121,6 +121,14 @@ func (r *Route) UnmarshalJSON(data []byte) error {
 	return json.Unmarshal(data, &r.Ingresses)
 }
 
+// MarshalJSON implements json.Marshaler, a route is marshalled back into the same form that it was unmarshalled from.
+func (r Route) MarshalJSON() ([]byte, error) {
+	if r.Name != "" {
+		return json.Marshal(r.Name)
+	}
+	return json.Marshal(r.Ingresses)
+}
+
 // GenerateRoutesV2 generate routesv2 definitions from lagoon route mappings
 func GenerateRoutesV2(yamlRoutes *RoutesV2, routeMap map[string][]Route, variables []EnvironmentVariable, defaultIngressClass, secretPrefix string, activeStandby bool) error {
 	for rName, lagoonRoutes := range routeMap {
@@ -0,0 +1,375 @@
+package lagoon
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon/yamlv2"
+	"github.com/xeipuuv/gojsonschema"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// the severities of a schema error, unknown fields are warnings unless strict validation is used
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// the bool fields that can also be defined as a string in .lagoon.yml, these are converted by the custom unmarshalers
+var coercedBoolFields = map[string]bool{
+	"Autogenerate.Enabled":           true,
+	"Autogenerate.AllowPullRequests": true,
+	"Autogenerate.TLSAcme":           true,
+	"EnvironmentVariables.GitSHA":    true,
+	"Ingress.TLSAcme":                true,
+	"Fastly.Watch":                   true,
+}
+
+// the strings that strconv.ParseBool accepts
+const boolStringPattern = "^(1|t|T|TRUE|true|True|0|f|F|FALSE|false|False)$"
+
+// SchemaError is a problem found when validating a .lagoon.yml file against the schema
+type SchemaError struct {
+	File     string `json:"file,omitempty"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+func (e SchemaError) String() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Path, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// GenerateSchema generates the JSON Schema for the version 1 .lagoon.yml file from the YAML struct
+func GenerateSchema() map[string]interface{} {
+	schema := schemaForType(reflect.TypeOf(YAML{}))
+	// the version isn't part of the YAML, a file without a version is version 1
+	schema["properties"].(map[string]interface{})["version"] = map[string]interface{}{
+		"type": "integer",
+		"enum": []int{1},
+	}
+	// the deprecated fields are reported by the deprecation warnings, so the schema allows them
+	for _, d := range deprecatedFields {
+		allowField(schema, d.path)
+	}
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = ".lagoon.yml"
+	return schema
+}
+
+// allowField adds a property that allows any value to the schema at the path, the * matches any key
+func allowField(schema map[string]interface{}, path []string) {
+	for _, p := range path[:len(path)-1] {
+		var next map[string]interface{}
+		if p == "*" {
+			next, _ = schema["additionalProperties"].(map[string]interface{})
+		} else {
+			properties, _ := schema["properties"].(map[string]interface{})
+			next, _ = properties[p].(map[string]interface{})
+		}
+		if next == nil {
+			return
+		}
+		schema = next
+	}
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		properties[path[len(path)-1]] = map[string]interface{}{}
+	}
+}
+
+// GenerateSchemaV2 generates the JSON Schema for the version 2 .lagoon.yml file from the yamlv2.YAML struct
+func GenerateSchemaV2() map[string]interface{} {
+	schema := schemaForType(reflect.TypeOf(yamlv2.YAML{}))
+	schema["properties"].(map[string]interface{})["version"] = map[string]interface{}{
+		"type": "integer",
+		"enum": []int{yamlv2.Version},
+	}
+	schema["required"] = []string{"version"}
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = ".lagoon.yml version 2"
+	return schema
+}
+
+// GenerateSchemaJSON generates the JSON Schema for a version of the .lagoon.yml file as indented json
+func GenerateSchemaJSON(version int) ([]byte, error) {
+	switch version {
+	case 1:
+		return json.MarshalIndent(GenerateSchema(), "", "  ")
+	case yamlv2.Version:
+		return json.MarshalIndent(GenerateSchemaV2(), "", "  ")
+	}
+	return nil, fmt.Errorf("unsupported .lagoon.yml version %d, the supported versions are 1 and %d", version, yamlv2.Version)
+}
+
+func schemaForType(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// a route is either the name of a domain, or a map of the domain to its ingress configuration
+	if t == reflect.TypeOf(Route{}) {
+		return map[string]interface{}{
+			"type":                 []string{"string", "object"},
+			"additionalProperties": schemaForType(reflect.TypeOf(Ingress{})),
+		}
+	}
+	// an empty key in yaml is null, which unmarshals to the zero value of maps, slices and structs
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		addStructProperties(t, properties)
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": schemaForType(t.Elem()),
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  []string{"array", "null"},
+			"items": schemaForType(t.Elem()),
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{}
+}
+
+func addStructProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			addStructProperties(field.Type, properties)
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldSchema := schemaForType(field.Type)
+		if coercedBoolFields[fmt.Sprintf("%s.%s", t.Name(), field.Name)] {
+			fieldSchema = map[string]interface{}{
+				"type":    []string{"boolean", "string"},
+				"pattern": boolStringPattern,
+			}
+		}
+		properties[name] = fieldSchema
+	}
+}
+
+// ValidateSchema validates the contents of a .lagoon.yml file against the schema and returns all the errors found along with
+// the line and column they were found at. If the file contains a polysite block for the project, it is validated too.
+// Unknown fields are returned as warnings, unless strict is true. A version 2 file is validated against the version 2 schema,
+// where unknown fields are always errors.
+func ValidateSchema(data []byte, project string, strict bool) ([]SchemaError, error) {
+	return validateSchema(data, project, strict, false)
+}
+
+// ValidateOverrideSchema validates the contents of a .lagoon.override.yml or LAGOON_YAML_OVERRIDE the same as ValidateSchema,
+// but allows the merge markers, and doesn't validate any values that are marked to be deleted.
+func ValidateOverrideSchema(data []byte, project string, strict bool) ([]SchemaError, error) {
+	return validateSchema(data, project, strict, true)
+}
+
+func validateSchema(data []byte, project string, strict, override bool) ([]SchemaError, error) {
+	node := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(jsonData, &doc); err != nil {
+		// an empty file, or a file that isn't a map
+		if string(jsonData) == "null" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("the .lagoon.yml must be a map: %v", err)
+	}
+	version, err := LagoonYAMLVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if version == yamlv2.Version {
+		// version 2 has no polysite blocks or merge markers, and unknown fields are always errors
+		errs, err := validateAgainstSchema(gojsonschema.NewGoLoader(GenerateSchemaV2()), doc, node, nil, true, false)
+		if err != nil {
+			return nil, err
+		}
+		sortSchemaErrors(errs)
+		return errs, nil
+	}
+	schema := gojsonschema.NewGoLoader(GenerateSchema())
+	errs := []SchemaError{}
+	if polysite, ok := doc[project]; ok && project != "" {
+		// a polysite block is validated on its own, it is a complete .lagoon.yml for the project
+		// and the blocks of the other polysite projects are not used
+		for _, other := range polysiteProjects(doc) {
+			delete(doc, other)
+		}
+		polysiteErrs, err := validateAgainstSchema(schema, polysite, node, []string{project}, strict, override)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, polysiteErrs...)
+	}
+	rootErrs, err := validateAgainstSchema(schema, doc, node, nil, strict, override)
+	if err != nil {
+		return nil, err
+	}
+	errs = append(errs, rootErrs...)
+	sortSchemaErrors(errs)
+	return errs, nil
+}
+
+func sortSchemaErrors(errs []SchemaError) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+}
+
+func validateAgainstSchema(schema gojsonschema.JSONLoader, doc interface{}, node *yamlv3.Node, prefix []string, strict, override bool) ([]SchemaError, error) {
+	validate := doc
+	if override {
+		// the markers are removed without removing the values they are in, so that the paths still match the yaml
+		validate = withoutMarkerKeys(doc)
+	}
+	result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(validate))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't validate against the schema: %v", err)
+	}
+	errs := []SchemaError{}
+	for _, re := range result.Errors() {
+		// the context is used with a delimiter that can't exist in a key, as domains in routes contain dots
+		path := append([]string{}, prefix...)
+		for _, p := range strings.Split(re.Context().String("\x00"), "\x00") {
+			if p != gojsonschema.STRING_CONTEXT_ROOT {
+				path = append(path, p)
+			}
+		}
+		if override && isMarkedDeleted(doc, path[len(prefix):]) {
+			continue
+		}
+		severity := SeverityError
+		keyNode := false
+		if re.Type() == "additional_property_not_allowed" {
+			if property, ok := re.Details()["property"].(string); ok {
+				path = append(path, property)
+				keyNode = true
+			}
+			if !strict {
+				severity = SeverityWarning
+			}
+		}
+		line, column := nodePosition(node, path, keyNode)
+		errs = append(errs, SchemaError{
+			Path:     FormatPath(path),
+			Line:     line,
+			Column:   column,
+			Message:  re.Description(),
+			Severity: severity,
+		})
+	}
+	return errs, nil
+}
+
+// nodePosition finds the line and column of the yaml node at the path, if the key is requested then the position of the
+// key of the last element of the path is returned instead of its value. If the path isn't in the yaml, the position of the
+// deepest node that is in the path is returned.
+func nodePosition(node *yamlv3.Node, path []string, key bool) (int, int) {
+	line, column, _ := findNodePosition(node, path, key)
+	return line, column
+}
+
+// findNodePosition is nodePosition, and also returns if the whole path was found
+func findNodePosition(node *yamlv3.Node, path []string, key bool) (int, int, bool) {
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for i, p := range path {
+		var next *yamlv3.Node
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			for c := 0; c+1 < len(node.Content); c += 2 {
+				if node.Content[c].Value == p {
+					if key && i == len(path)-1 {
+						return node.Content[c].Line, node.Content[c].Column, true
+					}
+					next = node.Content[c+1]
+					break
+				}
+			}
+		case yamlv3.SequenceNode:
+			if idx, err := strconv.Atoi(p); err == nil && idx < len(node.Content) {
+				next = node.Content[idx]
+			}
+		}
+		if next == nil {
+			return node.Line, node.Column, false
+		}
+		node = next
+		// follow aliases to the anchored node
+		for node.Kind == yamlv3.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+	}
+	return node.Line, node.Column, true
+}
+
+// LocatePath finds the line and column of the path in the contents of a .lagoon.yml file, and returns if it was found. If
+// the file has a polysite block for the project, the path is looked for in the polysite block first.
+func LocatePath(data []byte, project string, path []string) (int, int, bool) {
+	node := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, node); err != nil {
+		return 0, 0, false
+	}
+	if project != "" {
+		if line, column, ok := findNodePosition(node, append([]string{project}, path...), false); ok {
+			return line, column, true
+		}
+	}
+	return findNodePosition(node, path, false)
+}
+
+// FormatPath joins the keys of a path in a .lagoon.yml into a readable form, quoting any key that contains a dot
+func FormatPath(path []string) string {
+	parts := []string{}
+	for _, p := range path {
+		if strings.Contains(p, ".") {
+			p = strconv.Quote(p)
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) == 0 {
+		return "(root)"
+	}
+	return strings.Join(parts, ".")
+}
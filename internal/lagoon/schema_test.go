@@ -0,0 +1,104 @@
+package lagoon
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		project  string
+		strict   bool
+		override bool
+		want     []SchemaError
+		wantErr  bool
+	}{
+		{
+			name: "test1 - unknown fields are warnings",
+			file: "test-resources/lagoon-yaml/schema/lagoon.yml",
+			want: []SchemaError{
+				{Path: "routes.autogenerate.enabled", Line: 4, Column: 14, Message: "Does not match pattern '^(1|t|T|TRUE|true|True|0|f|F|FALSE|false|False)$'", Severity: SeverityError},
+				{Path: "environments.main.autogenerateroutes", Line: 7, Column: 5, Message: "Additional property autogenerateroutes is not allowed", Severity: SeverityWarning},
+				{Path: "environments.main.routes.0.nginx.0.\"a.example.com\".tls_acme", Line: 11, Column: 15, Message: "Additional property tls_acme is not allowed", Severity: SeverityWarning},
+				{Path: "environments.main.routes.0.nginx.0.\"a.example.com\".hstsMaxAge", Line: 12, Column: 27, Message: "Invalid type. Expected: integer, given: string", Severity: SeverityError},
+				{Path: "environments.main.cronjobs.0.shell", Line: 18, Column: 9, Message: "Additional property shell is not allowed", Severity: SeverityWarning},
+				{Path: "tasks.post-rollout.0.run.weight", Line: 25, Column: 17, Message: "Invalid type. Expected: integer, given: string", Severity: SeverityError},
+			},
+		},
+		{
+			name:   "test2 - unknown fields are errors in strict mode",
+			file:   "test-resources/lagoon-yaml/schema/lagoon.yml",
+			strict: true,
+			want: []SchemaError{
+				{Path: "routes.autogenerate.enabled", Line: 4, Column: 14, Message: "Does not match pattern '^(1|t|T|TRUE|true|True|0|f|F|FALSE|false|False)$'", Severity: SeverityError},
+				{Path: "environments.main.autogenerateroutes", Line: 7, Column: 5, Message: "Additional property autogenerateroutes is not allowed", Severity: SeverityError},
+				{Path: "environments.main.routes.0.nginx.0.\"a.example.com\".tls_acme", Line: 11, Column: 15, Message: "Additional property tls_acme is not allowed", Severity: SeverityError},
+				{Path: "environments.main.routes.0.nginx.0.\"a.example.com\".hstsMaxAge", Line: 12, Column: 27, Message: "Invalid type. Expected: integer, given: string", Severity: SeverityError},
+				{Path: "environments.main.cronjobs.0.shell", Line: 18, Column: 9, Message: "Additional property shell is not allowed", Severity: SeverityError},
+				{Path: "tasks.post-rollout.0.run.weight", Line: 25, Column: 17, Message: "Invalid type. Expected: integer, given: string", Severity: SeverityError},
+			},
+		},
+		{
+			name:    "test3 - polysite block is validated, other polysite projects are ignored",
+			file:    "test-resources/lagoon-yaml/schema/lagoon.polysite.yml",
+			project: "example-project",
+			want: []SchemaError{
+				{Path: "example-project.environments.main.routes.0.node.0.\"example.com\".tls_acme", Line: 8, Column: 17, Message: "Additional property tls_acme is not allowed", Severity: SeverityWarning},
+			},
+		},
+		{
+			name: "test4 - booleans represented as strings are valid",
+			file: "test-resources/lagoon-yaml/test1/lagoon.yml",
+			want: []SchemaError{},
+		},
+		{
+			name:     "test5 - merge markers are allowed in an override",
+			file:     "test-resources/lagoon-yaml/schema/lagoon.override.yml",
+			override: true,
+			want: []SchemaError{
+				{Path: "environments.main.cronjobs.0.shell", Line: 13, Column: 9, Message: "Additional property shell is not allowed", Severity: SeverityWarning},
+			},
+		},
+		{
+			name: "test6 - merge markers are not allowed in a .lagoon.yml",
+			file: "test-resources/lagoon-yaml/schema/lagoon.override.yml",
+			want: []SchemaError{
+				{Path: "environments.main.routes.0.nginx.0.\"a.example.com\".$delete", Line: 6, Column: 15, Message: "Additional property $delete is not allowed", Severity: SeverityWarning},
+				{Path: "environments.main.routes.1.varnish", Line: 8, Column: 11, Message: "Invalid type. Expected: [array,null], given: object", Severity: SeverityError},
+				{Path: "environments.main.cronjobs.0.$replace", Line: 11, Column: 9, Message: "Additional property $replace is not allowed", Severity: SeverityWarning},
+				{Path: "environments.main.cronjobs.0.shell", Line: 13, Column: 9, Message: "Additional property shell is not allowed", Severity: SeverityWarning},
+			},
+		},
+		{
+			name:   "test7 - fastly api secrets are known, deprecated fields are left to the deprecation warnings",
+			file:   "test-resources/lagoon-yaml/schema/lagoon.fastly.yml",
+			strict: true,
+			want: []SchemaError{
+				{Path: "fastly.api-secrets.1.token", Line: 11, Column: 7, Message: "Additional property token is not allowed", Severity: SeverityError},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("couldn't read %v: %v", tt.file, err)
+			}
+			validate := ValidateSchema
+			if tt.override {
+				validate = ValidateOverrideSchema
+			}
+			got, err := validate(data, tt.project, tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchema() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
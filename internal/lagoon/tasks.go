@@ -13,6 +13,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -33,7 +34,7 @@ type Task struct {
 	Weight              int    `json:"weight"`
 	ScaleWaitTime       int    `json:"scaleWaitTime"`
 	ScaleMaxIterations  int    `json:"scaleMaxIterations"`
-	RequiresEnvironment bool   `json:"requiresEnvironment"`
+	RequiresEnvironment bool   `json:"requiresEnvironment,omitempty"`
 }
 
 // NewTask .
@@ -76,7 +77,17 @@ func GetK8sClient(config *rest.Config) (*kubernetes.Clientset, error) {
 	return clientset, nil
 }
 
-func getConfig() (*rest.Config, error) {
+// GetDynamicClient .
+func GetDynamicClient(config *rest.Config) (dynamic.Interface, error) {
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// GetConfig returns the rest config from the KUBECONFIG if defined, or from the deployer token within the build pod
+func GetConfig() (*rest.Config, error) {
 	var kubeconfig *string
 	kubeconfig = new(string)
 	*kubeconfig = helpers.GetEnv("KUBECONFIG", "", false)
@@ -144,7 +155,7 @@ func ExecTaskInPod(
 	tty bool,
 ) error {
 
-	restCfg, err := getConfig()
+	restCfg, err := GetConfig()
 	if err != nil {
 		return err
 	}
@@ -310,7 +321,7 @@ func unidleReplicas(deploy appsv1.Deployment) int {
 var NamespaceUnidlingTimeoutError = errors.New("Unable to scale idled deployments due to timeout")
 
 func UnidleNamespace(ctx context.Context, namespace string, retries int, waitTime int) error {
-	restCfg, err := getConfig()
+	restCfg, err := GetConfig()
 	if err != nil {
 		return err
 	}
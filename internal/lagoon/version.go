@@ -0,0 +1,494 @@
+package lagoon
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon/yamlv2"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// LagoonYAMLVersion returns the version of a .lagoon.yml, a file without a version is version 1
+func LagoonYAMLVersion(data []byte) (int, error) {
+	versioned := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &versioned); err != nil {
+		return 0, err
+	}
+	version, ok := versioned["version"]
+	if !ok || version == nil {
+		return 1, nil
+	}
+	v, ok := version.(float64)
+	if !ok || (v != 1 && v != yamlv2.Version) {
+		return 0, fmt.Errorf("unsupported .lagoon.yml version %v, the supported versions are 1 and %d", version, yamlv2.Version)
+	}
+	return int(v), nil
+}
+
+// unmarshalLagoonYAMLV2 strictly unmarshals a version 2 .lagoon.yml and converts it into a YAML
+func unmarshalLagoonYAMLV2(data []byte, l *YAML) error {
+	v2 := &yamlv2.YAML{}
+	if err := yaml.UnmarshalStrict(data, v2); err != nil {
+		return err
+	}
+	*l = FromV2(v2)
+	return nil
+}
+
+// FromV2 converts a version 2 .lagoon.yml into a YAML
+func FromV2(v *yamlv2.YAML) YAML {
+	l := YAML{DockerComposeYAML: v.DockerComposeYAML}
+	if v.Environments != nil {
+		l.Environments = Environments{}
+		for name, environment := range v.Environments {
+			l.Environments[name] = environmentFromV2(environment)
+		}
+	}
+	if v.ProductionRoutes != nil {
+		l.ProductionRoutes = &ProductionRoutes{}
+		if v.ProductionRoutes.Active != nil {
+			active := environmentFromV2(*v.ProductionRoutes.Active)
+			l.ProductionRoutes.Active = &active
+		}
+		if v.ProductionRoutes.Standby != nil {
+			standby := environmentFromV2(*v.ProductionRoutes.Standby)
+			l.ProductionRoutes.Standby = &standby
+		}
+	}
+	if v.Tasks != nil {
+		l.Tasks.Prerollout = tasksFromV2(v.Tasks.PreRollout)
+		l.Tasks.Postrollout = tasksFromV2(v.Tasks.PostRollout)
+	}
+	if v.Routes != nil && v.Routes.Autogenerate != nil {
+		a := v.Routes.Autogenerate
+		l.Routes.Autogenerate = Autogenerate{
+			Enabled:             a.Enabled,
+			AllowPullRequests:   a.AllowPullRequests,
+			Insecure:            a.Insecure,
+			Prefixes:            a.Prefixes,
+			TLSAcme:             a.TLSAcme,
+			IngressClass:        a.IngressClass,
+			RequestVerification: a.DisableRequestVerification,
+			PathRoutes:          autogeneratePathRoutesFromV2(a.PathRoutes),
+		}
+	}
+	if v.BackupRetention != nil && v.BackupRetention.Production != nil {
+		l.BackupRetention.Production = Retention(*v.BackupRetention.Production)
+	}
+	if v.BackupSchedule != nil {
+		l.BackupSchedule.Production = v.BackupSchedule.Production
+	}
+	if v.EnvironmentVariables != nil {
+		l.EnvironmentVariables.GitSHA = v.EnvironmentVariables.GitSHA
+	}
+	if v.ContainerRegistries != nil {
+		l.ContainerRegistries = map[string]ContainerRegistry{}
+		for name, registry := range v.ContainerRegistries {
+			l.ContainerRegistries[name] = ContainerRegistry(registry)
+		}
+	}
+	if v.Fastly != nil {
+		l.Fastly = &FastlyConfiguration{}
+		for _, secret := range v.Fastly.APISecrets {
+			l.Fastly.APISecrets = append(l.Fastly.APISecrets, FastlyAPISecret(secret))
+		}
+	}
+	return l
+}
+
+func environmentFromV2(v yamlv2.Environment) Environment {
+	e := Environment{
+		AutogenerateRoutes:     v.AutogenerateRoutes,
+		Types:                  v.Types,
+		AutogeneratePathRoutes: autogeneratePathRoutesFromV2(v.AutogeneratePathRoutes),
+	}
+	if v.Routes != nil {
+		// routes for the same service that follow each other are kept together in the same map
+		e.Routes = []map[string][]Route{}
+		for _, r := range v.Routes {
+			route := routeFromV2(r)
+			if n := len(e.Routes); n > 0 {
+				if routes, ok := e.Routes[n-1][r.Service]; ok {
+					e.Routes[n-1][r.Service] = append(routes, route)
+					continue
+				}
+			}
+			e.Routes = append(e.Routes, map[string][]Route{r.Service: {route}})
+		}
+	}
+	for _, c := range v.Cronjobs {
+		e.Cronjobs = append(e.Cronjobs, Cronjob(c))
+	}
+	if v.Overrides != nil {
+		e.Overrides = map[string]Override{}
+		for service, o := range v.Overrides {
+			override := Override{
+				Image:          o.Image,
+				Resources:      o.Resources,
+				Probes:         o.Probes,
+				Autoscaling:    o.Autoscaling,
+				Containers:     o.Containers,
+				InitContainers: o.InitContainers,
+			}
+			if o.Build != nil {
+				override.Build = Build(*o.Build)
+			}
+			e.Overrides[service] = override
+		}
+	}
+	return e
+}
+
+func routeFromV2(r yamlv2.Route) Route {
+	ingress := Ingress{
+		TLSAcme:               r.TLSAcme,
+		Migrate:               r.Migrate,
+		Insecure:              r.Insecure,
+		MonitoringPath:        r.MonitoringPath,
+		Annotations:           r.Annotations,
+		IngressClass:          r.IngressClass,
+		HSTSEnabled:           r.HSTSEnabled,
+		HSTSMaxAge:            r.HSTSMaxAge,
+		HSTSIncludeSubdomains: r.HSTSIncludeSubdomains,
+		HSTSPreload:           r.HSTSPreload,
+		AlternativeNames:      r.AlternativeNames,
+		Wildcard:              r.Wildcard,
+		RequestVerification:   r.DisableRequestVerification,
+	}
+	if r.Fastly != nil {
+		ingress.Fastly = Fastly{ServiceID: r.Fastly.ServiceID, APISecretName: r.Fastly.APISecretName, Watch: r.Fastly.Watch}
+	}
+	for _, pr := range r.PathRoutes {
+		ingress.PathRoutes = append(ingress.PathRoutes, PathRoute(pr))
+	}
+	if reflect.DeepEqual(ingress, Ingress{}) {
+		// a route without any configuration is only a domain
+		return Route{Name: r.Domain}
+	}
+	return Route{Ingresses: map[string]Ingress{r.Domain: ingress}}
+}
+
+func autogeneratePathRoutesFromV2(v []yamlv2.AutogeneratePathRoute) []AutogeneratePathRoute {
+	var pathRoutes []AutogeneratePathRoute
+	for _, pr := range v {
+		pathRoutes = append(pathRoutes, AutogeneratePathRoute{
+			PathRoute:   PathRoute{ToService: pr.ToService, Path: pr.Path},
+			FromService: pr.FromService,
+		})
+	}
+	return pathRoutes
+}
+
+func tasksFromV2(v []yamlv2.Task) []TaskRun {
+	var tasks []TaskRun
+	for _, t := range v {
+		tasks = append(tasks, TaskRun{Run: Task(t)})
+	}
+	return tasks
+}
+
+// ToV2 converts a YAML into a version 2 .lagoon.yml, any warnings about the conversion are recorded in the warnings
+// collector, which can be nil
+func ToV2(l *YAML, warnings *Warnings) yamlv2.YAML {
+	return toV2(l, &v2Converter{warnings: warnings})
+}
+
+func toV2(l *YAML, c *v2Converter) yamlv2.YAML {
+	v := yamlv2.YAML{
+		Version:           yamlv2.Version,
+		DockerComposeYAML: l.DockerComposeYAML,
+	}
+	if l.Environments != nil {
+		v.Environments = map[string]yamlv2.Environment{}
+		for name, environment := range l.Environments {
+			v.Environments[name] = c.environment([]string{"environments", name}, environment)
+		}
+	}
+	if l.ProductionRoutes != nil {
+		v.ProductionRoutes = &yamlv2.ProductionRoutes{}
+		if l.ProductionRoutes.Active != nil {
+			active := c.environment([]string{"production_routes", "active"}, *l.ProductionRoutes.Active)
+			v.ProductionRoutes.Active = &active
+		}
+		if l.ProductionRoutes.Standby != nil {
+			standby := c.environment([]string{"production_routes", "standby"}, *l.ProductionRoutes.Standby)
+			v.ProductionRoutes.Standby = &standby
+		}
+	}
+	if l.Tasks.Prerollout != nil || l.Tasks.Postrollout != nil {
+		v.Tasks = &yamlv2.Tasks{
+			PreRollout:  tasksToV2(l.Tasks.Prerollout),
+			PostRollout: tasksToV2(l.Tasks.Postrollout),
+		}
+	}
+	if a := l.Routes.Autogenerate; !reflect.DeepEqual(a, Autogenerate{}) {
+		v.Routes = &yamlv2.Routes{Autogenerate: &yamlv2.Autogenerate{
+			Enabled:                    a.Enabled,
+			AllowPullRequests:          a.AllowPullRequests,
+			Insecure:                   a.Insecure,
+			Prefixes:                   a.Prefixes,
+			TLSAcme:                    a.TLSAcme,
+			IngressClass:               a.IngressClass,
+			DisableRequestVerification: a.RequestVerification,
+			PathRoutes:                 autogeneratePathRoutesToV2(a.PathRoutes),
+		}}
+	}
+	if r := l.BackupRetention.Production; !reflect.DeepEqual(r, Retention{}) {
+		retention := yamlv2.Retention(r)
+		v.BackupRetention = &yamlv2.BackupRetention{Production: &retention}
+	}
+	if l.BackupSchedule.Production != "" {
+		v.BackupSchedule = &yamlv2.BackupSchedule{Production: l.BackupSchedule.Production}
+	}
+	if l.EnvironmentVariables.GitSHA != nil {
+		v.EnvironmentVariables = &yamlv2.EnvironmentVariables{GitSHA: l.EnvironmentVariables.GitSHA}
+	}
+	if l.ContainerRegistries != nil {
+		v.ContainerRegistries = map[string]yamlv2.ContainerRegistry{}
+		for name, registry := range l.ContainerRegistries {
+			v.ContainerRegistries[name] = yamlv2.ContainerRegistry(registry)
+		}
+	}
+	if l.Fastly != nil {
+		v.Fastly = &yamlv2.FastlyConfiguration{}
+		for _, secret := range l.Fastly.APISecrets {
+			v.Fastly.APISecrets = append(v.Fastly.APISecrets, yamlv2.FastlyAPISecret(secret))
+		}
+	}
+	return v
+}
+
+// v2Converter records the warnings found while converting, if the node of the original file is known then the warnings
+// are positioned in it
+type v2Converter struct {
+	warnings *Warnings
+	source   string
+	node     *yamlv3.Node
+}
+
+func (c *v2Converter) warn(path []string, format string, args ...interface{}) {
+	if c.warnings == nil {
+		return
+	}
+	w := Warning{
+		File:    c.source,
+		Path:    FormatPath(path),
+		Message: fmt.Sprintf(format, args...),
+	}
+	if c.node != nil {
+		w.Line, w.Column = nodePosition(c.node, path, false)
+	}
+	*c.warnings = append(*c.warnings, w)
+}
+
+func (c *v2Converter) environment(path []string, e Environment) yamlv2.Environment {
+	v := yamlv2.Environment{
+		AutogenerateRoutes:     e.AutogenerateRoutes,
+		Types:                  e.Types,
+		AutogeneratePathRoutes: autogeneratePathRoutesToV2(e.AutogeneratePathRoutes),
+	}
+	for idx, routeMap := range e.Routes {
+		services := []string{}
+		for service := range routeMap {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		if len(services) > 1 {
+			c.warn(append(append([]string{}, path...), "routes", strconv.Itoa(idx)),
+				"has more than one service, the routes are converted in the order %v", services)
+		}
+		for _, service := range services {
+			for ridx, route := range routeMap[service] {
+				v.Routes = append(v.Routes, c.routes(append(append([]string{}, path...), "routes", strconv.Itoa(idx), service, strconv.Itoa(ridx)), service, route)...)
+			}
+		}
+	}
+	for _, cronjob := range e.Cronjobs {
+		v.Cronjobs = append(v.Cronjobs, yamlv2.Cronjob(cronjob))
+	}
+	if e.Overrides != nil {
+		v.Overrides = map[string]yamlv2.Override{}
+		for service, o := range e.Overrides {
+			override := yamlv2.Override{
+				Image:          o.Image,
+				Resources:      o.Resources,
+				Probes:         o.Probes,
+				Autoscaling:    o.Autoscaling,
+				Containers:     o.Containers,
+				InitContainers: o.InitContainers,
+			}
+			if o.Build != (Build{}) {
+				build := yamlv2.Build(o.Build)
+				override.Build = &build
+			}
+			v.Overrides[service] = override
+		}
+	}
+	return v
+}
+
+func (c *v2Converter) routes(path []string, service string, route Route) []yamlv2.Route {
+	if route.Name != "" {
+		return []yamlv2.Route{{Service: service, Domain: route.Name}}
+	}
+	domains := []string{}
+	for domain := range route.Ingresses {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	if len(domains) > 1 {
+		// the generator only uses one of the domains of a route, but it isn't possible to know which one was intended
+		c.warn(path, "has more than one domain, version 1 only uses one of them but all of them are converted into separate routes: %v", domains)
+	}
+	routes := []yamlv2.Route{}
+	for _, domain := range domains {
+		ingress := route.Ingresses[domain]
+		r := yamlv2.Route{
+			Service:                    service,
+			Domain:                     domain,
+			TLSAcme:                    ingress.TLSAcme,
+			Migrate:                    ingress.Migrate,
+			Insecure:                   ingress.Insecure,
+			MonitoringPath:             ingress.MonitoringPath,
+			Annotations:                ingress.Annotations,
+			IngressClass:               ingress.IngressClass,
+			HSTSEnabled:                ingress.HSTSEnabled,
+			HSTSMaxAge:                 ingress.HSTSMaxAge,
+			HSTSIncludeSubdomains:      ingress.HSTSIncludeSubdomains,
+			HSTSPreload:                ingress.HSTSPreload,
+			AlternativeNames:           ingress.AlternativeNames,
+			Wildcard:                   ingress.Wildcard,
+			DisableRequestVerification: ingress.RequestVerification,
+		}
+		if ingress.Fastly != (Fastly{}) {
+			r.Fastly = &yamlv2.Fastly{ServiceID: ingress.Fastly.ServiceID, APISecretName: ingress.Fastly.APISecretName, Watch: ingress.Fastly.Watch}
+		}
+		for _, pr := range ingress.PathRoutes {
+			r.PathRoutes = append(r.PathRoutes, yamlv2.PathRoute(pr))
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+func autogeneratePathRoutesToV2(pathRoutes []AutogeneratePathRoute) []yamlv2.AutogeneratePathRoute {
+	var v []yamlv2.AutogeneratePathRoute
+	for _, pr := range pathRoutes {
+		v = append(v, yamlv2.AutogeneratePathRoute{FromService: pr.FromService, ToService: pr.ToService, Path: pr.Path})
+	}
+	return v
+}
+
+func tasksToV2(tasks []TaskRun) []yamlv2.Task {
+	var v []yamlv2.Task
+	for _, t := range tasks {
+		v = append(v, yamlv2.Task(t.Run))
+	}
+	return v
+}
+
+// ConvertLagoonYAML converts a version 1 .lagoon.yml into a version 2 .lagoon.yml. If the file has a polysite block for the
+// project, the resulting file is the .lagoon.yml for that project. Anything that could not be converted exactly, such as
+// strings that are converted to booleans or fields that are dropped, is returned as a warning.
+func ConvertLagoonYAML(source string, data []byte, project string) ([]byte, Warnings, error) {
+	version, err := LagoonYAMLVersion(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version != 1 {
+		return nil, nil, fmt.Errorf("%s is already version %d", source, version)
+	}
+	warnings := Warnings{}
+	l := &YAML{}
+	if err := unmarshalLagoonYAML(source, data, l, project, &warnings); err != nil {
+		return nil, nil, err
+	}
+	// unknown fields are ignored by version 1, but they can't be converted
+	schemaErrors, err := ValidateSchema(data, project, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	warned := map[string]bool{}
+	for _, w := range warnings {
+		warned[w.Path] = true
+	}
+	for _, e := range schemaErrors {
+		if e.Severity == SeverityError {
+			return nil, nil, fmt.Errorf("%s is not valid: %s", source, e.String())
+		}
+		if warned[e.Path] {
+			// deprecated fields are already warned about
+			continue
+		}
+		warnings = append(warnings, Warning{
+			File:    source,
+			Path:    e.Path,
+			Line:    e.Line,
+			Column:  e.Column,
+			Message: "is not a known field and is not converted",
+		})
+	}
+	c := &v2Converter{warnings: &warnings, source: source}
+	if polysite, err := isPolysite(data, project); err == nil && !polysite {
+		// the paths of a polysite don't match the file, as the project block has been merged into it
+		c.node = &yamlv3.Node{}
+		if err := yamlv3.Unmarshal(data, c.node); err != nil {
+			return nil, nil, err
+		}
+	}
+	v2 := toV2(l, c)
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Line != warnings[j].Line {
+			return warnings[i].Line < warnings[j].Line
+		}
+		return warnings[i].Column < warnings[j].Column
+	})
+	converted, err := MarshalV2(&v2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return converted, warnings, nil
+}
+
+func isPolysite(data []byte, project string) (bool, error) {
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return false, err
+	}
+	_, ok := doc[project]
+	return ok && project != "", nil
+}
+
+// MarshalV2 marshals a version 2 .lagoon.yml with the version as the first key
+func MarshalV2(v *yamlv2.YAML) ([]byte, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	node := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	root := node.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			version := append([]*yamlv3.Node{}, root.Content[i:i+2]...)
+			root.Content = append(version, append(root.Content[:i], root.Content[i+2:]...)...)
+			break
+		}
+	}
+	var out bytes.Buffer
+	encoder := yamlv3.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,284 @@
+package lagoon
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+)
+
+func TestLagoonYAMLVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "test1 - no version is version 1",
+			data: "docker-compose-yaml: docker-compose.yml\n",
+			want: 1,
+		},
+		{
+			name: "test2 - version 1",
+			data: "version: 1\ndocker-compose-yaml: docker-compose.yml\n",
+			want: 1,
+		},
+		{
+			name: "test3 - version 2",
+			data: "version: 2\ndockerComposeYaml: docker-compose.yml\n",
+			want: 2,
+		},
+		{
+			name:    "test4 - unsupported version",
+			data:    "version: 3\n",
+			wantErr: true,
+		},
+		{
+			name:    "test5 - version isn't a number",
+			data:    "version: two\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LagoonYAMLVersion([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LagoonYAMLVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("LagoonYAMLVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalLagoonYAMLV2(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *YAML
+		wantErr bool
+	}{
+		{
+			name: "test1 - routes for the same service are grouped",
+			data: `version: 2
+dockerComposeYaml: docker-compose.yml
+environments:
+  main:
+    routes:
+      - service: nginx
+        domain: a.example.com
+        tlsAcme: false
+      - service: nginx
+        domain: b.example.com
+      - service: varnish
+        domain: c.example.com
+tasks:
+  postRollout:
+    - name: drush cim
+      command: drush cim -y
+      service: cli
+`,
+			want: &YAML{
+				DockerComposeYAML: "docker-compose.yml",
+				Environments: Environments{
+					"main": {
+						Routes: []map[string][]Route{
+							{"nginx": {
+								{Ingresses: map[string]Ingress{"a.example.com": {TLSAcme: helpers.BoolPtr(false)}}},
+								{Name: "b.example.com"},
+							}},
+							{"varnish": {{Name: "c.example.com"}}},
+						},
+					},
+				},
+				Tasks: Tasks{
+					Postrollout: []TaskRun{{Run: Task{Name: "drush cim", Command: "drush cim -y", Service: "cli"}}},
+				},
+			},
+		},
+		{
+			name: "test2 - booleans can't be strings",
+			data: `version: 2
+routes:
+  autogenerate:
+    enabled: "false"
+`,
+			wantErr: true,
+		},
+		{
+			name: "test3 - unknown fields are errors",
+			data: `version: 2
+docker-compose-yaml: docker-compose.yml
+`,
+			wantErr: true,
+		},
+		{
+			name: "test4 - polysite blocks are errors",
+			data: `version: 2
+example-project:
+  environments:
+    main: {}
+`,
+			wantErr: true,
+		},
+		{
+			name: "test5 - fastly api secrets",
+			data: `version: 2
+fastly:
+  apiSecrets:
+    - name: examplecom
+      apiTokenVariableName: FASTLY_API_TOKEN
+      platformTLSConfiguration: A1bcEdFgH12eD242Sds
+`,
+			want: &YAML{
+				Fastly: &FastlyConfiguration{
+					APISecrets: []FastlyAPISecret{
+						{Name: "examplecom", APITokenVariableName: "FASTLY_API_TOKEN", PlatformTLSConfiguration: "A1bcEdFgH12eD242Sds"},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &YAML{}
+			err := unmarshalLagoonYAML("lagoon.yml", []byte(tt.data), got, "example-project", nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unmarshalLagoonYAML() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tt.want)
+				t.Errorf("unmarshalLagoonYAML() = %v, want %v", string(gotJSON), string(wantJSON))
+			}
+		})
+	}
+}
+
+func TestConvertLagoonYAML(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		project      string
+		want         string
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{
+			name: "test1 - everything that can be converted",
+			file: "test-resources/lagoon-yaml/convert/lagoon.yml",
+			want: "test-resources/lagoon-yaml/convert/lagoon.v2.yml",
+			wantWarnings: []string{
+				"environment_variables.git_sha",
+				"routes.insecure",
+				"routes.autogenerate.enabled",
+				"environments.main.routes.0",
+				"environments.main.routes.0.nginx.0.\"a.example.com\".tls-acme",
+				"environments.main.routes.1.nginx.0",
+				"environments.main.monitoring_urls",
+				"environments.main.unknown",
+			},
+		},
+		{
+			name:    "test2 - a version 2 file can't be converted",
+			file:    "test-resources/lagoon-yaml/convert/lagoon.v2.yml",
+			wantErr: true,
+		},
+		{
+			name:    "test3 - an invalid file can't be converted",
+			file:    "test-resources/lagoon-yaml/schema/lagoon.yml",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("couldn't read %v: %v", tt.file, err)
+			}
+			got, warnings, err := ConvertLagoonYAML(tt.file, data, tt.project)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ConvertLagoonYAML() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			want, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Fatalf("couldn't read %v: %v", tt.want, err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("ConvertLagoonYAML() = %v, want %v", string(got), string(want))
+			}
+			paths := []string{}
+			for _, w := range warnings {
+				paths = append(paths, w.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.wantWarnings) {
+				t.Errorf("ConvertLagoonYAML() warnings = %v, want %v", paths, tt.wantWarnings)
+			}
+			// the converted file must be a valid version 2 file
+			schemaErrors, err := ValidateSchema(got, tt.project, true)
+			if err != nil || len(schemaErrors) > 0 {
+				t.Errorf("ValidateSchema() of the converted file = %v, %v", schemaErrors, err)
+			}
+		})
+	}
+}
+
+func TestConvertLagoonYAMLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		project string
+	}{
+		{
+			name:    "test1 - complex",
+			file:    "../testdata/complex/lagoon.yml",
+			project: "example-project",
+		},
+		{
+			name: "test2 - routes",
+			file: "test-resources/lagoon-yaml/test2/lagoon.yml",
+		},
+		{
+			name: "test3 - booleans as strings",
+			file: "test-resources/lagoon-yaml/test3/lagoon.yml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatalf("couldn't read %v: %v", tt.file, err)
+			}
+			want := &YAML{}
+			if err := unmarshalLagoonYAML(tt.file, data, want, tt.project, nil); err != nil {
+				t.Fatalf("unmarshalLagoonYAML() error = %v", err)
+			}
+			converted, _, err := ConvertLagoonYAML(tt.file, data, tt.project)
+			if err != nil {
+				t.Fatalf("ConvertLagoonYAML() error = %v", err)
+			}
+			got := &YAML{}
+			if err := unmarshalLagoonYAML(tt.file, converted, got, tt.project, nil); err != nil {
+				t.Fatalf("unmarshalLagoonYAML() of the converted file error = %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("converted = %v, want %v", string(gotJSON), string(wantJSON))
+			}
+		})
+	}
+}
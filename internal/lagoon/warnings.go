@@ -0,0 +1,229 @@
+package lagoon
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// Warning is something in a .lagoon.yml file that is accepted, but should be fixed by the user
+type Warning struct {
+	File    string `json:"file,omitempty"`
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	if w.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", w.File, w.Line, w.Column, w.Path, w.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", w.File, w.Path, w.Message)
+}
+
+// Warnings collects the warnings found while unmarshalling .lagoon.yml files
+type Warnings []Warning
+
+// Collect records the warnings for the contents of a .lagoon.yml file, the source is the name of the file or variable that
+// the contents were read from. A nil collector is allowed and records nothing.
+func (w *Warnings) Collect(source string, data []byte, project string) error {
+	if w == nil {
+		return nil
+	}
+	if version, err := LagoonYAMLVersion(data); err != nil || version != 1 {
+		// version 2 doesn't convert or ignore anything, so there is nothing to warn about
+		return err
+	}
+	warnings, err := lagoonYAMLWarnings(source, data, project)
+	if err != nil {
+		return err
+	}
+	*w = append(*w, warnings...)
+	return nil
+}
+
+// the fields that are no longer used, the * matches any key
+var deprecatedFields = []struct {
+	path    []string
+	message string
+}{
+	{
+		path:    []string{"routes", "insecure"},
+		message: "is deprecated and has no effect, use routes.autogenerate.insecure instead",
+	},
+	{
+		path:    []string{"environments", "*", "monitoring_urls"},
+		message: "is deprecated and has no effect",
+	},
+}
+
+// a warning before it is positioned in the yaml
+type pathWarning struct {
+	path    []string
+	key     bool
+	message string
+}
+
+func lagoonYAMLWarnings(source string, data []byte, project string) ([]Warning, error) {
+	node := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(jsonData, &doc); err != nil || doc == nil {
+		// nothing to warn about in an empty file, and a file that isn't a map fails to unmarshal
+		return nil, nil
+	}
+	schema := GenerateSchema()
+	found := []pathWarning{}
+	if polysite, ok := doc[project].(map[string]interface{}); ok && project != "" {
+		delete(doc, project)
+		found = append(found, documentWarnings(polysite, schema, []string{project})...)
+		found = append(found, polysiteWarnings(doc, polysite, project)...)
+	}
+	found = append(found, documentWarnings(doc, schema, nil)...)
+
+	warnings := []Warning{}
+	for _, f := range found {
+		line, column := nodePosition(node, f.path, f.key)
+		warnings = append(warnings, Warning{
+			File:    source,
+			Path:    FormatPath(f.path),
+			Line:    line,
+			Column:  column,
+			Message: f.message,
+		})
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Line != warnings[j].Line {
+			return warnings[i].Line < warnings[j].Line
+		}
+		return warnings[i].Column < warnings[j].Column
+	})
+	return warnings, nil
+}
+
+// documentWarnings finds the coerced and deprecated fields in a .lagoon.yml document
+func documentWarnings(doc map[string]interface{}, schema map[string]interface{}, prefix []string) []pathWarning {
+	found := []pathWarning{}
+	walkCoercions(doc, schema, prefix, &found)
+	for _, d := range deprecatedFields {
+		for _, path := range matchPaths(doc, d.path, nil) {
+			found = append(found, pathWarning{
+				path:    append(append([]string{}, prefix...), path...),
+				key:     true,
+				message: d.message,
+			})
+		}
+	}
+	return found
+}
+
+// walkCoercions walks the document alongside the schema, and records any string that is converted to a boolean by the
+// custom unmarshalers
+func walkCoercions(value interface{}, schema map[string]interface{}, path []string, found *[]pathWarning) {
+	if types, ok := schema["type"].([]string); ok && len(types) == 2 && types[0] == "boolean" && types[1] == "string" {
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				*found = append(*found, pathWarning{
+					path:    path,
+					message: fmt.Sprintf("the string %q is converted to the boolean %t, use a boolean instead", s, b),
+				})
+			}
+		}
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for key, child := range v {
+			childPath := append(append([]string{}, path...), key)
+			if propertySchema, ok := properties[key].(map[string]interface{}); ok {
+				walkCoercions(child, propertySchema, childPath, found)
+			} else if additional != nil {
+				walkCoercions(child, additional, childPath, found)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, child := range v {
+				walkCoercions(child, items, append(append([]string{}, path...), strconv.Itoa(i)), found)
+			}
+		}
+	}
+}
+
+// matchPaths returns the paths in the document that match the pattern
+func matchPaths(value interface{}, pattern []string, path []string) [][]string {
+	if len(pattern) == 0 {
+		return [][]string{path}
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	matches := [][]string{}
+	keys := []string{pattern[0]}
+	if pattern[0] == "*" {
+		keys = []string{}
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		if child, ok := m[key]; ok {
+			matches = append(matches, matchPaths(child, pattern[1:], append(append([]string{}, path...), key))...)
+		}
+	}
+	return matches
+}
+
+// polysiteWarnings finds the parts of the top-level .lagoon.yml that are merged with the polysite project block in ways
+// that users may not expect
+func polysiteWarnings(doc map[string]interface{}, polysite map[string]interface{}, project string) []pathWarning {
+	found := []pathWarning{}
+	for key := range doc {
+		if _, ok := polysite[key]; !ok || key == "environments" {
+			continue
+		}
+		found = append(found, pathWarning{
+			path:    []string{key},
+			key:     true,
+			message: fmt.Sprintf("is replaced by %s.%s from the polysite project", project, key),
+		})
+	}
+	environments, _ := doc["environments"].(map[string]interface{})
+	polysiteEnvironments, _ := polysite["environments"].(map[string]interface{})
+	for name, environment := range environments {
+		if _, ok := polysiteEnvironments[name]; !ok {
+			found = append(found, pathWarning{
+				path:    []string{"environments", name},
+				key:     true,
+				message: fmt.Sprintf("is not defined in %s.environments, the top-level environment is used", project),
+			})
+			continue
+		}
+		message := fmt.Sprintf("is replaced by %s.environments.%s from the polysite project", project, name)
+		if e, ok := environment.(map[string]interface{}); ok {
+			if cronjobs, ok := e["cronjobs"].([]interface{}); ok && len(cronjobs) > 0 {
+				message = fmt.Sprintf("is replaced by %s.environments.%s from the polysite project, but its cronjobs are merged into it", project, name)
+			}
+		}
+		found = append(found, pathWarning{
+			path:    []string{"environments", name},
+			key:     true,
+			message: message,
+		})
+	}
+	return found
+}
@@ -0,0 +1,116 @@
+package lagoon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshalLagoonYAMLWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		project string
+		want    Warnings
+	}{
+		{
+			name: "test1 - coerced and deprecated fields",
+			file: "test-resources/lagoon-yaml/warnings/lagoon.yml",
+			want: Warnings{
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.yml",
+					Path:    "routes.insecure",
+					Line:    3,
+					Column:  3,
+					Message: "is deprecated and has no effect, use routes.autogenerate.insecure instead",
+				},
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.yml",
+					Path:    "routes.autogenerate.enabled",
+					Line:    5,
+					Column:  14,
+					Message: "the string \"true\" is converted to the boolean true, use a boolean instead",
+				},
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.yml",
+					Path:    "environment_variables.git_sha",
+					Line:    7,
+					Column:  12,
+					Message: "the string \"false\" is converted to the boolean false, use a boolean instead",
+				},
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.yml",
+					Path:    "environments.main.monitoring_urls",
+					Line:    10,
+					Column:  5,
+					Message: "is deprecated and has no effect",
+				},
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.yml",
+					Path:    "environments.main.routes.0.nginx.0.\"example.com\".tls-acme",
+					Line:    15,
+					Column:  25,
+					Message: "the string \"false\" is converted to the boolean false, use a boolean instead",
+				},
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.yml",
+					Path:    "environments.main.routes.0.nginx.0.\"example.com\".fastly.watch",
+					Line:    17,
+					Column:  24,
+					Message: "the string \"true\" is converted to the boolean true, use a boolean instead",
+				},
+			},
+		},
+		{
+			name:    "test2 - polysite merge",
+			file:    "test-resources/lagoon-yaml/warnings/lagoon.polysite.yml",
+			project: "example-project",
+			want: Warnings{
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.polysite.yml",
+					Path:    "docker-compose-yaml",
+					Line:    1,
+					Column:  1,
+					Message: "is replaced by example-project.docker-compose-yaml from the polysite project",
+				},
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.polysite.yml",
+					Path:    "environments.main",
+					Line:    3,
+					Column:  3,
+					Message: "is replaced by example-project.environments.main from the polysite project, but its cronjobs are merged into it",
+				},
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.polysite.yml",
+					Path:    "environments.develop",
+					Line:    9,
+					Column:  3,
+					Message: "is not defined in example-project.environments, the top-level environment is used",
+				},
+				{
+					File:    "test-resources/lagoon-yaml/warnings/lagoon.polysite.yml",
+					Path:    "example-project.environments.main.routes.0.nginx.0.\"example.com\".tls-acme",
+					Line:    20,
+					Column:  27,
+					Message: "the string \"true\" is converted to the boolean true, use a boolean instead",
+				},
+			},
+		},
+		{
+			name: "test3 - no warnings",
+			file: "test-resources/lagoon-yaml/test2/lagoon.yml",
+			want: Warnings{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Warnings{}
+			if err := UnmarshalLagoonYAML(tt.file, &YAML{}, tt.project, &got); err != nil {
+				t.Errorf("UnmarshalLagoonYAML() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalLagoonYAML() warnings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
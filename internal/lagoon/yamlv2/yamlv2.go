@@ -0,0 +1,245 @@
+// Package yamlv2 defines version 2 of the .lagoon.yml file.
+//
+// Version 2 is a stricter form of the original .lagoon.yml. It is selected with `version: 2` at the top of the file and
+//   - every key uses camelCase, rather than the mix of dashes, underscores and camelCase in version 1
+//   - booleans must be booleans, the strings that version 1 converts to booleans are errors
+//   - unknown keys are errors, rather than being ignored
+//   - routes are a list of routes that each name their service and domain, rather than a list of maps of services to a
+//     list of either domains or maps of domains
+//   - tasks are a list of tasks, rather than a list of `run` blocks
+//   - there are no polysite blocks, a polysite project uses a separate .lagoon.yml for each project
+//
+// The generator doesn't use these types directly, a version 2 file is converted into the same lagoon.YAML as a version 1 file.
+package yamlv2
+
+// Version is the version of the .lagoon.yml described by this package
+const Version = 2
+
+// YAML represents a version 2 .lagoon.yml file.
+type YAML struct {
+	Version              int                          `json:"version"`
+	DockerComposeYAML    string                       `json:"dockerComposeYaml,omitempty"`
+	Environments         map[string]Environment       `json:"environments,omitempty"`
+	ProductionRoutes     *ProductionRoutes            `json:"productionRoutes,omitempty"`
+	Tasks                *Tasks                       `json:"tasks,omitempty"`
+	Routes               *Routes                      `json:"routes,omitempty"`
+	BackupRetention      *BackupRetention             `json:"backupRetention,omitempty"`
+	BackupSchedule       *BackupSchedule              `json:"backupSchedule,omitempty"`
+	EnvironmentVariables *EnvironmentVariables        `json:"environmentVariables,omitempty"`
+	ContainerRegistries  map[string]ContainerRegistry `json:"containerRegistries,omitempty"`
+	Fastly               *FastlyConfiguration         `json:"fastly,omitempty"`
+}
+
+// Environment represents a Lagoon environment.
+type Environment struct {
+	AutogenerateRoutes     *bool                   `json:"autogenerateRoutes,omitempty"`
+	Types                  map[string]string       `json:"types,omitempty"`
+	Routes                 []Route                 `json:"routes,omitempty"`
+	Cronjobs               []Cronjob               `json:"cronjobs,omitempty"`
+	Overrides              map[string]Override     `json:"overrides,omitempty"`
+	AutogeneratePathRoutes []AutogeneratePathRoute `json:"autogeneratePathRoutes,omitempty"`
+}
+
+// ProductionRoutes represents an active/standby configuration.
+type ProductionRoutes struct {
+	Active  *Environment `json:"active,omitempty"`
+	Standby *Environment `json:"standby,omitempty"`
+}
+
+// Route represents a route to a service.
+type Route struct {
+	Service                    string            `json:"service"`
+	Domain                     string            `json:"domain"`
+	TLSAcme                    *bool             `json:"tlsAcme,omitempty"`
+	Migrate                    *bool             `json:"migrate,omitempty"`
+	Insecure                   *string           `json:"insecure,omitempty"`
+	MonitoringPath             string            `json:"monitoringPath,omitempty"`
+	Fastly                     *Fastly           `json:"fastly,omitempty"`
+	Annotations                map[string]string `json:"annotations,omitempty"`
+	IngressClass               string            `json:"ingressClass,omitempty"`
+	HSTSEnabled                *bool             `json:"hstsEnabled,omitempty"`
+	HSTSMaxAge                 int               `json:"hstsMaxAge,omitempty"`
+	HSTSIncludeSubdomains      *bool             `json:"hstsIncludeSubdomains,omitempty"`
+	HSTSPreload                *bool             `json:"hstsPreload,omitempty"`
+	AlternativeNames           []string          `json:"alternativeNames,omitempty"`
+	Wildcard                   *bool             `json:"wildcard,omitempty"`
+	DisableRequestVerification *bool             `json:"disableRequestVerification,omitempty"`
+	PathRoutes                 []PathRoute       `json:"pathRoutes,omitempty"`
+}
+
+// Fastly represents the fastly configuration of a route.
+type Fastly struct {
+	ServiceID     string `json:"serviceId,omitempty"`
+	APISecretName string `json:"apiSecretName,omitempty"`
+	Watch         bool   `json:"watch,omitempty"`
+}
+
+// PathRoute routes a path of a route to another service.
+type PathRoute struct {
+	ToService string `json:"toService"`
+	Path      string `json:"path"`
+}
+
+// AutogeneratePathRoute routes a path of the autogenerated routes of a service to another service.
+type AutogeneratePathRoute struct {
+	FromService string `json:"fromService"`
+	ToService   string `json:"toService"`
+	Path        string `json:"path"`
+}
+
+// Cronjob represents a Lagoon cronjob.
+type Cronjob struct {
+	Name     string `json:"name"`
+	Service  string `json:"service"`
+	Schedule string `json:"schedule"`
+	Command  string `json:"command"`
+	InPod    *bool  `json:"inPod,omitempty"`
+}
+
+// Override overrides the image, build, resources or probes of a service.
+type Override struct {
+	Build          *Build             `json:"build,omitempty"`
+	Image          string             `json:"image,omitempty"`
+	Resources      *ResourceOverrides `json:"resources,omitempty"`
+	Probes         *ProbeOverrides    `json:"probes,omitempty"`
+	Autoscaling    *Autoscaling       `json:"autoscaling,omitempty"`
+	Containers     []Container        `json:"containers,omitempty"`
+	InitContainers []Container        `json:"initContainers,omitempty"`
+}
+
+// ResourceOverrides are the resource requests and limits of the container of a service.
+type ResourceOverrides struct {
+	Requests *ResourceList `json:"requests,omitempty"`
+	Limits   *ResourceList `json:"limits,omitempty"`
+}
+
+// ResourceList is the cpu and memory of a resource request or limit, as kubernetes resource quantities.
+type ResourceList struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// ProbeOverrides are the timings of the readiness and liveness probes of the container of a service.
+type ProbeOverrides struct {
+	Readiness *ProbeOverride `json:"readiness,omitempty"`
+	Liveness  *ProbeOverride `json:"liveness,omitempty"`
+}
+
+// ProbeOverride is the timing of a probe, the check that the probe does is defined by the service type.
+type ProbeOverride struct {
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32 `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int32 `json:"timeoutSeconds,omitempty"`
+	SuccessThreshold    *int32 `json:"successThreshold,omitempty"`
+	FailureThreshold    *int32 `json:"failureThreshold,omitempty"`
+}
+
+// Autoscaling is the horizontal pod autoscaling of a service, the targets are percentages of the resource requests.
+type Autoscaling struct {
+	MinReplicas             *int32 `json:"minReplicas,omitempty"`
+	MaxReplicas             *int32 `json:"maxReplicas,omitempty"`
+	TargetCPUUtilization    *int32 `json:"targetCPUUtilization,omitempty"`
+	TargetMemoryUtilization *int32 `json:"targetMemoryUtilization,omitempty"`
+}
+
+// Container is an additional container in the pods of a service, the image is either the image built for a docker-compose
+// service or an external image.
+type Container struct {
+	Name    string            `json:"name"`
+	Service string            `json:"service,omitempty"`
+	Image   string            `json:"image,omitempty"`
+	Command []string          `json:"command,omitempty"`
+	Volumes []ContainerVolume `json:"volumes,omitempty"`
+}
+
+// ContainerVolume is a volume of the service that is mounted in an additional container.
+type ContainerVolume struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Build is the build configuration of a service override.
+type Build struct {
+	Dockerfile string `json:"dockerfile,omitempty"`
+	Context    string `json:"context,omitempty"`
+}
+
+// Tasks are the tasks run before and after the rollout.
+type Tasks struct {
+	PreRollout  []Task `json:"preRollout,omitempty"`
+	PostRollout []Task `json:"postRollout,omitempty"`
+}
+
+// Task represents a pre or post rollout task.
+type Task struct {
+	Name                string `json:"name,omitempty"`
+	Command             string `json:"command"`
+	Namespace           string `json:"namespace,omitempty"`
+	Service             string `json:"service"`
+	Shell               string `json:"shell,omitempty"`
+	Container           string `json:"container,omitempty"`
+	When                string `json:"when,omitempty"`
+	Weight              int    `json:"weight,omitempty"`
+	ScaleWaitTime       int    `json:"scaleWaitTime,omitempty"`
+	ScaleMaxIterations  int    `json:"scaleMaxIterations,omitempty"`
+	RequiresEnvironment bool   `json:"requiresEnvironment,omitempty"`
+}
+
+// Routes is the configuration of the autogenerated routes.
+type Routes struct {
+	Autogenerate *Autogenerate `json:"autogenerate,omitempty"`
+}
+
+// Autogenerate is the configuration of the autogenerated routes.
+type Autogenerate struct {
+	Enabled                    *bool                   `json:"enabled,omitempty"`
+	AllowPullRequests          *bool                   `json:"allowPullRequests,omitempty"`
+	Insecure                   string                  `json:"insecure,omitempty"`
+	Prefixes                   []string                `json:"prefixes,omitempty"`
+	TLSAcme                    *bool                   `json:"tlsAcme,omitempty"`
+	IngressClass               string                  `json:"ingressClass,omitempty"`
+	DisableRequestVerification *bool                   `json:"disableRequestVerification,omitempty"`
+	PathRoutes                 []AutogeneratePathRoute `json:"pathRoutes,omitempty"`
+}
+
+// BackupRetention is the number of backups to keep.
+type BackupRetention struct {
+	Production *Retention `json:"production,omitempty"`
+}
+
+// Retention is the number of each kind of backup to keep.
+type Retention struct {
+	Hourly  *int `json:"hourly,omitempty"`
+	Daily   *int `json:"daily,omitempty"`
+	Weekly  *int `json:"weekly,omitempty"`
+	Monthly *int `json:"monthly,omitempty"`
+}
+
+// BackupSchedule is the schedule of the backups.
+type BackupSchedule struct {
+	Production string `json:"production,omitempty"`
+}
+
+// EnvironmentVariables configures the variables that lagoon adds to the environment.
+type EnvironmentVariables struct {
+	GitSHA *bool `json:"gitSha,omitempty"`
+}
+
+// ContainerRegistry is a private container registry.
+type ContainerRegistry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	URL      string `json:"url,omitempty"`
+}
+
+// FastlyConfiguration is the fastly configuration of the project.
+type FastlyConfiguration struct {
+	APISecrets []FastlyAPISecret `json:"apiSecrets,omitempty"`
+}
+
+// FastlyAPISecret is a fastly api token from a Lagoon variable, that a route can use with its apiSecretName.
+type FastlyAPISecret struct {
+	Name                     string `json:"name"`
+	APITokenVariableName     string `json:"apiTokenVariableName"`
+	PlatformTLSConfiguration string `json:"platformTLSConfiguration,omitempty"`
+}
@@ -0,0 +1,426 @@
+package manifests
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/andreyvit/diff"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Action is what would happen to an object when going from the left set of objects to the right set
+type Action string
+
+const (
+	Added     Action = "added"
+	Changed   Action = "changed"
+	Deleted   Action = "deleted"
+	Unchanged Action = "unchanged"
+)
+
+// Change is the result of comparing a single object
+type Change struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Action    Action `json:"action"`
+	Diff      string `json:"diff,omitempty"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(c.Kind), c.Name)
+}
+
+// serverMetadataFields are metadata fields that are populated by the api server
+var serverMetadataFields = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"managedFields",
+	"selfLink",
+}
+
+// serverAnnotations are annotations that are added by the api server, controllers, or kubectl
+var serverAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// Normalize returns a copy of the object with the fields that are populated by the api server removed, along with any
+// null values and empty maps or lists so that an object read from a cluster can be compared to a rendered template
+func Normalize(obj unstructured.Unstructured) unstructured.Unstructured {
+	n := obj.DeepCopy()
+	for _, f := range serverMetadataFields {
+		unstructured.RemoveNestedField(n.Object, "metadata", f)
+	}
+	unstructured.RemoveNestedField(n.Object, "status")
+	annotations := n.GetAnnotations()
+	for _, a := range serverAnnotations {
+		delete(annotations, a)
+	}
+	n.SetAnnotations(annotations)
+	if p, ok := prune(n.Object).(map[string]interface{}); ok {
+		n.Object = p
+	} else {
+		n.Object = map[string]interface{}{}
+	}
+	return *n
+}
+
+// prune removes null values and empty maps and lists
+func prune(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := map[string]interface{}{}
+		for k, mv := range t {
+			if p := prune(mv); p != nil {
+				out[k] = p
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	case []interface{}:
+		if len(t) == 0 {
+			return nil
+		}
+		out := make([]interface{}, len(t))
+		for i, lv := range t {
+			out[i] = prune(lv)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// TrimTo returns a copy of the object that only contains the fields that are set in the reference object, or that the field
+// manager owns in the managed fields of the object. This is used to remove any defaults that the api server adds to an object,
+// so that only the fields the reference manages are compared. Fields the manager owns that the reference no longer sets are
+// kept, as a server-side apply removes them, so the comparison shows that they are removed.
+// Lists are trimmed element by element when they are the same length, or when the manager owns the elements, otherwise they
+// are kept as they are
+func TrimTo(obj, reference unstructured.Unstructured, manager string) unstructured.Unstructured {
+	managed := managedFields(obj, manager)
+	t := obj.DeepCopy()
+	if trimmed, ok := trim(t.Object, reference.Object, managed).(map[string]interface{}); ok {
+		t.Object = trimmed
+	}
+	return *t
+}
+
+// managedFields returns the fields that the manager owns in the object, merged from all of the managed fields entries of the
+// manager. The fields are in the FieldsV1 format, where every key is prefixed with its kind, like `f:metadata`, and an entry
+// without any fields owns the whole value
+func managedFields(obj unstructured.Unstructured, manager string) map[string]interface{} {
+	var managed map[string]interface{}
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager != manager || entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		managed = mergeManaged(managed, fields)
+	}
+	return managed
+}
+
+func mergeManaged(a, b map[string]interface{}) map[string]interface{} {
+	if a == nil {
+		return b
+	}
+	for k, bv := range b {
+		am, aok := a[k].(map[string]interface{})
+		bm, bok := bv.(map[string]interface{})
+		if aok && bok {
+			a[k] = mergeManaged(am, bm)
+			continue
+		}
+		a[k] = bv
+	}
+	return a
+}
+
+// ownsWholeValue returns true if a managed fields entry doesn't list any fields, so the manager owns all of the value
+func ownsWholeValue(managed map[string]interface{}) bool {
+	for k := range managed {
+		if k != "." {
+			return false
+		}
+	}
+	return true
+}
+
+func trim(obj, ref interface{}, managed map[string]interface{}) interface{} {
+	if managed != nil && ownsWholeValue(managed) {
+		return obj
+	}
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		r, rok := ref.(map[string]interface{})
+		if !rok && managed == nil {
+			return obj
+		}
+		out := map[string]interface{}{}
+		for k, ov := range o {
+			rv, inRef := r[k]
+			mv, inManaged := managed["f:"+k]
+			if !inRef && !inManaged {
+				continue
+			}
+			mm, _ := mv.(map[string]interface{})
+			out[k] = trim(ov, rv, mm)
+		}
+		return out
+	case []interface{}:
+		r, rok := ref.([]interface{})
+		if managed == nil {
+			if !rok || len(o) != len(r) {
+				return obj
+			}
+			out := make([]interface{}, len(o))
+			for i := range o {
+				out[i] = trim(o[i], r[i], nil)
+			}
+			return out
+		}
+		out := []interface{}{}
+		for i, ov := range o {
+			var rv interface{}
+			if rok && len(o) == len(r) {
+				rv = r[i]
+			}
+			mv := managedListItem(managed, ov, i)
+			if rv == nil && mv == nil {
+				continue
+			}
+			out = append(out, trim(ov, rv, mv))
+		}
+		return out
+	default:
+		return obj
+	}
+}
+
+// managedListItem returns the managed fields of an item in a list, the item is either found by its key fields, by its value,
+// or by its index
+func managedListItem(managed map[string]interface{}, item interface{}, idx int) map[string]interface{} {
+	for k, mv := range managed {
+		mm, _ := mv.(map[string]interface{})
+		if mm == nil {
+			mm = map[string]interface{}{}
+		}
+		switch {
+		case strings.HasPrefix(k, "k:"):
+			keys := map[string]interface{}{}
+			im, ok := item.(map[string]interface{})
+			if !ok || json.Unmarshal([]byte(strings.TrimPrefix(k, "k:")), &keys) != nil {
+				continue
+			}
+			matched := true
+			for kk, kv := range keys {
+				if fmt.Sprint(im[kk]) != fmt.Sprint(kv) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				return mm
+			}
+		case strings.HasPrefix(k, "v:"):
+			value, err := json.Marshal(item)
+			if err == nil && strings.TrimPrefix(k, "v:") == string(value) {
+				return mm
+			}
+		case k == fmt.Sprintf("i:%d", idx):
+			return mm
+		}
+	}
+	return nil
+}
+
+// Compare matches the objects in the left and right sets by kind, namespace and name, and returns the changes required
+// to go from the left to the right. Objects are normalized before they are compared, and the changes are returned in the
+// same order as Sort
+func Compare(left, right []unstructured.Unstructured) ([]Change, error) {
+	leftObjects := map[string]unstructured.Unstructured{}
+	all := []unstructured.Unstructured{}
+	for _, o := range left {
+		leftObjects[objectKey(o)] = o
+		all = append(all, o)
+	}
+	rightObjects := map[string]unstructured.Unstructured{}
+	for _, o := range right {
+		rightObjects[objectKey(o)] = o
+		if _, ok := leftObjects[objectKey(o)]; !ok {
+			all = append(all, o)
+		}
+	}
+	Sort(all)
+	changes := []Change{}
+	for _, o := range all {
+		key := objectKey(o)
+		l, inLeft := leftObjects[key]
+		r, inRight := rightObjects[key]
+		change := Change{
+			Kind:      o.GetKind(),
+			Namespace: o.GetNamespace(),
+			Name:      o.GetName(),
+		}
+		var lNormalized, rNormalized *unstructured.Unstructured
+		var lYAML, rYAML string
+		var err error
+		if inLeft {
+			n := Normalize(l)
+			lNormalized = &n
+			if lYAML, err = objectYAML(lNormalized); err != nil {
+				return nil, err
+			}
+		}
+		if inRight {
+			n := Normalize(r)
+			rNormalized = &n
+			if rYAML, err = objectYAML(rNormalized); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case !inLeft:
+			change.Action = Added
+		case !inRight:
+			change.Action = Deleted
+		case lYAML == rYAML:
+			change.Action = Unchanged
+		default:
+			change.Action = Changed
+		}
+		if change.Action != Unchanged {
+			// the diff is written to build logs, so the values of secrets are never shown
+			lMasked, rMasked := maskSensitive(lNormalized, rNormalized)
+			if lYAML, err = objectYAML(lMasked); err != nil {
+				return nil, err
+			}
+			if rYAML, err = objectYAML(rMasked); err != nil {
+				return nil, err
+			}
+			change.Diff = diff.LineDiff(lYAML, rYAML)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// HasChanges returns true if any of the changes are not unchanged
+func HasChanges(changes []Change) bool {
+	for _, c := range changes {
+		if c.Action != Unchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// objectYAML returns the yaml of an object, or an empty string if there is no object
+func objectYAML(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	b, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("couldn't marshal %s %s: %v", obj.GetKind(), obj.GetName(), err)
+	}
+	return string(b), nil
+}
+
+// the placeholders that replace sensitive values in a diff, they only show how each key has changed
+const (
+	maskUnchanged = "<unchanged>"
+	maskPrevious  = "<previous>"
+	maskChanged   = "<changed>"
+	maskRemoved   = "<removed>"
+	maskAdded     = "<added>"
+)
+
+// sensitiveFields returns the fields of an object that contain values that must not be shown in a diff, the data of secrets and
+// of the lagoon-env configmap, which contains the runtime variables of the environment
+func sensitiveFields(obj *unstructured.Unstructured) [][]string {
+	if obj == nil {
+		return nil
+	}
+	switch {
+	case obj.GetKind() == "Secret":
+		return [][]string{{"data"}, {"stringData"}}
+	case obj.GetKind() == "ConfigMap" && obj.GetName() == "lagoon-env":
+		return [][]string{{"data"}, {"binaryData"}}
+	}
+	return nil
+}
+
+// maskSensitive returns copies of the objects with the values of their sensitive fields replaced with placeholders
+func maskSensitive(left, right *unstructured.Unstructured) (*unstructured.Unstructured, *unstructured.Unstructured) {
+	fields := sensitiveFields(left)
+	if fields == nil {
+		fields = sensitiveFields(right)
+	}
+	if fields == nil {
+		return left, right
+	}
+	if left != nil {
+		left = left.DeepCopy()
+	}
+	if right != nil {
+		right = right.DeepCopy()
+	}
+	for _, field := range fields {
+		var lValues, rValues map[string]interface{}
+		if left != nil {
+			lValues, _, _ = unstructured.NestedMap(left.Object, field...)
+		}
+		if right != nil {
+			rValues, _, _ = unstructured.NestedMap(right.Object, field...)
+		}
+		for k, lv := range lValues {
+			rv, ok := rValues[k]
+			switch {
+			case !ok:
+				lValues[k] = maskRemoved
+			case reflect.DeepEqual(lv, rv):
+				lValues[k] = maskUnchanged
+			default:
+				lValues[k] = maskPrevious
+			}
+		}
+		// the left values have already been masked, so they show if the key is unchanged
+		for k := range rValues {
+			lv, ok := lValues[k]
+			switch {
+			case !ok:
+				rValues[k] = maskAdded
+			case lv == maskUnchanged:
+				rValues[k] = maskUnchanged
+			default:
+				rValues[k] = maskChanged
+			}
+		}
+		if lValues != nil {
+			_ = unstructured.SetNestedMap(left.Object, lValues, field...)
+		}
+		if rValues != nil {
+			_ = unstructured.SetNestedMap(right.Object, rValues, field...)
+		}
+	}
+	return left, right
+}
+
+func objectKey(obj unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
@@ -0,0 +1,328 @@
+package manifests
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		left    string
+		right   string
+		want    []Action
+		wantLen int
+	}{
+		{
+			name: "test1 - unchanged ignoring server fields",
+			left: `apiVersion: v1
+kind: Service
+metadata:
+  name: node
+  namespace: example-project-main
+  uid: 1234
+  resourceVersion: "100"
+  creationTimestamp: "2024-01-01T00:00:00Z"
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: "{}"
+spec:
+  type: ClusterIP
+status:
+  loadBalancer: {}
+`,
+			right: `apiVersion: v1
+kind: Service
+metadata:
+  name: node
+  namespace: example-project-main
+  creationTimestamp: null
+spec:
+  type: ClusterIP
+status: {}
+`,
+			want: []Action{Unchanged},
+		},
+		{
+			name: "test2 - added, changed and deleted",
+			left: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: node
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: redis
+`,
+			right: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: node
+spec:
+  replicas: 2
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: example.com
+`,
+			want: []Action{Changed, Added, Deleted},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, err := Decode([]byte(tt.left))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			right, err := Decode([]byte(tt.right))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			changes, err := Compare(left, right)
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			got := []Action{}
+			for _, c := range changes {
+				got = append(got, c.Action)
+				if c.Action == Unchanged && c.Diff != "" {
+					t.Errorf("Compare() %s has a diff but is unchanged", c)
+				}
+				if c.Action != Unchanged && c.Diff == "" {
+					t.Errorf("Compare() %s is %s but has no diff", c, c.Action)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareMasksSensitiveValues(t *testing.T) {
+	left, err := Decode([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: lagoon-private-registry
+data:
+  password: b2xkLXBhc3N3b3Jk
+  username: dXNlcg==
+  token: dG9rZW4=
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: lagoon-env
+data:
+  API_KEY: old-api-key
+  LAGOON_ENVIRONMENT: main
+`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	right, err := Decode([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: lagoon-private-registry
+data:
+  password: bmV3LXBhc3N3b3Jk
+  username: dXNlcg==
+stringData:
+  url: registry.example.com
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: lagoon-env
+data:
+  API_KEY: new-api-key
+  LAGOON_ENVIRONMENT: main
+  DB_PASSWORD: secret
+`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	changes, err := Compare(left, right)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Compare() = %v, want 2 changes", changes)
+	}
+	for _, c := range changes {
+		if c.Action != Changed {
+			t.Errorf("Compare() %s is %s, want changed", c, c.Action)
+		}
+		for _, value := range []string{"b2xkLXBhc3N3b3Jk", "bmV3LXBhc3N3b3Jk", "dXNlcg==", "dG9rZW4=", "registry.example.com", "old-api-key", "new-api-key", "secret\n"} {
+			if strings.Contains(c.Diff, value) {
+				t.Errorf("Compare() %s diff contains the value %s:\n%s", c, value, c.Diff)
+			}
+		}
+	}
+	for _, want := range []string{"password: <previous>", "password: <changed>", "username: <unchanged>", "token: <removed>", "url: <added>"} {
+		if !strings.Contains(changes[0].Diff+changes[1].Diff, want) {
+			t.Errorf("Compare() diffs don't contain %s:\n%s\n%s", want, changes[0].Diff, changes[1].Diff)
+		}
+	}
+	for _, want := range []string{"API_KEY: <previous>", "API_KEY: <changed>", "LAGOON_ENVIRONMENT: <unchanged>", "DB_PASSWORD: <added>"} {
+		if !strings.Contains(changes[0].Diff+changes[1].Diff, want) {
+			t.Errorf("Compare() diffs don't contain %s:\n%s\n%s", want, changes[0].Diff, changes[1].Diff)
+		}
+	}
+}
+
+func TestTrimTo(t *testing.T) {
+	live := unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{
+			"replicas":                int64(1),
+			"progressDeadlineSeconds": int64(600),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "node", "image": "node:18", "terminationMessagePath": "/dev/termination-log"},
+					},
+				},
+			},
+		},
+	}}
+	reference := unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "node", "image": "node:20"},
+					},
+				},
+			},
+		},
+	}}
+	want := map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "node", "image": "node:18"},
+					},
+				},
+			},
+		},
+	}
+	if got := TrimTo(live, reference, "build-deploy-tool"); !reflect.DeepEqual(got.Object, want) {
+		t.Errorf("TrimTo() = %v, want %v", got.Object, want)
+	}
+}
+
+func TestTrimToManagedFields(t *testing.T) {
+	live, err := Decode([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: node
+  labels:
+    app.kubernetes.io/name: node
+    lagoon.sh/removed: "true"
+    added-by-someone-else: "true"
+  managedFields:
+  - manager: build-deploy-tool
+    operation: Apply
+    apiVersion: apps/v1
+    fieldsType: FieldsV1
+    fieldsV1:
+      f:metadata:
+        f:labels:
+          f:app.kubernetes.io/name: {}
+          f:lagoon.sh/removed: {}
+      f:spec:
+        f:template:
+          f:spec:
+            f:containers:
+              k:{"name":"node"}:
+                .: {}
+                f:name: {}
+                f:env:
+                  k:{"name":"KEEP"}:
+                    .: {}
+                    f:name: {}
+                    f:value: {}
+                  k:{"name":"REMOVED"}:
+                    .: {}
+                    f:name: {}
+                    f:value: {}
+  - manager: kubectl
+    operation: Update
+    apiVersion: apps/v1
+    fieldsType: FieldsV1
+    fieldsV1:
+      f:metadata:
+        f:labels:
+          f:added-by-someone-else: {}
+spec:
+  progressDeadlineSeconds: 600
+  template:
+    spec:
+      containers:
+      - name: node
+        terminationMessagePath: /dev/termination-log
+        env:
+        - name: KEEP
+          value: "1"
+        - name: REMOVED
+          value: "1"
+`))
+	if err != nil {
+		t.Fatalf("couldn't decode live object: %v", err)
+	}
+	desired, err := Decode([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: node
+  labels:
+    app.kubernetes.io/name: node
+spec:
+  template:
+    spec:
+      containers:
+      - name: node
+        env:
+        - name: KEEP
+          value: "1"
+`))
+	if err != nil {
+		t.Fatalf("couldn't decode desired object: %v", err)
+	}
+	trimmed := Normalize(TrimTo(live[0], Normalize(desired[0]), "build-deploy-tool"))
+	labels := trimmed.GetLabels()
+	if _, ok := labels["lagoon.sh/removed"]; !ok {
+		t.Errorf("TrimTo() removed the label the field manager owns: %v", labels)
+	}
+	if _, ok := labels["added-by-someone-else"]; ok {
+		t.Errorf("TrimTo() kept a label the field manager doesn't own: %v", labels)
+	}
+	changes, err := Compare([]unstructured.Unstructured{trimmed}, desired)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].Action != Changed {
+		t.Fatalf("Compare() = %v, want a single changed object", changes)
+	}
+	for _, removed := range []string{"-    lagoon.sh/removed", "-        - name: REMOVED", "-          value: \"1\""} {
+		if !strings.Contains(changes[0].Diff, removed) {
+			t.Errorf("Compare() diff doesn't remove %q:\n%s", removed, changes[0].Diff)
+		}
+	}
+	for _, defaulted := range []string{"terminationMessagePath", "progressDeadlineSeconds", "added-by-someone-else"} {
+		if strings.Contains(changes[0].Diff, defaulted) {
+			t.Errorf("Compare() diff contains %q:\n%s", defaulted, changes[0].Diff)
+		}
+	}
+}
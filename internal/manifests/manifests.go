@@ -0,0 +1,175 @@
+package manifests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Bundle is a collection of rendered kubernetes objects.
+// Objects are stored against the file they would have been written to, so that adding the same file again
+// replaces what was there previously, the same as overwriting a file on disk would.
+type Bundle struct {
+	files map[string][]unstructured.Unstructured
+}
+
+// NewBundle returns an empty bundle
+func NewBundle() *Bundle {
+	return &Bundle{
+		files: map[string][]unstructured.Unstructured{},
+	}
+}
+
+// Add parses the provided yaml or json data, which may contain multiple documents, and stores the resulting objects
+// against the provided file name
+func (b *Bundle) Add(file string, data []byte) error {
+	objects, err := Decode(data)
+	if err != nil {
+		return fmt.Errorf("couldn't decode %s: %v", file, err)
+	}
+	b.files[file] = objects
+	return nil
+}
+
+// Objects returns all the objects in the bundle sorted by kind, then namespace and name
+func (b *Bundle) Objects() []unstructured.Unstructured {
+	objects := []unstructured.Unstructured{}
+	for _, o := range b.files {
+		objects = append(objects, o...)
+	}
+	Sort(objects)
+	return objects
+}
+
+// YAML returns the bundle as a single multi-document yaml
+func (b *Bundle) YAML() ([]byte, error) {
+	var result []byte
+	separator := []byte("---\n")
+	for _, o := range b.Objects() {
+		oBytes, err := yaml.Marshal(o.Object)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal %s %s: %v", o.GetKind(), o.GetName(), err)
+		}
+		result = append(result, separator[:]...)
+		result = append(result, oBytes[:]...)
+	}
+	return result, nil
+}
+
+// JSON returns the bundle as a v1.List
+func (b *Bundle) JSON() ([]byte, error) {
+	list := corev1.List{}
+	list.Kind = "List"
+	list.APIVersion = "v1"
+	list.Items = []runtime.RawExtension{}
+	for _, o := range b.Objects() {
+		obj := o
+		list.Items = append(list.Items, runtime.RawExtension{Object: &obj})
+	}
+	return json.MarshalIndent(list, "", "  ")
+}
+
+// Decode parses yaml or json data, which may contain multiple documents, into a slice of objects.
+// Empty documents are skipped, and any v1.List is expanded into its items.
+func Decode(data []byte) ([]unstructured.Unstructured, error) {
+	objects := []unstructured.Unstructured{}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		u := unstructured.Unstructured{Object: obj}
+		if u.IsList() {
+			err := u.EachListItem(func(item runtime.Object) error {
+				objects = append(objects, *item.(*unstructured.Unstructured))
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		objects = append(objects, u)
+	}
+	return objects, nil
+}
+
+// Load reads all the objects from a file, or from all the yaml and json files within a directory and its sub directories
+func Load(path string) ([]unstructured.Unstructured, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		files = []string{}
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch filepath.Ext(p) {
+			case ".yaml", ".yml", ".json":
+				if !d.IsDir() {
+					files = append(files, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	objects := []unstructured.Unstructured{}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read file %v: %v", f, err)
+		}
+		fObjects, err := Decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't decode %s: %v", f, err)
+		}
+		objects = append(objects, fObjects...)
+	}
+	Sort(objects)
+	return objects, nil
+}
+
+// Sort sorts objects deterministically by kind, namespace, name and then apiversion
+func Sort(objects []unstructured.Unstructured) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].GetKind() != objects[j].GetKind() {
+			return objects[i].GetKind() < objects[j].GetKind()
+		}
+		if objects[i].GetNamespace() != objects[j].GetNamespace() {
+			return objects[i].GetNamespace() < objects[j].GetNamespace()
+		}
+		if objects[i].GetName() != objects[j].GetName() {
+			return objects[i].GetName() < objects[j].GetName()
+		}
+		return objects[i].GetAPIVersion() < objects[j].GetAPIVersion()
+	})
+}
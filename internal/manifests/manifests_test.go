@@ -0,0 +1,216 @@
+package manifests
+
+import (
+	"testing"
+
+	"github.com/andreyvit/diff"
+)
+
+func TestBundle(t *testing.T) {
+	type file struct {
+		name string
+		data string
+	}
+	tests := []struct {
+		name     string
+		files    []file
+		wantYAML string
+		wantJSON string
+		wantErr  bool
+	}{
+		{
+			name: "test1 - sorted by kind and name",
+			files: []file{
+				{
+					name: "deployment-node.yaml",
+					data: "---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: node\n",
+				},
+				{
+					name: "services.yaml",
+					data: "---\napiVersion: v1\nkind: Service\nmetadata:\n  name: node\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: mariadb\n",
+				},
+				{
+					name: "deployment-mariadb.yaml",
+					data: "---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: mariadb\n",
+				},
+			},
+			wantYAML: `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: mariadb
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: node
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: mariadb
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: node
+`,
+			wantJSON: `{
+  "kind": "List",
+  "apiVersion": "v1",
+  "metadata": {},
+  "items": [
+    {
+      "apiVersion": "apps/v1",
+      "kind": "Deployment",
+      "metadata": {
+        "name": "mariadb"
+      }
+    },
+    {
+      "apiVersion": "apps/v1",
+      "kind": "Deployment",
+      "metadata": {
+        "name": "node"
+      }
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Service",
+      "metadata": {
+        "name": "mariadb"
+      }
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Service",
+      "metadata": {
+        "name": "node"
+      }
+    }
+  ]
+}`,
+		},
+		{
+			name: "test2 - same file overwrites previous objects and empty documents are skipped",
+			files: []file{
+				{
+					name: "example.com.yaml",
+					data: "---\napiVersion: networking.k8s.io/v1\nkind: Ingress\nmetadata:\n  name: example.com\n  labels:\n    route: main\n",
+				},
+				{
+					name: "example.com.yaml",
+					data: "---\n---\napiVersion: networking.k8s.io/v1\nkind: Ingress\nmetadata:\n  name: example.com\n  labels:\n    route: activestandby\n",
+				},
+			},
+			wantYAML: `---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  labels:
+    route: activestandby
+  name: example.com
+`,
+			wantJSON: `{
+  "kind": "List",
+  "apiVersion": "v1",
+  "metadata": {},
+  "items": [
+    {
+      "apiVersion": "networking.k8s.io/v1",
+      "kind": "Ingress",
+      "metadata": {
+        "labels": {
+          "route": "activestandby"
+        },
+        "name": "example.com"
+      }
+    }
+  ]
+}`,
+		},
+		{
+			name: "test3 - lists are expanded",
+			files: []file{
+				{
+					name: "list.json",
+					data: `{"apiVersion":"v1","kind":"List","items":[{"apiVersion":"v1","kind":"Secret","metadata":{"name":"b"}},{"apiVersion":"v1","kind":"Secret","metadata":{"name":"a"}}]}`,
+				},
+			},
+			wantYAML: `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: a
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: b
+`,
+			wantJSON: `{
+  "kind": "List",
+  "apiVersion": "v1",
+  "metadata": {},
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "Secret",
+      "metadata": {
+        "name": "a"
+      }
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Secret",
+      "metadata": {
+        "name": "b"
+      }
+    }
+  ]
+}`,
+		},
+		{
+			name: "test4 - invalid yaml",
+			files: []file{
+				{
+					name: "broken.yaml",
+					data: "---\nkind: [Service\n",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBundle()
+			var err error
+			for _, f := range tt.files {
+				if err = b.Add(f.name, []byte(f.data)); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Add() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			gotYAML, err := b.YAML()
+			if err != nil {
+				t.Errorf("YAML() error = %v", err)
+			}
+			if string(gotYAML) != tt.wantYAML {
+				t.Errorf("YAML() = \n%v", diff.LineDiff(tt.wantYAML, string(gotYAML)))
+			}
+			gotJSON, err := b.JSON()
+			if err != nil {
+				t.Errorf("JSON() error = %v", err)
+			}
+			if string(gotJSON) != tt.wantJSON {
+				t.Errorf("JSON() = \n%v", diff.LineDiff(tt.wantJSON, string(gotJSON)))
+			}
+		})
+	}
+}
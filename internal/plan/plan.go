@@ -0,0 +1,94 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/apply"
+	"github.com/uselagoon/build-deploy-tool/internal/manifests"
+	"github.com/uselagoon/build-deploy-tool/internal/prune"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Planner compares generated objects against the live objects in a namespace
+type Planner struct {
+	Client    dynamic.Interface
+	Namespace string
+	// PrunePVCs includes persistent volume claims in the objects that would be deleted
+	PrunePVCs bool
+	// PruneIngress includes ingress in the objects that would be deleted
+	PruneIngress bool
+}
+
+// NewPlanner returns a planner for the provided namespace
+func NewPlanner(client dynamic.Interface, namespace string, prunePVCs, pruneIngress bool) *Planner {
+	return &Planner{
+		Client:       client,
+		Namespace:    namespace,
+		PrunePVCs:    prunePVCs,
+		PruneIngress: pruneIngress,
+	}
+}
+
+// Plan returns the changes that applying the generated objects, and pruning anything that is no longer generated, would
+// make to the namespace. Live objects are trimmed to the fields that the generated objects set or that the build owns, so that
+// defaults and fields populated by the api server are not reported as changes, but fields that would be removed are
+func (p *Planner) Plan(ctx context.Context, generated []unstructured.Unstructured) ([]manifests.Change, error) {
+	live := []unstructured.Unstructured{}
+	desired := []unstructured.Unstructured{}
+	for _, g := range generated {
+		obj := g.DeepCopy()
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(p.Namespace)
+		}
+		desired = append(desired, *obj)
+		existing, err := p.get(ctx, obj.GroupVersionKind(), obj.GetName())
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			// the managed fields are removed when an object is normalized, so the live object is trimmed first
+			live = append(live, manifests.Normalize(manifests.TrimTo(*existing, manifests.Normalize(*obj), apply.FieldManager)))
+		}
+	}
+	// anything that would be pruned shows up as deleted
+	pruner := prune.NewPruner(p.Client, p.Namespace, true, p.PrunePVCs, p.PruneIngress)
+	stale, err := pruner.Prune(ctx, generated)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stale {
+		if s.Retained {
+			continue
+		}
+		for _, gvk := range append(append([]schema.GroupVersionKind{}, prune.Kinds...), prune.PersistentVolumeClaimKind) {
+			if gvk.Kind != s.Kind {
+				continue
+			}
+			existing, err := p.get(ctx, gvk, s.Name)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				live = append(live, *existing)
+				break
+			}
+		}
+	}
+	return manifests.Compare(live, desired)
+}
+
+func (p *Planner) get(ctx context.Context, gvk schema.GroupVersionKind, name string) (*unstructured.Unstructured, error) {
+	existing, err := p.Client.Resource(apply.GroupVersionResource(gvk)).Namespace(p.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("couldn't get %s %s: %v", gvk.Kind, name, err)
+	}
+	return existing, nil
+}
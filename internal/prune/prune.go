@@ -0,0 +1,145 @@
+package prune
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/apply"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// LabelSelector selects the objects that were created by the build-deploy-tool for a lagoon service, anything without
+// these labels is never considered for pruning
+const LabelSelector = "app.kubernetes.io/managed-by=build-deploy-tool,lagoon.sh/service"
+
+// Kinds are the kinds that are pruned, in the order they are deleted
+var Kinds = []schema.GroupVersionKind{
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+	{Group: "batch", Version: "v1", Kind: "CronJob"},
+	{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"},
+	{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "", Version: "v1", Kind: "Service"},
+	{Group: "backup.appuio.ch", Version: "v1alpha1", Kind: "PreBackupPod"},
+	{Group: "k8up.io", Version: "v1", Kind: "PreBackupPod"},
+}
+
+// PersistentVolumeClaimKind is only pruned if it has been explicitly enabled
+var PersistentVolumeClaimKind = schema.GroupVersionKind{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"}
+
+// the labels that protect an object from being pruned, the same as the route cleanup in the legacy build
+const (
+	// RemoveLabel set to false on an object stops it from being pruned
+	RemoveLabel = "lagoon.sh/remove"
+	// HTTP01SolverLabel is set on the temporary ingress that cert-manager creates to solve http01 challenges
+	HTTP01SolverLabel = "acme.cert-manager.io/http01-solver"
+)
+
+var ingressGroupKind = schema.GroupKind{Group: "networking.k8s.io", Kind: "Ingress"}
+
+// Object is an object that has been, or would be, pruned
+type Object struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// Retained is set if the object is no longer generated, but was not deleted because pruning it isn't enabled
+	Retained bool `json:"retained,omitempty"`
+}
+
+func (o Object) String() string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(o.Kind), o.Name)
+}
+
+// Pruner removes objects from a namespace that are no longer generated by a build
+type Pruner struct {
+	Client    dynamic.Interface
+	Namespace string
+	DryRun    bool
+	// PrunePVCs must be enabled for persistent volume claims to be pruned, deleting a pvc deletes the data in it
+	PrunePVCs bool
+	// PruneIngress must be enabled for ingress to be pruned, this is the CLEANUP_REMOVED_LAGOON_ROUTES feature flag. Stale
+	// ingress are returned as retained if it isn't enabled
+	PruneIngress bool
+}
+
+// NewPruner returns a pruner for the provided namespace
+func NewPruner(client dynamic.Interface, namespace string, dryRun, prunePVCs, pruneIngress bool) *Pruner {
+	return &Pruner{
+		Client:       client,
+		Namespace:    namespace,
+		DryRun:       dryRun,
+		PrunePVCs:    prunePVCs,
+		PruneIngress: pruneIngress,
+	}
+}
+
+// Prune deletes any labelled objects in the namespace that are not in the provided set of generated objects. The objects that
+// were deleted are returned, in dry run mode the objects that would be deleted are returned and nothing is deleted. Objects
+// labelled lagoon.sh/remove=false and the cert-manager http01 solver ingress are never pruned
+func (p *Pruner) Prune(ctx context.Context, generated []unstructured.Unstructured) ([]Object, error) {
+	keep := map[string]bool{}
+	for _, o := range generated {
+		keep[objectKey(o.GroupVersionKind().GroupKind(), o.GetName())] = true
+	}
+	kinds := Kinds
+	if p.PrunePVCs {
+		kinds = append(append([]schema.GroupVersionKind{}, Kinds...), PersistentVolumeClaimKind)
+	}
+	pruned := []Object{}
+	for _, gvk := range kinds {
+		client := p.Client.Resource(apply.GroupVersionResource(gvk)).Namespace(p.Namespace)
+		existing, err := client.List(ctx, metav1.ListOptions{LabelSelector: LabelSelector})
+		if err != nil {
+			// the custom resources may not be installed in the cluster, so there is nothing to prune
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return pruned, fmt.Errorf("couldn't list %s: %v", gvk.Kind, err)
+		}
+		for _, e := range existing.Items {
+			if keep[objectKey(gvk.GroupKind(), e.GetName())] || protected(gvk.GroupKind(), e) {
+				continue
+			}
+			if gvk.GroupKind() == ingressGroupKind && !p.PruneIngress {
+				pruned = append(pruned, Object{
+					Kind:      gvk.Kind,
+					Name:      e.GetName(),
+					Namespace: p.Namespace,
+					Retained:  true,
+				})
+				continue
+			}
+			if !p.DryRun {
+				propagation := metav1.DeletePropagationBackground
+				err := client.Delete(ctx, e.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+				if err != nil && !apierrors.IsNotFound(err) {
+					return pruned, fmt.Errorf("couldn't delete %s %s: %v", gvk.Kind, e.GetName(), err)
+				}
+			}
+			pruned = append(pruned, Object{
+				Kind:      gvk.Kind,
+				Name:      e.GetName(),
+				Namespace: p.Namespace,
+			})
+		}
+	}
+	return pruned, nil
+}
+
+// protected checks if an object has been labelled so that it is never pruned
+func protected(gk schema.GroupKind, o unstructured.Unstructured) bool {
+	labels := o.GetLabels()
+	if labels[RemoveLabel] == "false" {
+		return true
+	}
+	return gk == ingressGroupKind && labels[HTTP01SolverLabel] == "true"
+}
+
+func objectKey(gk schema.GroupKind, name string) string {
+	return fmt.Sprintf("%s/%s", gk.String(), name)
+}
@@ -0,0 +1,226 @@
+package prune
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/apply"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+const testNamespace = "example-project-main"
+
+func newFakeClient(objects ...runtime.Object) *fake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, gvk := range append(Kinds, PersistentVolumeClaimKind) {
+		listKinds[apply.GroupVersionResource(gvk)] = gvk.Kind + "List"
+	}
+	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func newObject(apiVersion, kind, name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": testNamespace,
+		},
+	}}
+	obj.SetLabels(labels)
+	return obj
+}
+
+func managed(service string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/managed-by": "build-deploy-tool",
+		"lagoon.sh/service":            service,
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     []runtime.Object
+		generated    []unstructured.Unstructured
+		dryRun       bool
+		prunePVCs    bool
+		pruneIngress bool
+		want         []Object
+		wantRemain   []Object
+	}{
+		{
+			name: "test1 - stale objects are pruned",
+			existing: []runtime.Object{
+				newObject("networking.k8s.io/v1", "Ingress", "example.com", managed("example.com")),
+				newObject("networking.k8s.io/v1", "Ingress", "old.example.com", managed("old.example.com")),
+				newObject("batch/v1", "CronJob", "cronjob-cli-old", managed("cli")),
+				newObject("apps/v1", "Deployment", "node", managed("node")),
+				newObject("apps/v1", "Deployment", "redis", managed("redis")),
+				newObject("v1", "Service", "redis", managed("redis")),
+				newObject("backup.appuio.ch/v1alpha1", "PreBackupPod", "mariadb-prebackuppod", managed("mariadb")),
+			},
+			generated: []unstructured.Unstructured{
+				*newObject("networking.k8s.io/v1", "Ingress", "example.com", nil),
+				*newObject("apps/v1", "Deployment", "node", nil),
+			},
+			pruneIngress: true,
+			want: []Object{
+				{Kind: "Ingress", Name: "old.example.com", Namespace: testNamespace},
+				{Kind: "CronJob", Name: "cronjob-cli-old", Namespace: testNamespace},
+				{Kind: "Deployment", Name: "redis", Namespace: testNamespace},
+				{Kind: "Service", Name: "redis", Namespace: testNamespace},
+				{Kind: "PreBackupPod", Name: "mariadb-prebackuppod", Namespace: testNamespace},
+			},
+			wantRemain: []Object{
+				{Kind: "Ingress", Name: "example.com"},
+				{Kind: "Deployment", Name: "node"},
+			},
+		},
+		{
+			name: "test2 - unlabelled objects and pvcs are not pruned",
+			existing: []runtime.Object{
+				newObject("networking.k8s.io/v1", "Ingress", "helm.example.com", nil),
+				newObject("apps/v1", "Deployment", "other", map[string]string{"app.kubernetes.io/managed-by": "build-deploy-tool"}),
+				newObject("v1", "PersistentVolumeClaim", "redis", managed("redis")),
+			},
+			want: []Object{},
+			wantRemain: []Object{
+				{Kind: "Ingress", Name: "helm.example.com"},
+				{Kind: "Deployment", Name: "other"},
+				{Kind: "PersistentVolumeClaim", Name: "redis"},
+			},
+		},
+		{
+			name: "test3 - pvcs are pruned when enabled",
+			existing: []runtime.Object{
+				newObject("v1", "PersistentVolumeClaim", "redis", managed("redis")),
+				newObject("v1", "PersistentVolumeClaim", "nginx", managed("nginx")),
+			},
+			generated: []unstructured.Unstructured{
+				*newObject("v1", "PersistentVolumeClaim", "nginx", nil),
+			},
+			prunePVCs: true,
+			want: []Object{
+				{Kind: "PersistentVolumeClaim", Name: "redis", Namespace: testNamespace},
+			},
+			wantRemain: []Object{
+				{Kind: "PersistentVolumeClaim", Name: "nginx"},
+			},
+		},
+		{
+			name: "test4 - dry run does not delete anything",
+			existing: []runtime.Object{
+				newObject("batch/v1", "CronJob", "cronjob-cli-old", managed("cli")),
+			},
+			dryRun: true,
+			want: []Object{
+				{Kind: "CronJob", Name: "cronjob-cli-old", Namespace: testNamespace},
+			},
+			wantRemain: []Object{
+				{Kind: "CronJob", Name: "cronjob-cli-old"},
+			},
+		},
+		{
+			name: "test5 - ingress are retained unless route cleanup is enabled",
+			existing: []runtime.Object{
+				newObject("networking.k8s.io/v1", "Ingress", "old.example.com", managed("old.example.com")),
+				newObject("batch/v1", "CronJob", "cronjob-cli-old", managed("cli")),
+			},
+			want: []Object{
+				{Kind: "Ingress", Name: "old.example.com", Namespace: testNamespace, Retained: true},
+				{Kind: "CronJob", Name: "cronjob-cli-old", Namespace: testNamespace},
+			},
+			wantRemain: []Object{
+				{Kind: "Ingress", Name: "old.example.com"},
+			},
+		},
+		{
+			name: "test6 - objects labelled lagoon.sh/remove=false are not pruned",
+			existing: []runtime.Object{
+				newObject("networking.k8s.io/v1", "Ingress", "keep.example.com", func() map[string]string {
+					labels := managed("keep.example.com")
+					labels["lagoon.sh/remove"] = "false"
+					return labels
+				}()),
+				newObject("apps/v1", "Deployment", "redis", func() map[string]string {
+					labels := managed("redis")
+					labels["lagoon.sh/remove"] = "false"
+					return labels
+				}()),
+				newObject("v1", "Service", "redis", managed("redis")),
+			},
+			pruneIngress: true,
+			want: []Object{
+				{Kind: "Service", Name: "redis", Namespace: testNamespace},
+			},
+			wantRemain: []Object{
+				{Kind: "Ingress", Name: "keep.example.com"},
+				{Kind: "Deployment", Name: "redis"},
+			},
+		},
+		{
+			name: "test7 - cert-manager http01 solver ingress are not pruned",
+			existing: []runtime.Object{
+				newObject("networking.k8s.io/v1", "Ingress", "cm-acme-http-solver-abcde", func() map[string]string {
+					labels := managed("example.com")
+					labels["acme.cert-manager.io/http01-solver"] = "true"
+					return labels
+				}()),
+				newObject("networking.k8s.io/v1", "Ingress", "old.example.com", managed("old.example.com")),
+			},
+			pruneIngress: true,
+			want: []Object{
+				{Kind: "Ingress", Name: "old.example.com", Namespace: testNamespace},
+			},
+			wantRemain: []Object{
+				{Kind: "Ingress", Name: "cm-acme-http-solver-abcde"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient(tt.existing...)
+			p := NewPruner(client, testNamespace, tt.dryRun, tt.prunePVCs, tt.pruneIngress)
+			got, err := p.Prune(context.TODO(), tt.generated)
+			if err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Prune() = %v, want %v", got, tt.want)
+			}
+			for _, o := range got {
+				if tt.dryRun || o.Retained {
+					continue
+				}
+				for _, gvk := range append(Kinds, PersistentVolumeClaimKind) {
+					if gvk.Kind != o.Kind {
+						continue
+					}
+					if _, err := client.Resource(apply.GroupVersionResource(gvk)).Namespace(testNamespace).Get(context.TODO(), o.Name, metav1.GetOptions{}); err == nil {
+						t.Errorf("Prune() %s was not deleted", o)
+					}
+				}
+			}
+			for _, o := range tt.wantRemain {
+				found := false
+				for _, gvk := range append(Kinds, PersistentVolumeClaimKind) {
+					if gvk.Kind != o.Kind {
+						continue
+					}
+					if _, err := client.Resource(apply.GroupVersionResource(gvk)).Namespace(testNamespace).Get(context.TODO(), o.Name, metav1.GetOptions{}); err == nil {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("Prune() %s was deleted", o)
+				}
+			}
+		})
+	}
+}
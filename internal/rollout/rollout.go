@@ -0,0 +1,374 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// DefaultTimeout matches the fallback timeout used by the legacy rollout monitor, double the default progressDeadlineSeconds
+	DefaultTimeout = 20 * time.Minute
+	// DefaultLogLines is the number of container log lines shown for failing pods
+	DefaultLogLines = 50
+	// DefaultProbeFailureThreshold is the number of times a probe can fail on a pod before the rollout is considered failed
+	DefaultProbeFailureThreshold = 10
+
+	// the annotation the deployment controller uses to match a deployment to the replicaset of its current revision
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+// waitingFailureReasons are container waiting reasons that mean a rollout will not complete without intervention
+var waitingFailureReasons = []string{
+	"CrashLoopBackOff",
+	"ImagePullBackOff",
+	"ErrImagePull",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	"RunContainerError",
+}
+
+// RolloutError is returned when a deployment fails to roll out
+type RolloutError struct {
+	Deployment string
+	Reason     string
+}
+
+func (e *RolloutError) Error() string {
+	return fmt.Sprintf("rollout for %s failed: %s", e.Deployment, e.Reason)
+}
+
+// Monitor watches deployments in a namespace until they have rolled out, or failed to
+type Monitor struct {
+	Client                kubernetes.Interface
+	Namespace             string
+	Timeout               time.Duration
+	ServiceTimeouts       map[string]time.Duration
+	LogLines              int64
+	ProbeFailureThreshold int32
+	Out                   io.Writer
+	interval              time.Duration
+}
+
+// NewMonitor returns a monitor with the default timeouts
+func NewMonitor(client kubernetes.Interface, namespace string) *Monitor {
+	return &Monitor{
+		Client:                client,
+		Namespace:             namespace,
+		Timeout:               DefaultTimeout,
+		ServiceTimeouts:       map[string]time.Duration{},
+		LogLines:              DefaultLogLines,
+		ProbeFailureThreshold: DefaultProbeFailureThreshold,
+		Out:                   os.Stdout,
+		interval:              time.Second,
+	}
+}
+
+// ParseServiceTimeouts parses a list of service=duration pairs into a map of service timeouts
+func ParseServiceTimeouts(pairs []string) (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	for _, p := range pairs {
+		ps := strings.SplitN(p, "=", 2)
+		if len(ps) != 2 || ps[0] == "" {
+			return nil, fmt.Errorf("service timeout %s must be in the format service=duration", p)
+		}
+		d, err := time.ParseDuration(ps[1])
+		if err != nil {
+			return nil, fmt.Errorf("service timeout %s has an invalid duration: %v", p, err)
+		}
+		timeouts[ps[0]] = d
+	}
+	return timeouts, nil
+}
+
+// Wait watches the provided deployments until they have all rolled out. If any deployment fails, the events and the
+// last container log lines for failing pods are written to the output and an error is returned
+func (m *Monitor) Wait(ctx context.Context, deployments []appsv1.Deployment) error {
+	if len(deployments) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	factory := informers.NewSharedInformerFactoryWithOptions(m.Client, 0, informers.WithNamespace(m.Namespace))
+	deploymentLister := factory.Apps().V1().Deployments().Lister()
+	replicaSetLister := factory.Apps().V1().ReplicaSets().Lister()
+	podLister := factory.Core().V1().Pods().Lister()
+	eventLister := factory.Core().V1().Events().Lister()
+
+	// any change to a watched resource triggers a check of all the pending deployments
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { notify() },
+		UpdateFunc: func(oldObj, newObj interface{}) { notify() },
+		DeleteFunc: func(obj interface{}) { notify() },
+	}
+	for _, informer := range []cache.SharedIndexInformer{
+		factory.Apps().V1().Deployments().Informer(),
+		factory.Apps().V1().ReplicaSets().Informer(),
+		factory.Core().V1().Pods().Informer(),
+		factory.Core().V1().Events().Informer(),
+	} {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return err
+		}
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	start := time.Now()
+	pending := map[string]appsv1.Deployment{}
+	for _, d := range deployments {
+		pending[d.Name] = d
+	}
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		for _, name := range sortedNames(pending) {
+			deployment, err := deploymentLister.Deployments(m.Namespace).Get(name)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			if deployment != nil {
+				pods, err := m.deploymentPods(replicaSetLister, podLister, deployment)
+				if err != nil {
+					return err
+				}
+				events, err := eventLister.Events(m.Namespace).List(labels.Everything())
+				if err != nil {
+					return err
+				}
+				done, reason := m.status(deployment, pods, events)
+				if done {
+					fmt.Fprintf(m.Out, "deployment %s successfully rolled out\n", name)
+					delete(pending, name)
+					continue
+				}
+				if reason != "" {
+					m.diagnostics(ctx, deployment, pods, events)
+					return &RolloutError{Deployment: name, Reason: reason}
+				}
+			}
+			if time.Since(start) > m.timeout(name) {
+				if deployment != nil {
+					pods, _ := m.deploymentPods(replicaSetLister, podLister, deployment)
+					events, _ := eventLister.Events(m.Namespace).List(labels.Everything())
+					m.diagnostics(ctx, deployment, pods, events)
+				}
+				return &RolloutError{Deployment: name, Reason: fmt.Sprintf("timed out after %s waiting for the rollout to complete", m.timeout(name))}
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) timeout(name string) time.Duration {
+	if t, ok := m.ServiceTimeouts[name]; ok {
+		return t
+	}
+	return m.Timeout
+}
+
+// status checks if a deployment has completed its rollout, if it hasn't and it has failed then the reason is returned
+func (m *Monitor) status(deployment *appsv1.Deployment, pods []*corev1.Pod, events []*corev1.Event) (bool, string) {
+	// the same checks that kubectl rollout status performs, the status and conditions are from the previous rollout until the
+	// controller has observed the new generation
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, ""
+	}
+	for _, c := range deployment.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return false, fmt.Sprintf("ProgressDeadlineExceeded: %s", c.Message)
+		}
+	}
+	for _, pod := range pods {
+		statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if cs.State.Waiting != nil && contains(waitingFailureReasons, cs.State.Waiting.Reason) {
+				return false, fmt.Sprintf("%s: container %s in pod %s: %s", cs.State.Waiting.Reason, cs.Name, pod.Name, cs.State.Waiting.Message)
+			}
+		}
+	}
+	for _, event := range podEvents(pods, events) {
+		if event.Reason == "Unhealthy" && eventCount(event) >= m.ProbeFailureThreshold {
+			return false, fmt.Sprintf("probe failed %d times on pod %s: %s", eventCount(event), event.InvolvedObject.Name, event.Message)
+		}
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.UpdatedReplicas < replicas {
+		return false, ""
+	}
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		return false, ""
+	}
+	if deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas {
+		return false, ""
+	}
+	return true, ""
+}
+
+// deploymentPods returns the pods of the replicaset for the current revision of the deployment, the pods of older replicasets that
+// are still terminating or failing don't belong to this rollout
+func (m *Monitor) deploymentPods(replicaSetLister appslisters.ReplicaSetLister, podLister corelisters.PodLister, deployment *appsv1.Deployment) ([]*corev1.Pod, error) {
+	if deployment.Spec.Selector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replicaSets, err := replicaSetLister.ReplicaSets(m.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	hash := ""
+	for _, rs := range replicaSets {
+		if metav1.IsControlledBy(rs, deployment) && rs.Annotations[revisionAnnotation] != "" &&
+			rs.Annotations[revisionAnnotation] == deployment.Annotations[revisionAnnotation] {
+			hash = rs.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+			break
+		}
+	}
+	if hash == "" {
+		// the replicaset for the new revision hasn't been created yet
+		return nil, nil
+	}
+	pods, err := podLister.Pods(m.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	newPods := []*corev1.Pod{}
+	for _, pod := range pods {
+		if pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey] == hash {
+			newPods = append(newPods, pod)
+		}
+	}
+	sort.Slice(newPods, func(i, j int) bool {
+		return newPods[i].Name < newPods[j].Name
+	})
+	return newPods, nil
+}
+
+// diagnostics writes the events for the deployment and its pods, and the last log lines of any containers that aren't ready
+func (m *Monitor) diagnostics(ctx context.Context, deployment *appsv1.Deployment, pods []*corev1.Pod, events []*corev1.Event) {
+	fmt.Fprintf(m.Out, "##############################################\n")
+	fmt.Fprintf(m.Out, "Rollout for %s failed, the information below could be useful in helping debug what went wrong\n", deployment.Name)
+	fmt.Fprintf(m.Out, "##############################################\n")
+	relevant := []*corev1.Event{}
+	for _, e := range events {
+		if e.InvolvedObject.Kind == "Deployment" && e.InvolvedObject.Name == deployment.Name {
+			relevant = append(relevant, e)
+		}
+	}
+	relevant = append(relevant, podEvents(pods, events)...)
+	if len(relevant) > 0 {
+		fmt.Fprintf(m.Out, "Events:\n")
+		for _, e := range relevant {
+			fmt.Fprintf(m.Out, "%s\t%s/%s\t%s\t%s\n", e.Type, strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name, e.Reason, e.Message)
+		}
+	}
+	for _, pod := range pods {
+		for _, c := range pod.Status.Conditions {
+			if c.Status != corev1.ConditionTrue && c.Message != "" {
+				fmt.Fprintf(m.Out, "pod/%s\t%s\t%s\t%s\n", pod.Name, pod.Status.Phase, c.Type, c.Message)
+			}
+		}
+		statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+		statuses = append(statuses, pod.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if cs.Ready {
+				continue
+			}
+			opts := &corev1.PodLogOptions{
+				Container: cs.Name,
+				TailLines: &m.LogLines,
+			}
+			// if the container has restarted, the logs from the previous container are more likely to contain the reason
+			if cs.RestartCount > 0 {
+				opts.Previous = true
+			}
+			logs, err := m.Client.CoreV1().Pods(m.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+			if err != nil {
+				fmt.Fprintf(m.Out, "======== %s/%s logs unavailable: %v =========\n", pod.Name, cs.Name, err)
+				continue
+			}
+			fmt.Fprintf(m.Out, "======== %s/%s =========\n%s\n", pod.Name, cs.Name, string(logs))
+		}
+	}
+	fmt.Fprintf(m.Out, "##############################################\n")
+}
+
+func podEvents(pods []*corev1.Pod, events []*corev1.Event) []*corev1.Event {
+	result := []*corev1.Event{}
+	for _, e := range events {
+		if e.InvolvedObject.Kind != "Pod" {
+			continue
+		}
+		for _, pod := range pods {
+			if e.InvolvedObject.Name == pod.Name {
+				result = append(result, e)
+			}
+		}
+	}
+	return result
+}
+
+// eventCount returns the number of times an event has occurred, supporting both the core and events.k8s.io series fields
+func eventCount(e *corev1.Event) int32 {
+	if e.Series != nil && e.Series.Count > e.Count {
+		return e.Series.Count
+	}
+	return e.Count
+}
+
+func sortedNames(deployments map[string]appsv1.Deployment) []string {
+	names := []string{}
+	for name := range deployments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,360 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testNamespace = "example-project-main"
+
+func newDeployment(name string, replicas int32, status appsv1.DeploymentStatus) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  testNamespace,
+			UID:        types.UID(name),
+			Generation: 1,
+			Annotations: map[string]string{
+				revisionAnnotation: "2",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app.kubernetes.io/instance": name,
+				},
+			},
+		},
+		Status: status,
+	}
+}
+
+func readyStatus(replicas int32) appsv1.DeploymentStatus {
+	return appsv1.DeploymentStatus{
+		ObservedGeneration: 1,
+		Replicas:           replicas,
+		UpdatedReplicas:    replicas,
+		AvailableReplicas:  replicas,
+	}
+}
+
+// newReplicaSet returns a replicaset of the deployment for the revision, the pods of the replicaset have the hash label
+func newReplicaSet(deployment, hash, revision string) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deployment + "-" + hash,
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/instance":           deployment,
+				appsv1.DefaultDeploymentUniqueLabelKey: hash,
+			},
+			Annotations: map[string]string{
+				revisionAnnotation: revision,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       deployment,
+					UID:        types.UID(deployment),
+					Controller: helpers.BoolPtr(true),
+				},
+			},
+		},
+	}
+}
+
+func newPod(name, instance, hash string, statuses ...corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/instance":           instance,
+				appsv1.DefaultDeploymentUniqueLabelKey: hash,
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: statuses,
+		},
+	}
+}
+
+func waitingStatus(name, reason string) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:         name,
+		RestartCount: 3,
+		State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{
+				Reason:  reason,
+				Message: "back-off restarting failed container",
+			},
+		},
+	}
+}
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		name            string
+		objects         []runtime.Object
+		deployments     []string
+		serviceTimeouts map[string]time.Duration
+		wantErr         *RolloutError
+		wantOutput      []string
+	}{
+		{
+			name: "test1 - deployments are rolled out",
+			objects: []runtime.Object{
+				newDeployment("nginx", 1, readyStatus(1)),
+				newDeployment("cli", 2, readyStatus(2)),
+			},
+			deployments: []string{"nginx", "cli"},
+			wantOutput: []string{
+				"deployment cli successfully rolled out",
+				"deployment nginx successfully rolled out",
+			},
+		},
+		{
+			name: "test2 - progress deadline exceeded",
+			objects: []runtime.Object{
+				newDeployment("nginx", 1, readyStatus(1)),
+				newDeployment("php", 1, appsv1.DeploymentStatus{
+					ObservedGeneration: 1,
+					Replicas:           2,
+					UpdatedReplicas:    1,
+					Conditions: []appsv1.DeploymentCondition{
+						{
+							Type:    appsv1.DeploymentProgressing,
+							Status:  corev1.ConditionFalse,
+							Reason:  "ProgressDeadlineExceeded",
+							Message: `ReplicaSet "php-5d8c" has timed out progressing.`,
+						},
+					},
+				}),
+			},
+			deployments: []string{"nginx", "php"},
+			wantErr: &RolloutError{
+				Deployment: "php",
+				Reason:     `ProgressDeadlineExceeded: ReplicaSet "php-5d8c" has timed out progressing.`,
+			},
+			wantOutput: []string{
+				"Rollout for php failed",
+			},
+		},
+		{
+			name: "test3 - crashloopbackoff shows events and logs",
+			objects: []runtime.Object{
+				newDeployment("nginx", 1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1}),
+				newReplicaSet("nginx", "abc", "2"),
+				newPod("nginx-abc", "nginx", "abc", waitingStatus("php", "CrashLoopBackOff")),
+				&corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "nginx-abc.1", Namespace: testNamespace},
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "nginx-abc"},
+					Type:           "Warning",
+					Reason:         "BackOff",
+					Message:        "Back-off restarting failed container",
+				},
+			},
+			deployments: []string{"nginx"},
+			wantErr: &RolloutError{
+				Deployment: "nginx",
+				Reason:     "CrashLoopBackOff: container php in pod nginx-abc: back-off restarting failed container",
+			},
+			wantOutput: []string{
+				"Warning\tpod/nginx-abc\tBackOff\tBack-off restarting failed container",
+				"======== nginx-abc/php =========\nfake logs",
+			},
+		},
+		{
+			name: "test4 - image pull backoff",
+			objects: []runtime.Object{
+				newDeployment("node", 1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1}),
+				newReplicaSet("node", "abc", "2"),
+				newPod("node-abc", "node", "abc", waitingStatus("node", "ImagePullBackOff")),
+			},
+			deployments: []string{"node"},
+			wantErr: &RolloutError{
+				Deployment: "node",
+				Reason:     "ImagePullBackOff: container node in pod node-abc: back-off restarting failed container",
+			},
+		},
+		{
+			name: "test5 - failed probes",
+			objects: []runtime.Object{
+				newDeployment("node", 1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1}),
+				newReplicaSet("node", "abc", "2"),
+				newPod("node-abc", "node", "abc", corev1.ContainerStatus{Name: "node"}),
+				&corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "node-abc.1", Namespace: testNamespace},
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "node-abc"},
+					Type:           "Warning",
+					Reason:         "Unhealthy",
+					Message:        "Readiness probe failed: dial tcp 10.0.0.1:3000: connect: connection refused",
+					Count:          12,
+				},
+			},
+			deployments: []string{"node"},
+			wantErr: &RolloutError{
+				Deployment: "node",
+				Reason:     "probe failed 12 times on pod node-abc: Readiness probe failed: dial tcp 10.0.0.1:3000: connect: connection refused",
+			},
+		},
+		{
+			name: "test6 - service timeout",
+			objects: []runtime.Object{
+				newDeployment("nginx", 1, readyStatus(1)),
+				newDeployment("solr", 1, appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1}),
+			},
+			deployments: []string{"nginx", "solr"},
+			serviceTimeouts: map[string]time.Duration{
+				"solr": 50 * time.Millisecond,
+			},
+			wantErr: &RolloutError{
+				Deployment: "solr",
+				Reason:     "timed out after 50ms waiting for the rollout to complete",
+			},
+			wantOutput: []string{
+				"deployment nginx successfully rolled out",
+			},
+		},
+		{
+			name: "test7 - a progress deadline from the previous rollout isn't a failure until the new generation is observed",
+			objects: []runtime.Object{
+				func() *appsv1.Deployment {
+					d := newDeployment("php", 1, appsv1.DeploymentStatus{
+						ObservedGeneration: 1,
+						Replicas:           1,
+						UpdatedReplicas:    1,
+						Conditions: []appsv1.DeploymentCondition{
+							{
+								Type:    appsv1.DeploymentProgressing,
+								Status:  corev1.ConditionFalse,
+								Reason:  "ProgressDeadlineExceeded",
+								Message: `ReplicaSet "php-5d8c" has timed out progressing.`,
+							},
+						},
+					})
+					d.Generation = 2
+					return d
+				}(),
+			},
+			deployments: []string{"php"},
+			serviceTimeouts: map[string]time.Duration{
+				"php": 50 * time.Millisecond,
+			},
+			wantErr: &RolloutError{
+				Deployment: "php",
+				Reason:     "timed out after 50ms waiting for the rollout to complete",
+			},
+		},
+		{
+			name: "test8 - failing pods of an old replicaset are ignored",
+			objects: []runtime.Object{
+				newDeployment("node", 1, readyStatus(1)),
+				newReplicaSet("node", "old", "1"),
+				newReplicaSet("node", "new", "2"),
+				newPod("node-old", "node", "old", waitingStatus("node", "CrashLoopBackOff")),
+				newPod("node-new", "node", "new", corev1.ContainerStatus{Name: "node", Ready: true}),
+				&corev1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "node-old.1", Namespace: testNamespace},
+					InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "node-old"},
+					Type:           "Warning",
+					Reason:         "Unhealthy",
+					Message:        "Readiness probe failed: dial tcp 10.0.0.1:3000: connect: connection refused",
+					Count:          12,
+				},
+			},
+			deployments: []string{"node"},
+			wantOutput: []string{
+				"deployment node successfully rolled out",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			m := NewMonitor(fake.NewSimpleClientset(tt.objects...), testNamespace)
+			m.Timeout = 10 * time.Second
+			m.ServiceTimeouts = tt.serviceTimeouts
+			m.Out = out
+			m.interval = 10 * time.Millisecond
+			deployments := []appsv1.Deployment{}
+			for _, d := range tt.deployments {
+				deployments = append(deployments, appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: d}})
+			}
+			err := m.Wait(context.TODO(), deployments)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Wait() error = %v", err)
+			}
+			if tt.wantErr != nil {
+				var rerr *RolloutError
+				if !errors.As(err, &rerr) {
+					t.Fatalf("Wait() error = %v, want %v", err, tt.wantErr)
+				}
+				if !reflect.DeepEqual(rerr, tt.wantErr) {
+					t.Errorf("Wait() error = %v, want %v", rerr, tt.wantErr)
+				}
+			}
+			for _, o := range tt.wantOutput {
+				if !strings.Contains(out.String(), o) {
+					t.Errorf("Wait() output = %v, want to contain %v", out.String(), o)
+				}
+			}
+		})
+	}
+}
+
+func TestParseServiceTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		want    map[string]time.Duration
+		wantErr bool
+	}{
+		{
+			name:  "test1 - valid timeouts",
+			pairs: []string{"nginx=30m", "solr=90s"},
+			want: map[string]time.Duration{
+				"nginx": 30 * time.Minute,
+				"solr":  90 * time.Second,
+			},
+		},
+		{
+			name:    "test2 - missing duration",
+			pairs:   []string{"nginx"},
+			wantErr: true,
+		},
+		{
+			name:    "test3 - invalid duration",
+			pairs:   []string{"nginx=thirty"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseServiceTimeouts(tt.pairs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseServiceTimeouts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseServiceTimeouts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package servicetypes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/xeipuuv/gojsonschema"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+// Definitions is a file of additional service types that a cluster can provide to builds, the remote-controller provides the path
+// to the file, or to a mounted configmap directory of files. The service types are added to the built-in service types, a
+// service type can't replace a built-in service type, but it can extend one and only define the values that differ.
+//
+//	serviceTypes:
+//	  memcached:
+//	    ports:
+//	      - name: memcached
+//	        port: 11211
+//	  java:
+//	    extends: basic
+//	    autogeneratedRoutes: true
+type Definitions struct {
+	ServiceTypes map[string]Definition `json:"serviceTypes"`
+}
+
+// Definition is a service type in the definitions file
+type Definition struct {
+	// the built-in service type that this service type starts from
+	Extends string           `json:"extends,omitempty"`
+	Ports   []DefinitionPort `json:"ports,omitempty"`
+	// the port can be changed with the `lagoon.service.port` label, this requires tcp probes
+	CanChangePort      *bool                         `json:"canChangePort,omitempty"`
+	ReadinessProbe     *corev1.Probe                 `json:"readinessProbe,omitempty"`
+	LivenessProbe      *corev1.Probe                 `json:"livenessProbe,omitempty"`
+	Strategy           appsv1.DeploymentStrategyType `json:"strategy,omitempty"`
+	PodSecurityContext *DefinitionPodSecurityContext `json:"podSecurityContext,omitempty"`
+	PersistentVolume   *DefinitionVolume             `json:"persistentVolume,omitempty"`
+	BackupCommand      *DefinitionBackupCommand      `json:"backupCommand,omitempty"`
+	EnableServiceLinks *bool                         `json:"enableServiceLinks,omitempty"`
+	// the service type supports autogenerated routes
+	AutogeneratedRoutes *bool `json:"autogeneratedRoutes,omitempty"`
+	// the service type has resources that require backups, this enables the backup schedule for the environment
+	Backups *bool `json:"backups,omitempty"`
+}
+
+// DefinitionPort is a port of a service type, the first port is the one that could be associated to an ingress
+type DefinitionPort struct {
+	Name string `json:"name,omitempty"`
+	Port int32  `json:"port"`
+}
+
+// DefinitionPodSecurityContext is the default pod security context of a service type
+type DefinitionPodSecurityContext struct {
+	FSGroup int64 `json:"fsGroup"`
+}
+
+// DefinitionVolume is the default persistent volume of a service type
+type DefinitionVolume struct {
+	Size       string                            `json:"size"`
+	Path       string                            `json:"path"`
+	AccessMode corev1.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+	// the persistent volume is backed up
+	Backup bool `json:"backup,omitempty"`
+}
+
+// DefinitionBackupCommand is the command that backs up the data of a service type, the output is stored in a file with the
+// extension. Both can use 'go template' with generator.ServiceValues, like the built-in service types.
+type DefinitionBackupCommand struct {
+	Command       string `json:"command"`
+	FileExtension string `json:"fileExtension"`
+}
+
+// the name of a service type is used in labels and resource names
+var definitionNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// DefinitionsSchema is the JSON Schema of the definitions file, the probes are validated when they are unmarshalled
+const DefinitionsSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "service type definitions",
+  "type": "object",
+  "required": ["serviceTypes"],
+  "additionalProperties": false,
+  "properties": {
+    "serviceTypes": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "extends": {"type": "string"},
+          "ports": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "required": ["port"],
+              "additionalProperties": false,
+              "properties": {
+                "name": {"type": "string", "pattern": "^[a-z0-9]([-a-z0-9]*[a-z0-9])?$", "maxLength": 15},
+                "port": {"type": "integer", "minimum": 1, "maximum": 65535}
+              }
+            }
+          },
+          "canChangePort": {"type": "boolean"},
+          "readinessProbe": {"type": "object"},
+          "livenessProbe": {"type": "object"},
+          "strategy": {"type": "string", "enum": ["Recreate", "RollingUpdate"]},
+          "podSecurityContext": {
+            "type": "object",
+            "required": ["fsGroup"],
+            "additionalProperties": false,
+            "properties": {
+              "fsGroup": {"type": "integer", "minimum": 0}
+            }
+          },
+          "persistentVolume": {
+            "type": "object",
+            "required": ["size", "path"],
+            "additionalProperties": false,
+            "properties": {
+              "size": {"type": "string"},
+              "path": {"type": "string", "pattern": "^/"},
+              "accessMode": {"type": "string", "enum": ["ReadWriteOnce", "ReadWriteMany"]},
+              "backup": {"type": "boolean"}
+            }
+          },
+          "backupCommand": {
+            "type": "object",
+            "required": ["command", "fileExtension"],
+            "additionalProperties": false,
+            "properties": {
+              "command": {"type": "string"},
+              "fileExtension": {"type": "string"}
+            }
+          },
+          "enableServiceLinks": {"type": "boolean"},
+          "autogeneratedRoutes": {"type": "boolean"},
+          "backups": {"type": "boolean"}
+        }
+      }
+    }
+  }
+}`
+
+// LoadDefinitions loads the service type definitions from a yaml or json file, or from every yaml or json file in a directory
+// like a mounted configmap. The service types are validated, and returned with the values of any built-in service type that
+// they extend.
+func LoadDefinitions(path string) (map[string]ServiceType, error) {
+	files := []string{path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the service type definitions: %v", err)
+	}
+	if info.IsDir() {
+		files = []string{}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the service type definitions: %v", err)
+		}
+		for _, entry := range entries {
+			// configmap mounts contain hidden files and directories that link to the keys
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			switch filepath.Ext(entry.Name()) {
+			case ".yaml", ".yml", ".json":
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+	serviceTypes := map[string]ServiceType{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the service type definitions %s: %v", file, err)
+		}
+		definitions, err := ParseDefinitions(data)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load the service type definitions %s: %v", file, err)
+		}
+		for name, serviceType := range definitions {
+			if _, ok := serviceTypes[name]; ok {
+				return nil, fmt.Errorf("couldn't load the service type definitions %s: service type %s is already defined", file, name)
+			}
+			serviceTypes[name] = serviceType
+		}
+	}
+	return serviceTypes, nil
+}
+
+// ParseDefinitions validates a definitions file against the schema, and converts the definitions into service types
+func ParseDefinitions(data []byte) (map[string]ServiceType, error) {
+	definitionsJSON, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(DefinitionsSchema), gojsonschema.NewBytesLoader(definitionsJSON))
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid() {
+		errs := []string{}
+		for _, e := range result.Errors() {
+			errs = append(errs, e.String())
+		}
+		sort.Strings(errs)
+		return nil, fmt.Errorf("the definitions don't match the schema: %s", strings.Join(errs, ", "))
+	}
+	definitions := Definitions{}
+	if err := yaml.UnmarshalStrict(definitionsJSON, &definitions); err != nil {
+		return nil, err
+	}
+	serviceTypes := map[string]ServiceType{}
+	for name, definition := range definitions.ServiceTypes {
+		serviceType, err := definition.ServiceType(name)
+		if err != nil {
+			return nil, fmt.Errorf("service type %s: %v", name, err)
+		}
+		serviceTypes[name] = serviceType
+	}
+	return serviceTypes, nil
+}
+
+// ServiceType converts the definition into a service type
+func (d Definition) ServiceType(name string) (ServiceType, error) {
+	if !definitionNameRegex.MatchString(name) {
+		return ServiceType{}, fmt.Errorf("the name must be a lowercase RFC 1123 label")
+	}
+	if _, ok := ServiceTypes[name]; ok {
+		return ServiceType{}, fmt.Errorf("a built-in service type can't be redefined, use extends to base a service type on it")
+	}
+	serviceType := ServiceType{
+		PrimaryContainer: ServiceContainer{
+			Container: corev1.Container{
+				ImagePullPolicy: corev1.PullAlways,
+				SecurityContext: &corev1.SecurityContext{},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("10m"),
+						corev1.ResourceMemory: resource.MustParse("10Mi"),
+					},
+				},
+			},
+		},
+	}
+	if d.Extends != "" {
+		base, ok := ServiceTypes[d.Extends]
+		if !ok {
+			return ServiceType{}, fmt.Errorf("extends %s, which isn't a built-in service type", d.Extends)
+		}
+		if err := helpers.DeepCopy(base, &serviceType); err != nil {
+			return ServiceType{}, fmt.Errorf("couldn't copy the service type %s: %v", d.Extends, err)
+		}
+	}
+	serviceType.Name = name
+	serviceType.PrimaryContainer.Name = name
+
+	if d.Ports != nil {
+		serviceType.Ports.Ports = []corev1.ServicePort{}
+		serviceType.PrimaryContainer.Container.Ports = []corev1.ContainerPort{}
+		for _, port := range d.Ports {
+			portName := port.Name
+			if portName == "" {
+				portName = fmt.Sprintf("%d-tcp", port.Port)
+			}
+			serviceType.Ports.Ports = append(serviceType.Ports.Ports, corev1.ServicePort{
+				Port: port.Port,
+				TargetPort: intstr.IntOrString{
+					Type:   intstr.String,
+					StrVal: portName,
+				},
+				Protocol: corev1.ProtocolTCP,
+				Name:     portName,
+			})
+			serviceType.PrimaryContainer.Container.Ports = append(serviceType.PrimaryContainer.Container.Ports, corev1.ContainerPort{
+				Name:          portName,
+				ContainerPort: port.Port,
+				Protocol:      corev1.ProtocolTCP,
+			})
+		}
+		// the probes of the service type that is extended check its ports, so the default probes are used unless they are defined
+		serviceType.PrimaryContainer.Container.ReadinessProbe = nil
+		serviceType.PrimaryContainer.Container.LivenessProbe = nil
+		if len(d.Ports) > 0 {
+			serviceType.PrimaryContainer.Container.ReadinessProbe = tcpProbe(d.Ports[0].Port, 1, 1)
+			serviceType.PrimaryContainer.Container.LivenessProbe = tcpProbe(d.Ports[0].Port, 60, 10)
+		}
+	}
+	if d.ReadinessProbe != nil {
+		serviceType.PrimaryContainer.Container.ReadinessProbe = d.ReadinessProbe
+	}
+	if d.LivenessProbe != nil {
+		serviceType.PrimaryContainer.Container.LivenessProbe = d.LivenessProbe
+	}
+	if d.CanChangePort != nil {
+		serviceType.Ports.CanChangePort = *d.CanChangePort
+	}
+	if serviceType.Ports.CanChangePort {
+		// changing the port changes the port of the first container port and the tcp probes
+		container := serviceType.PrimaryContainer.Container
+		if len(container.Ports) == 0 ||
+			container.ReadinessProbe == nil || container.ReadinessProbe.TCPSocket == nil ||
+			container.LivenessProbe == nil || container.LivenessProbe.TCPSocket == nil {
+			return ServiceType{}, fmt.Errorf("canChangePort requires a port, and tcpSocket readiness and liveness probes")
+		}
+	}
+	if d.Strategy != "" {
+		serviceType.Strategy = appsv1.DeploymentStrategy{Type: d.Strategy}
+	}
+	if d.PodSecurityContext != nil {
+		serviceType.PodSecurityContext = ServicePodSecurityContext{
+			HasDefault: true,
+			FSGroup:    d.PodSecurityContext.FSGroup,
+		}
+	}
+	if d.PersistentVolume != nil {
+		if _, err := resource.ParseQuantity(d.PersistentVolume.Size); err != nil {
+			return ServiceType{}, fmt.Errorf("persistentVolume size %s is not valid: %v", d.PersistentVolume.Size, err)
+		}
+		accessMode := d.PersistentVolume.AccessMode
+		if accessMode == "" {
+			accessMode = corev1.ReadWriteOnce
+		}
+		serviceType.ProvidesPersistentVolume = true
+		serviceType.Volumes.PersistentVolumeSize = d.PersistentVolume.Size
+		serviceType.Volumes.PersistentVolumePath = d.PersistentVolume.Path
+		serviceType.Volumes.PersistentVolumeType = accessMode
+		serviceType.Volumes.Backup = d.PersistentVolume.Backup
+	}
+	if d.BackupCommand != nil {
+		serviceType.Volumes.BackupConfiguration = BackupConfiguration{
+			Command:       d.BackupCommand.Command,
+			FileExtension: d.BackupCommand.FileExtension,
+		}
+	}
+	if d.EnableServiceLinks != nil {
+		serviceType.EnableServiceLinks = *d.EnableServiceLinks
+	}
+	if d.AutogeneratedRoutes != nil {
+		serviceType.AutogeneratedRoutes = *d.AutogeneratedRoutes
+	}
+	if d.Backups != nil {
+		serviceType.Backups = *d.Backups
+	}
+	return serviceType, nil
+}
+
+// tcpProbe is the default probe of a service type from the definitions file
+func tcpProbe(port int32, initialDelaySeconds, timeoutSeconds int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.IntOrString{
+					Type:   intstr.Int,
+					IntVal: port,
+				},
+			},
+		},
+		InitialDelaySeconds: initialDelaySeconds,
+		TimeoutSeconds:      timeoutSeconds,
+	}
+}
@@ -0,0 +1,205 @@
+package servicetypes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseDefinitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		definitions string
+		check       func(t *testing.T, serviceTypes map[string]ServiceType)
+		wantErr     string
+	}{
+		{
+			name: "test1 - new service type",
+			definitions: `
+serviceTypes:
+  memcached:
+    ports:
+      - port: 11211
+    strategy: Recreate
+    podSecurityContext:
+      fsGroup: 0
+    persistentVolume:
+      size: 1Gi
+      path: /var/lib/memcached
+    backupCommand:
+      command: /bin/sh -c 'cat /var/lib/memcached/dump'
+      fileExtension: .{{ .ServiceValues.OverrideName }}.dump
+    backups: true
+`,
+			check: func(t *testing.T, serviceTypes map[string]ServiceType) {
+				st := serviceTypes["memcached"]
+				if st.Name != "memcached" || st.PrimaryContainer.Name != "memcached" {
+					t.Errorf("name = %v, container name = %v", st.Name, st.PrimaryContainer.Name)
+				}
+				if len(st.Ports.Ports) != 1 || st.Ports.Ports[0].Name != "11211-tcp" || st.Ports.Ports[0].TargetPort.StrVal != "11211-tcp" {
+					t.Errorf("ports = %v", st.Ports.Ports)
+				}
+				container := st.PrimaryContainer.Container
+				if container.ReadinessProbe == nil || container.ReadinessProbe.TCPSocket.Port.IntVal != 11211 {
+					t.Errorf("readiness probe = %v", container.ReadinessProbe)
+				}
+				if st.Strategy.Type != appsv1.RecreateDeploymentStrategyType || !st.PodSecurityContext.HasDefault {
+					t.Errorf("strategy = %v, pod security context = %v", st.Strategy, st.PodSecurityContext)
+				}
+				if !st.ProvidesPersistentVolume || st.Volumes.PersistentVolumeType != corev1.ReadWriteOnce || st.Volumes.PersistentVolumeSize != "1Gi" {
+					t.Errorf("volumes = %v", st.Volumes)
+				}
+				if st.Volumes.BackupConfiguration.FileExtension != ".{{ .ServiceValues.OverrideName }}.dump" || !st.Backups || st.AutogeneratedRoutes {
+					t.Errorf("backup configuration = %v, backups = %v", st.Volumes.BackupConfiguration, st.Backups)
+				}
+			},
+		},
+		{
+			name: "test2 - extends a built-in service type",
+			definitions: `
+serviceTypes:
+  java:
+    extends: basic
+    autogeneratedRoutes: true
+    # the port can't be changed with an http probe
+    canChangePort: false
+    livenessProbe:
+      httpGet:
+        path: /health
+        port: 3000
+`,
+			check: func(t *testing.T, serviceTypes map[string]ServiceType) {
+				st := serviceTypes["java"]
+				if st.Name != "java" || st.Ports.CanChangePort || st.Ports.Ports[0].Port != 3000 || !st.AllowAdditionalVolumes {
+					t.Errorf("service type = %v", st)
+				}
+				container := st.PrimaryContainer.Container
+				if container.LivenessProbe.HTTPGet == nil || container.LivenessProbe.HTTPGet.Path != "/health" {
+					t.Errorf("liveness probe = %v", container.LivenessProbe)
+				}
+				if container.ReadinessProbe.TCPSocket == nil || container.ReadinessProbe.TCPSocket.Port.IntVal != 3000 {
+					t.Errorf("readiness probe = %v", container.ReadinessProbe)
+				}
+				// the built-in service type isn't changed
+				if ServiceTypes["basic"].Name != "basic" || ServiceTypes["basic"].PrimaryContainer.Container.LivenessProbe.HTTPGet != nil {
+					t.Errorf("the basic service type was changed")
+				}
+			},
+		},
+		{
+			name: "test3 - unknown field",
+			definitions: `
+serviceTypes:
+  memcached:
+    port: 11211
+`,
+			wantErr: "the definitions don't match the schema: serviceTypes.memcached: Additional property port is not allowed",
+		},
+		{
+			name: "test4 - unknown probe field",
+			definitions: `
+serviceTypes:
+  memcached:
+    livenessProbe:
+      tcp:
+        port: 11211
+`,
+			wantErr: `error unmarshaling JSON: while decoding JSON: json: unknown field "tcp"`,
+		},
+		{
+			name: "test5 - a built-in service type can't be redefined",
+			definitions: `
+serviceTypes:
+  nginx:
+    extends: basic
+`,
+			wantErr: "service type nginx: a built-in service type can't be redefined, use extends to base a service type on it",
+		},
+		{
+			name: "test6 - extends an unknown service type",
+			definitions: `
+serviceTypes:
+  java:
+    extends: tomcat
+`,
+			wantErr: "service type java: extends tomcat, which isn't a built-in service type",
+		},
+		{
+			name: "test7 - the port can only change with tcp probes",
+			definitions: `
+serviceTypes:
+  java:
+    canChangePort: true
+    ports:
+      - name: http
+        port: 8080
+    readinessProbe:
+      httpGet:
+        path: /health
+        port: 8080
+`,
+			wantErr: "service type java: canChangePort requires a port, and tcpSocket readiness and liveness probes",
+		},
+		{
+			name: "test8 - invalid persistent volume size",
+			definitions: `
+serviceTypes:
+  memcached:
+    persistentVolume:
+      size: lots
+      path: /data
+`,
+			wantErr: "service type memcached: persistentVolume size lots is not valid: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDefinitions([]byte(tt.definitions))
+			if err != nil {
+				if err.Error() != tt.wantErr {
+					t.Errorf("ParseDefinitions() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Errorf("ParseDefinitions() error = nil, wantErr %v", tt.wantErr)
+				return
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"memcached.yaml": "serviceTypes:\n  memcached:\n    ports:\n      - port: 11211\n",
+		"java.json":      `{"serviceTypes": {"java": {"extends": "basic"}}}`,
+		"README.md":      "not a definitions file",
+		".hidden.yaml":   "not a definitions file",
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := LoadDefinitions(dir)
+	if err != nil {
+		t.Fatalf("LoadDefinitions() error = %v", err)
+	}
+	if len(got) != 2 || got["memcached"].Name != "memcached" || got["java"].Name != "java" {
+		t.Errorf("LoadDefinitions() = %v", got)
+	}
+
+	// a service type can only be defined once
+	if err := os.WriteFile(filepath.Join(dir, "memcached2.yml"), []byte(files["memcached.yaml"]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDefinitions(dir); err == nil || !strings.Contains(err.Error(), "service type memcached is already defined") {
+		t.Errorf("LoadDefinitions() error = %v, want already defined", err)
+	}
+}
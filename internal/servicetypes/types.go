@@ -18,6 +18,10 @@ type ServiceType struct {
 	ProvidesPersistentVolume bool
 	ConsumesPersistentVolume bool
 	AllowAdditionalVolumes   bool
+	// the built-in service types that support autogenerated routes or have backups are listed in the generator, these are only
+	// set by the service types from the definitions file
+	AutogeneratedRoutes bool
+	Backups             bool
 }
 
 type ServicePodSecurityContext struct {
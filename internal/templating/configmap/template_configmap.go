@@ -0,0 +1,101 @@
+package configmap
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// LagoonEnvName is the name of the configmap that holds the runtime variables for an environment
+const LagoonEnvName = "lagoon-env"
+
+// GenerateLagoonEnvConfigMap generates the lagoon-env configmap from the runtime and global scoped variables.
+func GenerateLagoonEnvConfigMap(
+	buildValues generator.BuildValues,
+) (corev1.ConfigMap, error) {
+	data := LagoonEnvData(buildValues)
+	sha, err := LagoonEnvSha(data)
+	if err != nil {
+		return corev1.ConfigMap{}, err
+	}
+
+	// add the default labels
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "build-deploy-tool",
+		"app.kubernetes.io/name":       LagoonEnvName,
+		"app.kubernetes.io/instance":   LagoonEnvName,
+		"lagoon.sh/template":           fmt.Sprintf("%s-0.1.0", LagoonEnvName),
+		"lagoon.sh/project":            buildValues.Project,
+		"lagoon.sh/environment":        buildValues.Environment,
+		"lagoon.sh/environmentType":    buildValues.EnvironmentType,
+		"lagoon.sh/buildType":          buildValues.BuildType,
+	}
+
+	// add the default annotations
+	annotations := map[string]string{
+		"lagoon.sh/version":      buildValues.LagoonVersion,
+		"lagoon.sh/configMapSha": sha,
+	}
+	if buildValues.BuildType == "branch" {
+		annotations["lagoon.sh/branch"] = buildValues.Branch
+	} else if buildValues.BuildType == "pullrequest" {
+		annotations["lagoon.sh/prNumber"] = buildValues.PRNumber
+		annotations["lagoon.sh/prHeadBranch"] = buildValues.PRHeadBranch
+		annotations["lagoon.sh/prBaseBranch"] = buildValues.PRBaseBranch
+	}
+
+	return corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: corev1.SchemeGroupVersion.Version,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        LagoonEnvName,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Data: data,
+	}, nil
+}
+
+// LagoonEnvData returns the data for the lagoon-env configmap, these are any runtime or global scoped variables from the
+// merged project, environment, and build variables
+func LagoonEnvData(buildValues generator.BuildValues) map[string]string {
+	data := map[string]string{}
+	for _, envvar := range buildValues.EnvironmentVariables {
+		if helpers.Contains([]string{"runtime", "global"}, envvar.Scope) {
+			data[envvar.Name] = envvar.Value
+		}
+	}
+	// the routes are only known once route generation has completed, which is after the build variables are collected
+	// so use the generated routes here
+	data["LAGOON_ROUTE"] = buildValues.Route
+	data["LAGOON_ROUTES"] = strings.Join(buildValues.Routes, ",")
+	data["LAGOON_AUTOGENERATED_ROUTES"] = strings.Join(buildValues.AutogeneratedRoutes, ",")
+	return data
+}
+
+// LagoonEnvSha returns the sha256 of the lagoon-env configmap data, the keys are sorted when marshalled so the
+// sha will only change if a variable changes
+func LagoonEnvSha(data map[string]string) (string, error) {
+	dataBytes, err := yaml.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("couldn't generate configmap sha: %v", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(dataBytes)), nil
+}
+
+// ConfigMapSha returns the configmap sha to use on workloads, if a sha has been provided to the build it is used
+// otherwise the sha of the generated lagoon-env configmap data is used
+func ConfigMapSha(buildValues generator.BuildValues) (string, error) {
+	if buildValues.ConfigMapSha != "" {
+		return buildValues.ConfigMapSha, nil
+	}
+	return LagoonEnvSha(LagoonEnvData(buildValues))
+}
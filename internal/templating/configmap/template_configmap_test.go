@@ -0,0 +1,107 @@
+package configmap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+)
+
+func TestLagoonEnvData(t *testing.T) {
+	tests := []struct {
+		name   string
+		values generator.BuildValues
+		want   map[string]string
+	}{
+		{
+			name: "test1 - only runtime and global variables",
+			values: generator.BuildValues{
+				EnvironmentVariables: []lagoon.EnvironmentVariable{
+					{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+					{Name: "MY_GLOBAL_VAR", Value: "global", Scope: "global"},
+					{Name: "MY_BUILD_VAR", Value: "build", Scope: "build"},
+					{Name: "LAGOON_SYSTEM_ROUTER_PATTERN", Value: "${environment}.example.com", Scope: "internal_system"},
+					{Name: "REGISTRY_PASSWORD", Value: "password", Scope: "container_registry"},
+				},
+				Route:               "https://example.com",
+				Routes:              []string{"https://example.com", "https://www.example.com"},
+				AutogeneratedRoutes: []string{"https://node-example-project-main.example.com"},
+			},
+			want: map[string]string{
+				"LAGOON_PROJECT":              "example-project",
+				"MY_GLOBAL_VAR":               "global",
+				"LAGOON_ROUTE":                "https://example.com",
+				"LAGOON_ROUTES":               "https://example.com,https://www.example.com",
+				"LAGOON_AUTOGENERATED_ROUTES": "https://node-example-project-main.example.com",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LagoonEnvData(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LagoonEnvData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigMapSha(t *testing.T) {
+	base := []lagoon.EnvironmentVariable{
+		{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+		{Name: "MY_GLOBAL_VAR", Value: "global", Scope: "global"},
+	}
+	reordered := []lagoon.EnvironmentVariable{base[1], base[0]}
+	changed := []lagoon.EnvironmentVariable{base[0], {Name: "MY_GLOBAL_VAR", Value: "changed", Scope: "global"}}
+	buildOnly := append([]lagoon.EnvironmentVariable{{Name: "MY_BUILD_VAR", Value: "build", Scope: "build"}}, base...)
+
+	sha := func(vars []lagoon.EnvironmentVariable, provided string) string {
+		s, err := ConfigMapSha(generator.BuildValues{EnvironmentVariables: vars, ConfigMapSha: provided})
+		if err != nil {
+			t.Fatalf("ConfigMapSha() error = %v", err)
+		}
+		return s
+	}
+	if sha(base, "") != sha(reordered, "") {
+		t.Errorf("ConfigMapSha() changed when the variable order changed")
+	}
+	if sha(base, "") != sha(buildOnly, "") {
+		t.Errorf("ConfigMapSha() changed when a build scoped variable was added")
+	}
+	if sha(base, "") == sha(changed, "") {
+		t.Errorf("ConfigMapSha() didn't change when a variable changed")
+	}
+	if got := sha(base, "abcdefg1234567890"); got != "abcdefg1234567890" {
+		t.Errorf("ConfigMapSha() = %v, want the provided sha abcdefg1234567890", got)
+	}
+}
+
+func TestGenerateLagoonEnvConfigMap(t *testing.T) {
+	values := generator.BuildValues{
+		Project:         "example-project",
+		Environment:     "main",
+		EnvironmentType: "production",
+		BuildType:       "branch",
+		Branch:          "main",
+		LagoonVersion:   "v2.7.x",
+		EnvironmentVariables: []lagoon.EnvironmentVariable{
+			{Name: "LAGOON_PROJECT", Value: "example-project", Scope: "runtime"},
+		},
+		// a provided sha only applies to the workloads, the configmap always has the sha of its own data
+		ConfigMapSha: "abcdefg1234567890",
+	}
+	got, err := GenerateLagoonEnvConfigMap(values)
+	if err != nil {
+		t.Fatalf("GenerateLagoonEnvConfigMap() error = %v", err)
+	}
+	want, _ := LagoonEnvSha(LagoonEnvData(values))
+	if got.Name != LagoonEnvName {
+		t.Errorf("GenerateLagoonEnvConfigMap() name = %v, want %v", got.Name, LagoonEnvName)
+	}
+	if got.Annotations["lagoon.sh/configMapSha"] != want {
+		t.Errorf("GenerateLagoonEnvConfigMap() sha = %v, want %v", got.Annotations["lagoon.sh/configMapSha"], want)
+	}
+	if !reflect.DeepEqual(got.Data, LagoonEnvData(values)) {
+		t.Errorf("GenerateLagoonEnvConfigMap() data = %v, want %v", got.Data, LagoonEnvData(values))
+	}
+}
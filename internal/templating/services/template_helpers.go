@@ -1,8 +1,15 @@
 package services
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 // LinkedServiceCalculator checks the provided services to see if there are any linked services
@@ -49,3 +56,121 @@ func LinkedServiceCalculator(services []generator.ServiceValues) []generator.Ser
 	}
 	return retServices
 }
+
+// applyResourceOverrides sets the requests and limits from the resource overrides of a service on a container. The resources
+// are copied first, so the defaults of the service type aren't changed.
+// the quantities were checked when the service values were generated
+func applyResourceOverrides(container *corev1.Container, overrides *lagoon.ResourceOverrides) {
+	container.Resources = *container.Resources.DeepCopy()
+	if overrides == nil {
+		return
+	}
+	if overrides.Requests != nil {
+		container.Resources.Requests = setResourceList(container.Resources.Requests, *overrides.Requests)
+	}
+	if overrides.Limits != nil {
+		container.Resources.Limits = setResourceList(container.Resources.Limits, *overrides.Limits)
+	}
+}
+
+func setResourceList(list corev1.ResourceList, values lagoon.ResourceList) corev1.ResourceList {
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+	if values.CPU != "" {
+		list[corev1.ResourceCPU] = resource.MustParse(values.CPU)
+	}
+	if values.Memory != "" {
+		list[corev1.ResourceMemory] = resource.MustParse(values.Memory)
+	}
+	return list
+}
+
+// hasMemoryLimitOverride checks if the resource overrides of a service set a memory limit
+func hasMemoryLimitOverride(overrides *lagoon.ResourceOverrides) bool {
+	return overrides != nil && overrides.Limits != nil && overrides.Limits.Memory != ""
+}
+
+// applyProbeOverrides sets the timings and thresholds from the probe overrides of a service on the probes of a container
+func applyProbeOverrides(container *corev1.Container, overrides *lagoon.ProbeOverrides) {
+	if overrides == nil {
+		return
+	}
+	container.ReadinessProbe = overrideProbe(container.ReadinessProbe, overrides.Readiness)
+	container.LivenessProbe = overrideProbe(container.LivenessProbe, overrides.Liveness)
+}
+
+// overrideProbe returns a copy of the probe with the overrides set, a probe the service type doesn't have can't be tuned
+func overrideProbe(probe *corev1.Probe, override *lagoon.ProbeOverride) *corev1.Probe {
+	if probe == nil || override == nil {
+		return probe
+	}
+	probe = probe.DeepCopy()
+	if override.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *override.InitialDelaySeconds
+	}
+	if override.PeriodSeconds != nil {
+		probe.PeriodSeconds = *override.PeriodSeconds
+	}
+	if override.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *override.TimeoutSeconds
+	}
+	if override.SuccessThreshold != nil {
+		probe.SuccessThreshold = *override.SuccessThreshold
+	}
+	if override.FailureThreshold != nil {
+		probe.FailureThreshold = *override.FailureThreshold
+	}
+	return probe
+}
+
+// serviceAutoscaling returns the autoscaling of a service, a linked service can set it on either of its docker-compose services,
+// the generator makes sure that only one of them does
+func serviceAutoscaling(serviceValues generator.ServiceValues) *generator.ServiceAutoscaling {
+	if serviceValues.Autoscaling == nil && serviceValues.LinkedService != nil {
+		return serviceValues.LinkedService.Autoscaling
+	}
+	return serviceValues.Autoscaling
+}
+
+// serviceLabelsAndAnnotations returns the labels and annotations that the deployment of a service has, for the resources that
+// are generated alongside the deployment
+func serviceLabelsAndAnnotations(buildValues generator.BuildValues, serviceTypeName string, serviceValues generator.ServiceValues) (map[string]string, map[string]string) {
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "build-deploy-tool",
+		"app.kubernetes.io/name":       serviceTypeName,
+		"app.kubernetes.io/instance":   serviceValues.OverrideName,
+		"lagoon.sh/project":            buildValues.Project,
+		"lagoon.sh/environment":        buildValues.Environment,
+		"lagoon.sh/environmentType":    buildValues.EnvironmentType,
+		"lagoon.sh/buildType":          buildValues.BuildType,
+		"lagoon.sh/template":           fmt.Sprintf("%s-%s", serviceTypeName, "0.1.0"),
+		"lagoon.sh/service":            serviceValues.OverrideName,
+		"lagoon.sh/service-type":       serviceTypeName,
+	}
+	annotations := map[string]string{
+		"lagoon.sh/version": buildValues.LagoonVersion,
+	}
+	if buildValues.BuildType == "branch" {
+		annotations["lagoon.sh/branch"] = buildValues.Branch
+	} else if buildValues.BuildType == "pullrequest" {
+		annotations["lagoon.sh/prNumber"] = buildValues.PRNumber
+		annotations["lagoon.sh/prHeadBranch"] = buildValues.PRHeadBranch
+		annotations["lagoon.sh/prBaseBranch"] = buildValues.PRBaseBranch
+	}
+	return labels, annotations
+}
+
+// imageCacheImage returns the image pulled through the imagecache if one is defined, the imagecache only caches docker hub
+// images so the images of other registries are pulled from their registry
+func imageCacheImage(buildValues generator.BuildValues, image string) string {
+	if buildValues.ImageCache == "" || buildValues.IgnoreImageCache {
+		return image
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil || reference.Domain(named) != "docker.io" {
+		return image
+	}
+	// the imagecache needs the full docker hub path, like `library/busybox` for the `busybox` image
+	return fmt.Sprintf("%s%s", buildValues.ImageCache, strings.TrimPrefix(named.String(), "docker.io/"))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
 	"github.com/uselagoon/build-deploy-tool/internal/servicetypes"
+	"github.com/uselagoon/build-deploy-tool/internal/templating/configmap"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -20,13 +21,19 @@ func GenerateCronjobTemplate(
 ) ([]batchv1.CronJob, error) {
 	var result []batchv1.CronJob
 
+	// the configmap sha is added to the pod template so that any change to the lagoon-env configmap triggers a rollout
+	configMapSha, err := configmap.ConfigMapSha(buildValues)
+	if err != nil {
+		return nil, err
+	}
+
 	// check linked services
 	checkedServices := LinkedServiceCalculator(buildValues.Services)
 
 	// for all the services that the build values generated
 	// iterate over them and generate any kubernetes cronjobs
 	for _, serviceValues := range checkedServices {
-		if val, ok := servicetypes.ServiceTypes[serviceValues.Type]; ok {
+		if val, ok := buildValues.GetServiceType(serviceValues.Type); ok {
 			for _, nCronjob := range serviceValues.NativeCronjobs {
 				serviceTypeValues := &servicetypes.ServiceType{}
 				helpers.DeepCopy(val, serviceTypeValues)
@@ -62,7 +69,7 @@ func GenerateCronjobTemplate(
 				}
 
 				templateAnnotations := make(map[string]string)
-				templateAnnotations["lagoon.sh/configMapSha"] = buildValues.ConfigMapSha
+				templateAnnotations["lagoon.sh/configMapSha"] = configMapSha
 				tpld := struct {
 					ServiceValues     interface{}
 					ServiceTypeValues interface{}
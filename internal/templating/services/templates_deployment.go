@@ -7,7 +7,9 @@ import (
 
 	"github.com/uselagoon/build-deploy-tool/internal/generator"
 	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	"github.com/uselagoon/build-deploy-tool/internal/lagoon"
 	"github.com/uselagoon/build-deploy-tool/internal/servicetypes"
+	"github.com/uselagoon/build-deploy-tool/internal/templating/configmap"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -23,13 +25,19 @@ func GenerateDeploymentTemplate(
 ) ([]appsv1.Deployment, error) {
 	var deployments []appsv1.Deployment
 
+	// the configmap sha is added to the pod template so that any change to the lagoon-env configmap triggers a rollout
+	configMapSha, err := configmap.ConfigMapSha(buildValues)
+	if err != nil {
+		return nil, err
+	}
+
 	// check linked services
 	checkedServices := LinkedServiceCalculator(buildValues.Services)
 
 	// for all the services that the build values generated
 	// iterate over them and generate any kubernetes deployments
 	for _, serviceValues := range checkedServices {
-		if val, ok := servicetypes.ServiceTypes[serviceValues.Type]; ok {
+		if val, ok := buildValues.GetServiceType(serviceValues.Type); ok {
 			serviceTypeValues := &servicetypes.ServiceType{}
 			helpers.DeepCopy(val, serviceTypeValues)
 
@@ -64,7 +72,7 @@ func GenerateDeploymentTemplate(
 			}
 
 			templateAnnotations := make(map[string]string)
-			templateAnnotations["lagoon.sh/configMapSha"] = buildValues.ConfigMapSha
+			templateAnnotations["lagoon.sh/configMapSha"] = configMapSha
 			tpld := struct {
 				ServiceValues     interface{}
 				ServiceTypeValues interface{}
@@ -269,6 +277,10 @@ func GenerateDeploymentTemplate(
 			if serviceValues.Replicas != 0 {
 				deployment.Spec.Replicas = helpers.Int32Ptr(serviceValues.Replicas)
 			}
+			// the replicas are left unset if a horizontal pod autoscaler owns them, so applying the deployment doesn't reset them
+			if serviceAutoscaling(serviceValues) != nil {
+				deployment.Spec.Replicas = nil
+			}
 			deployment.Spec.Selector = &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					"app.kubernetes.io/name":     serviceTypeValues.Name,
@@ -594,8 +606,13 @@ func GenerateDeploymentTemplate(
 				deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, volume)
 			}
 
+			// set the resource and probe overrides of the service from its labels or the .lagoon.yml
+			applyResourceOverrides(&container.Container, serviceValues.Resources)
+			applyProbeOverrides(&container.Container, serviceValues.Probes)
+
 			// set the resource limit overrides if htey are provided
-			if buildValues.Resources.Limits.Memory != "" {
+			// the memory limit is only used if the service doesn't set its own, which can't be higher than this
+			if buildValues.Resources.Limits.Memory != "" && !hasMemoryLimitOverride(serviceValues.Resources) {
 				if container.Container.Resources.Limits == nil {
 					container.Container.Resources.Limits = corev1.ResourceList{}
 				}
@@ -693,12 +710,133 @@ func GenerateDeploymentTemplate(
 					helpers.TemplateThings(tpld, svm, &volumeMount)
 					linkedContainer.Container.VolumeMounts = append(linkedContainer.Container.VolumeMounts, volumeMount)
 				}
+				applyResourceOverrides(&linkedContainer.Container, serviceValues.LinkedService.Resources)
+				applyProbeOverrides(&linkedContainer.Container, serviceValues.LinkedService.Probes)
 				deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, linkedContainer.Container)
 			}
 
+			// add the additional containers and init containers from the .lagoon.yml, a linked service can add them from either of its
+			// docker-compose services
+			containers := serviceValues.Containers
+			initContainers := serviceValues.InitContainers
+			if serviceValues.LinkedService != nil {
+				containers = append(append([]lagoon.Container{}, containers...), serviceValues.LinkedService.Containers...)
+				initContainers = append(append([]lagoon.Container{}, initContainers...), serviceValues.LinkedService.InitContainers...)
+			}
+			for _, c := range containers {
+				container, err := generateAdditionalContainer(buildValues, serviceValues, c, deployment.Spec.Template.Spec)
+				if err != nil {
+					return nil, err
+				}
+				deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, container)
+			}
+			for _, c := range initContainers {
+				container, err := generateAdditionalContainer(buildValues, serviceValues, c, deployment.Spec.Template.Spec)
+				if err != nil {
+					return nil, err
+				}
+				deployment.Spec.Template.Spec.InitContainers = append(deployment.Spec.Template.Spec.InitContainers, container)
+			}
+
 			// end deployment template
 			deployments = append(deployments, *deployment)
 		}
 	}
 	return deployments, nil
 }
+
+// generateAdditionalContainer generates an additional container of a service from the .lagoon.yml. The container gets the same
+// environment as the primary container, can mount the volumes the pod already has, and has small default requests so that
+// resource utilization can still be measured for the pod. The memory and ephemeral storage limits of the build are set like
+// they are on the primary container.
+func generateAdditionalContainer(
+	buildValues generator.BuildValues,
+	serviceValues generator.ServiceValues,
+	c lagoon.Container,
+	podSpec corev1.PodSpec,
+) (corev1.Container, error) {
+	for _, existing := range append(append([]corev1.Container{}, podSpec.Containers...), podSpec.InitContainers...) {
+		if existing.Name == c.Name {
+			return corev1.Container{}, fmt.Errorf("container %s of service %s has the same name as another container in the service", c.Name, serviceValues.OverrideName)
+		}
+	}
+	container := corev1.Container{
+		Name:    c.Name,
+		Command: c.Command,
+		Env: []corev1.EnvVar{
+			{
+				Name:  "LAGOON_GIT_SHA",
+				Value: buildValues.GitSHA,
+			},
+			{
+				Name:  "SERVICE_NAME",
+				Value: serviceValues.OverrideName,
+			},
+		},
+		EnvFrom: []corev1.EnvFromSource{
+			{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: "lagoon-env",
+					},
+				},
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+				corev1.ResourceMemory: resource.MustParse("10Mi"),
+			},
+		},
+	}
+	// the resource limits of the build apply to every container, the additional containers have no overrides to replace them
+	if buildValues.Resources.Limits.Memory != "" {
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		container.Resources.Limits[corev1.ResourceMemory] = resource.MustParse(buildValues.Resources.Limits.Memory)
+	}
+	if buildValues.Resources.Limits.EphemeralStorage != "" {
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		container.Resources.Limits[corev1.ResourceEphemeralStorage] = resource.MustParse(buildValues.Resources.Limits.EphemeralStorage)
+	}
+	if c.Service != "" {
+		// the image built for a docker-compose service
+		image, ok := buildValues.ImageReferences[c.Service]
+		if !ok {
+			return corev1.Container{}, fmt.Errorf("no image reference was found for service %s used by container %s of service %s", c.Service, c.Name, serviceValues.OverrideName)
+		}
+		container.Image = image
+	} else {
+		// external docker hub images are pulled through the imagecache if one is defined
+		container.Image = imageCacheImage(buildValues, c.Image)
+	}
+	for _, dds := range buildValues.DynamicDBaaSSecrets {
+		container.EnvFrom = append(container.EnvFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: dds,
+				},
+			},
+		})
+	}
+	for _, cv := range c.Volumes {
+		name := ""
+		for _, volume := range podSpec.Volumes {
+			// custom volumes from the docker-compose file are referenced by the name in the docker-compose file
+			if volume.Name == cv.Name || volume.Name == lagoon.GetLagoonVolumeName(cv.Name) {
+				name = volume.Name
+			}
+		}
+		if name == "" {
+			return corev1.Container{}, fmt.Errorf("container %s of service %s mounts volume %s, which isn't a volume of the service", c.Name, serviceValues.OverrideName, cv.Name)
+		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: cv.Path,
+		})
+	}
+	return container, nil
+}
@@ -912,6 +912,66 @@ func TestGenerateDeploymentTemplate(t *testing.T) {
 			},
 			want: "test-resources/deployment/result-basic-4.yaml",
 		},
+		{
+			name: "test20 - node with additional containers",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "example-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					GitSHA:          "0",
+					ConfigMapSha:    "32bf1359ac92178c8909f0ef938257b477708aa0d78a5a15ad7c2d7919adf273",
+					ImageCache:      "imagecache.example.com/",
+					Resources: generator.Resources{
+						Limits: generator.ResourceLimits{
+							Memory:           "16Gi",
+							EphemeralStorage: "160Gi",
+						},
+					},
+					ImageReferences: map[string]string{
+						"node-persist": "harbor.example.com/example-project/environment-name/node-persist@latest",
+					},
+					Services: []generator.ServiceValues{
+						{
+							Name:         "node-persist",
+							OverrideName: "node-persist",
+							Type:         "node-persistent",
+							Containers: []lagoon.Container{
+								{
+									Name:    "log-shipper",
+									Image:   "fluent/fluent-bit:3.0",
+									Command: []string{"/fluent-bit/bin/fluent-bit", "-i", "tail", "-p", "path=/app/logs/*.log", "-o", "stdout"},
+									Volumes: []lagoon.ContainerVolume{
+										{
+											Name: "node-persist",
+											Path: "/app/logs",
+										},
+									},
+								},
+								{
+									Name:    "cloud-sql-proxy",
+									Image:   "gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.8",
+									Command: []string{"/cloud-sql-proxy", "--port=3306", "project:region:instance"},
+								},
+							},
+							InitContainers: []lagoon.Container{
+								{
+									Name:    "migrate",
+									Service: "node-persist",
+									Command: []string{"/bin/sh", "-c", "yarn migrate"},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: "test-resources/deployment/result-node-2.yaml",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+)
+
+// GenerateHPATemplate generates the horizontal pod autoscalers for the services that autoscale.
+func GenerateHPATemplate(
+	buildValues generator.BuildValues,
+) ([]autoscalingv2.HorizontalPodAutoscaler, error) {
+	var hpas []autoscalingv2.HorizontalPodAutoscaler
+
+	// check linked services
+	checkedServices := LinkedServiceCalculator(buildValues.Services)
+
+	// for all the services that the build values generated
+	// iterate over them and generate any horizontal pod autoscalers
+	for _, serviceValues := range checkedServices {
+		autoscaling := serviceAutoscaling(serviceValues)
+		if autoscaling == nil {
+			continue
+		}
+		serviceType, ok := buildValues.GetServiceType(serviceValues.Type)
+		if !ok {
+			continue
+		}
+		labels, annotations := serviceLabelsAndAnnotations(buildValues, serviceType.Name, serviceValues)
+		hpa := autoscalingv2.HorizontalPodAutoscaler{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "HorizontalPodAutoscaler",
+				APIVersion: fmt.Sprintf("%s/%s", autoscalingv2.SchemeGroupVersion.Group, autoscalingv2.SchemeGroupVersion.Version),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        serviceValues.OverrideName,
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+					APIVersion: fmt.Sprintf("%s/%s", appsv1.SchemeGroupVersion.Group, appsv1.SchemeGroupVersion.Version),
+					Kind:       "Deployment",
+					Name:       serviceValues.OverrideName,
+				},
+				MinReplicas: helpers.Int32Ptr(autoscaling.MinReplicas),
+				MaxReplicas: autoscaling.MaxReplicas,
+			},
+		}
+		if autoscaling.TargetCPUUtilization > 0 {
+			hpa.Spec.Metrics = append(hpa.Spec.Metrics, utilizationMetric(corev1.ResourceCPU, autoscaling.TargetCPUUtilization))
+		}
+		if autoscaling.TargetMemoryUtilization > 0 {
+			hpa.Spec.Metrics = append(hpa.Spec.Metrics, utilizationMetric(corev1.ResourceMemory, autoscaling.TargetMemoryUtilization))
+		}
+		// validate any annotations
+		if err := apivalidation.ValidateAnnotations(hpa.ObjectMeta.Annotations, nil); err != nil {
+			if len(err) != 0 {
+				return nil, fmt.Errorf("the annotations for %s are not valid: %v", serviceValues.OverrideName, err)
+			}
+		}
+		// validate any labels
+		if err := metavalidation.ValidateLabels(hpa.ObjectMeta.Labels, nil); err != nil {
+			if len(err) != 0 {
+				return nil, fmt.Errorf("the labels for %s are not valid: %v", serviceValues.OverrideName, err)
+			}
+		}
+		// check length of labels
+		if err := helpers.CheckLabelLength(hpa.ObjectMeta.Labels); err != nil {
+			return nil, err
+		}
+		hpas = append(hpas, hpa)
+	}
+	return hpas, nil
+}
+
+// utilizationMetric is a metric that scales on the average utilization of a resource, as a percentage of the requests
+func utilizationMetric(name corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: helpers.Int32Ptr(utilization),
+			},
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/andreyvit/diff"
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerateHPATemplate(t *testing.T) {
+	type args struct {
+		buildValues generator.BuildValues
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "test1 - autoscaling services",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					Services: []generator.ServiceValues{
+						{
+							Name:         "node",
+							OverrideName: "node",
+							Type:         "node",
+							Autoscaling: &generator.ServiceAutoscaling{
+								MinReplicas:          2,
+								MaxReplicas:          5,
+								TargetCPUUtilization: 70,
+							},
+						},
+						{
+							Name:         "nginx",
+							OverrideName: "nginx",
+							Type:         "nginx-php",
+						},
+						{
+							Name:         "php",
+							OverrideName: "nginx",
+							Type:         "nginx-php",
+							Autoscaling: &generator.ServiceAutoscaling{
+								MinReplicas:             3,
+								MaxReplicas:             10,
+								TargetCPUUtilization:    80,
+								TargetMemoryUtilization: 90,
+							},
+						},
+						{
+							Name:         "myservice",
+							OverrideName: "myservice",
+							Type:         "basic",
+						},
+					},
+				},
+			},
+			want: "test-resources/hpa/result-autoscaling-1.yaml",
+		},
+		{
+			name: "test2 - no autoscaling services",
+			args: args{
+				buildValues: generator.BuildValues{
+					Project:         "example-project",
+					Environment:     "environment-name",
+					EnvironmentType: "production",
+					Namespace:       "myexample-project-environment-name",
+					BuildType:       "branch",
+					LagoonVersion:   "v2.x.x",
+					Kubernetes:      "generator.local",
+					Branch:          "environment-name",
+					Services: []generator.ServiceValues{
+						{
+							Name:         "node",
+							OverrideName: "node",
+							Type:         "node",
+						},
+					},
+				},
+			},
+			want: "test-resources/hpa/result-none-1.yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateHPATemplate(tt.args.buildValues)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateHPATemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			r1, err := os.ReadFile(tt.want)
+			if err != nil {
+				t.Errorf("couldn't read file %v: %v", tt.want, err)
+			}
+			separator := []byte("---\n")
+			var result []byte
+			for _, d := range got {
+				hpaBytes, err := yaml.Marshal(d)
+				if err != nil {
+					t.Errorf("couldn't generate template  %v", err)
+				}
+				restoreResult := append(separator[:], hpaBytes[:]...)
+				result = append(result, restoreResult[:]...)
+			}
+			if !reflect.DeepEqual(string(result), string(r1)) {
+				t.Errorf("GenerateHPATemplate() = \n%v", diff.LineDiff(string(r1), string(result)))
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/uselagoon/build-deploy-tool/internal/generator"
+	"github.com/uselagoon/build-deploy-tool/internal/helpers"
+	policyv1 "k8s.io/api/policy/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+)
+
+// GeneratePodDisruptionBudgetTemplate generates the pod disruption budgets for the services that run more than one replica or
+// autoscale, so that a node drain can't evict all of their pods at once.
+func GeneratePodDisruptionBudgetTemplate(
+	buildValues generator.BuildValues,
+) ([]policyv1.PodDisruptionBudget, error) {
+	var pdbs []policyv1.PodDisruptionBudget
+	if !buildValues.PodDisruptionBudget.Enabled {
+		return pdbs, nil
+	}
+
+	// check linked services
+	checkedServices := LinkedServiceCalculator(buildValues.Services)
+
+	// for all the services that the build values generated
+	// iterate over them and generate any pod disruption budgets
+	for _, serviceValues := range checkedServices {
+		if serviceValues.Replicas < 2 && serviceAutoscaling(serviceValues) == nil {
+			continue
+		}
+		serviceType, ok := buildValues.GetServiceType(serviceValues.Type)
+		if !ok {
+			continue
+		}
+		labels, annotations := serviceLabelsAndAnnotations(buildValues, serviceType.Name, serviceValues)
+		maxUnavailable := buildValues.PodDisruptionBudget.MaxUnavailable
+		pdb := policyv1.PodDisruptionBudget{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "PodDisruptionBudget",
+				APIVersion: fmt.Sprintf("%s/%s", policyv1.SchemeGroupVersion.Group, policyv1.SchemeGroupVersion.Version),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        serviceValues.OverrideName,
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &maxUnavailable,
+				// the pods of the deployment, the cronjob pods of the service have a different name and instance
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app.kubernetes.io/name":     serviceType.Name,
+						"app.kubernetes.io/instance": serviceValues.OverrideName,
+						"lagoon.sh/service":          serviceValues.OverrideName,
+					},
+				},
+			},
+		}
+		// validate any annotations
+		if err := apivalidation.ValidateAnnotations(pdb.ObjectMeta.Annotations, nil); err != nil {
+			if len(err) != 0 {
+				return nil, fmt.Errorf("the annotations for %s are not valid: %v", serviceValues.OverrideName, err)
+			}
+		}
+		// validate any labels
+		if err := metavalidation.ValidateLabels(pdb.ObjectMeta.Labels, nil); err != nil {
+			if len(err) != 0 {
+				return nil, fmt.Errorf("the labels for %s are not valid: %v", serviceValues.OverrideName, err)
+			}
+		}
+		// check length of labels
+		if err := helpers.CheckLabelLength(pdb.ObjectMeta.Labels); err != nil {
+			return nil, err
+		}
+		pdbs = append(pdbs, pdb)
+	}
+	return pdbs, nil
+}
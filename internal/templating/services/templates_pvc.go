@@ -50,7 +50,7 @@ func GeneratePVCTemplate(
 	// for all the services that the build values generated
 	// iterate over them and generate any kubernetes services
 	for _, serviceValues := range checkedServices {
-		if val, ok := servicetypes.ServiceTypes[serviceValues.Type]; ok {
+		if val, ok := buildValues.GetServiceType(serviceValues.Type); ok {
 			if val.Volumes.PersistentVolumeSize != "" {
 				pvc, err := generateDefaultPVC(buildValues, serviceValues, val, labels, annotations)
 				if err != nil {
@@ -49,7 +49,7 @@ func GenerateServiceTemplate(
 	// for all the services that the build values generated
 	// iterate over them and generate any kubernetes services
 	for _, serviceValues := range checkedServices {
-		if val, ok := servicetypes.ServiceTypes[serviceValues.Type]; ok {
+		if val, ok := buildValues.GetServiceType(serviceValues.Type); ok {
 			serviceType := &servicetypes.ServiceType{}
 			helpers.DeepCopy(val, serviceType)
 			service, err := GenerateService(serviceType, serviceValues, labels, annotations)
@@ -161,8 +161,21 @@ if [ ! -z "$(featureFlag IMAGECACHE_REGISTRY)" ]; then
   [[ $last_char != "/" ]] && IMAGECACHE_REGISTRY="$IMAGECACHE_REGISTRY/"; :
 fi
 
+# Load the values of the .lagoon.yml that the build uses, build-deploy-tool reads both versions of the .lagoon.yml and
+# merges in any overrides
+set +e
+LAGOON_YML_VALUES=$(build-deploy-tool identify lagoon-yml)
+if [ $? -ne 0 ]; then
+  echo "
+##############################################
+Unable to read the .lagoon.yml file, the error is above
+##############################################"
+  exit 1
+fi
+set -e
+
 # Load path of docker-compose that should be used
-DOCKER_COMPOSE_YAML=($(cat .lagoon.yml | shyaml get-value docker-compose-yaml))
+DOCKER_COMPOSE_YAML=$(echo "${LAGOON_YML_VALUES}" | jq -r '.dockerComposeYaml')
 
 echo "Updating lagoon-yaml configmap with a pre-deploy version of the .lagoon.yml file"
 if kubectl -n ${NAMESPACE} get configmap lagoon-yaml &> /dev/null; then
@@ -308,16 +321,6 @@ https://docs.lagoon.sh/using-lagoon-the-basics/lagoon-yml/
 fi
 set -e
 
-# Validate .lagoon.yml only, no overrides. lagoon-linter still has checks that
-# aren't in build-deploy-tool.
-if ! lagoon-linter; then
-	echo "https://docs.lagoon.sh/lagoon/using-lagoon-the-basics/lagoon-yml#restrictions describes some possible reasons for this build failure."
-	echo "If you require assistance to fix this error, please contact support."
-	exit 1
-else
-	echo "lagoon-linter found no issues with the .lagoon.yml file"
-fi
-
 ##################
 # build deploy-tool can collect this value now from the lagoon.yml file
 # this means further use of `LAGOON_GIT_SHA` can eventually be
@@ -328,7 +331,7 @@ fi
 #
 #   export LAGOON_GIT_SHA=`git rev-parse HEAD`
 #
-INJECT_GIT_SHA=$(cat .lagoon.yml | shyaml get-value environment_variables.git_sha false)
+INJECT_GIT_SHA=$(echo "${LAGOON_YML_VALUES}" | jq -r '.gitSha')
 if [ "$INJECT_GIT_SHA" == "true" ]
 then
   # export this so the build-deploy-tool can read it
@@ -342,7 +345,6 @@ currentStepEnd="$(date +"%Y-%m-%d %H:%M:%S")"
 patchBuildStep "${buildStartTime}" "${previousStepEnd}" "${currentStepEnd}" "${NAMESPACE}" "lagoonYmlValidation" ".lagoon.yml Validation" "false"
 previousStepEnd=${currentStepEnd}
 beginBuildStep "Configure Variables" "configuringVariables"
-DEPLOY_TYPE=$(cat .lagoon.yml | shyaml get-value environments.${BRANCH//./\\.}.deploy-type default)
 
 # Load all Services that are defined
 COMPOSE_SERVICES=($(cat $DOCKER_COMPOSE_YAML | shyaml keys services))
@@ -426,7 +428,7 @@ do
   SERVICE_TYPE=$(cat $DOCKER_COMPOSE_YAML | shyaml get-value services.$COMPOSE_SERVICE.labels.lagoon\\.type custom)
 
   # Allow the servicetype to be overriden by environment in .lagoon.yml
-  ENVIRONMENT_SERVICE_TYPE_OVERRIDE=$(cat .lagoon.yml | shyaml get-value environments.${BRANCH//./\\.}.types.$SERVICE_NAME false)
+  ENVIRONMENT_SERVICE_TYPE_OVERRIDE=$(echo "${LAGOON_YML_VALUES}" | jq -r --arg service "$SERVICE_NAME" '.types[$service] // false')
   if [ ! $ENVIRONMENT_SERVICE_TYPE_OVERRIDE == "false" ]; then
     SERVICE_TYPE=$ENVIRONMENT_SERVICE_TYPE_OVERRIDE
   fi
@@ -807,15 +809,18 @@ FASTLY_API_SECRET_PREFIX="fastly-api-"
 
 FASTLY_API_SECRETS_COUNTER=0
 FASTLY_API_SECRETS=()
-if [ -n "$(cat .lagoon.yml | shyaml keys fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER 2> /dev/null)" ]; then
-  while [ -n "$(cat .lagoon.yml | shyaml get-value fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER 2> /dev/null)" ]; do
-    FASTLY_API_SECRET_NAME=$FASTLY_API_SECRET_PREFIX$(cat .lagoon.yml | shyaml get-value fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER.name 2> /dev/null)
-    if [ -z "$FASTLY_API_SECRET_NAME" ]; then
+# the api secrets are read from the merged .lagoon.yml, so this works for either version of the .lagoon.yml and the override
+FASTLY_API_SECRETS_COUNT=$(echo "${LAGOON_YML_VALUES}" | jq -r '.fastlyApiSecrets | length')
+if [ "$FASTLY_API_SECRETS_COUNT" -gt 0 ]; then
+  while [ $FASTLY_API_SECRETS_COUNTER -lt $FASTLY_API_SECRETS_COUNT ]; do
+    FASTLY_API_SECRET_VALUE_NAME=$(echo "${LAGOON_YML_VALUES}" | jq -r --argjson idx $FASTLY_API_SECRETS_COUNTER '.fastlyApiSecrets[$idx].name // empty')
+    if [ -z "$FASTLY_API_SECRET_VALUE_NAME" ]; then
         echo -e "A fastly api secret was defined in the .lagoon.yml file, but no name could be found the .lagoon.yml\n\nPlease check if the name has been set correctly."
         exit 1
     fi
-    FASTLY_API_TOKEN_VALUE=$(cat .lagoon.yml | shyaml get-value fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER.apiTokenVariableName false)
-    if [[ $FASTLY_API_TOKEN_VALUE == "false" ]]; then
+    FASTLY_API_SECRET_NAME=$FASTLY_API_SECRET_PREFIX$FASTLY_API_SECRET_VALUE_NAME
+    FASTLY_API_TOKEN_VALUE=$(echo "${LAGOON_YML_VALUES}" | jq -r --argjson idx $FASTLY_API_SECRETS_COUNTER '.fastlyApiSecrets[$idx].apiTokenVariableName // empty')
+    if [ -z "$FASTLY_API_TOKEN_VALUE" ]; then
       echo "No 'apiTokenVariableName' defined for fastly secret $FASTLY_API_SECRET_NAME"; exit 1;
     fi
     # if we have everything we need, we can proceed to logging in
@@ -836,7 +841,7 @@ if [ -n "$(cat .lagoon.yml | shyaml keys fastly.api-secrets.$FASTLY_API_SECRETS_
         exit 1
       fi
     fi
-    FASTLY_API_PLATFORMTLS_CONFIGURATION=$(cat .lagoon.yml | shyaml get-value fastly.api-secrets.$FASTLY_API_SECRETS_COUNTER.platformTLSConfiguration "")
+    FASTLY_API_PLATFORMTLS_CONFIGURATION=$(echo "${LAGOON_YML_VALUES}" | jq -r --argjson idx $FASTLY_API_SECRETS_COUNTER '.fastlyApiSecrets[$idx].platformTLSConfiguration // empty')
     if [ -z "$FASTLY_API_PLATFORMTLS_CONFIGURATION" ]; then
       echo -e "A fastly api secret was defined in the .lagoon.yml file, but no platform tls configuration id could be found in the .lagoon.yml\n\nPlease check if the platform tls configuration id has been set correctly."
       exit 1
@@ -1559,6 +1564,64 @@ if [ "$(ls -A $LAGOON_SERVICES_YAML_FOLDER/)" ]; then
   fi
 fi
 
+##############################################
+### CLEANUP HORIZONTAL POD AUTOSCALERS of services that no longer autoscale
+##############################################
+
+# a service that no longer autoscales sets its replicas again, so its horizontal pod autoscaler is removed straight away
+CURRENT_HPAS=$(kubectl -n ${NAMESPACE} get hpa -l app.kubernetes.io/managed-by=build-deploy-tool --no-headers 2>/dev/null | cut -d " " -f 1 | xargs)
+MATCHED_HPA=false
+DELETE_HPAS=()
+HPA_CLEANUP_ARRAY=$(build-deploy-tool identify horizontal-pod-autoscalers | jq -r '.[]')
+for SINGLE_HPA in $CURRENT_HPAS; do
+  for CLEANUP_HPA in ${HPA_CLEANUP_ARRAY[@]}; do
+    if [ "${SINGLE_HPA}" == "${CLEANUP_HPA}" ]; then
+      MATCHED_HPA=true
+      continue
+    fi
+  done
+  if [ "${MATCHED_HPA}" != "true" ]; then
+    DELETE_HPAS+=($SINGLE_HPA)
+  fi
+  MATCHED_HPA=false
+done
+for DH in ${!DELETE_HPAS[@]}; do
+  # delete any horizontal pod autoscalers if the service no longer autoscales
+  if kubectl -n ${NAMESPACE} get hpa ${DELETE_HPAS[$DH]} &> /dev/null; then
+    echo ">> Removing horizontal pod autoscaler ${DELETE_HPAS[$DH]} because the service no longer autoscales"
+    kubectl -n ${NAMESPACE} delete hpa ${DELETE_HPAS[$DH]}
+  fi
+done
+
+##############################################
+### CLEANUP POD DISRUPTION BUDGETS of services that no longer run more than one replica
+##############################################
+
+# this also removes every pod disruption budget if they have been disabled with the POD_DISRUPTION_BUDGET feature flag
+CURRENT_PDBS=$(kubectl -n ${NAMESPACE} get pdb -l app.kubernetes.io/managed-by=build-deploy-tool --no-headers 2>/dev/null | cut -d " " -f 1 | xargs)
+MATCHED_PDB=false
+DELETE_PDBS=()
+PDB_CLEANUP_ARRAY=$(build-deploy-tool identify pod-disruption-budgets | jq -r '.[]')
+for SINGLE_PDB in $CURRENT_PDBS; do
+  for CLEANUP_PDB in ${PDB_CLEANUP_ARRAY[@]}; do
+    if [ "${SINGLE_PDB}" == "${CLEANUP_PDB}" ]; then
+      MATCHED_PDB=true
+      continue
+    fi
+  done
+  if [ "${MATCHED_PDB}" != "true" ]; then
+    DELETE_PDBS+=($SINGLE_PDB)
+  fi
+  MATCHED_PDB=false
+done
+for DP in ${!DELETE_PDBS[@]}; do
+  # delete any pod disruption budgets that are no longer needed
+  if kubectl -n ${NAMESPACE} get pdb ${DELETE_PDBS[$DP]} &> /dev/null; then
+    echo ">> Removing pod disruption budget ${DELETE_PDBS[$DP]} because it is no longer needed"
+    kubectl -n ${NAMESPACE} delete pdb ${DELETE_PDBS[$DP]}
+  fi
+done
+
 ##############################################
 ### WAIT FOR POST-ROLLOUT TO BE FINISHED
 ##############################################